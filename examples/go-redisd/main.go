@@ -21,8 +21,12 @@
 	 go-redisd [OPTIONS]
 
 	OPTIONS
-	-v      : Enable verbose output.
-	-p      : Enable profiling.
+	-config        : Load the specified redis.conf format file.
+	-debug         : Enable debugging log output.
+	-profile       : Enable profiling.
+	-metrics-port  : Enable Prometheus metrics with the specified port.
+	-bind          : Listen on the specified addresses separated by spaces.
+	-protected-mode: Accept only loopback clients when no password is set.
 
 	RETURN VALUE
 	  Return EXIT_SUCCESS or EXIT_FAILURE
@@ -31,15 +35,18 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	clog "github.com/cybergarage/go-logger/log"
 	"github.com/cybergarage/go-redis/examples/go-redisd/server"
+	"github.com/cybergarage/go-redis/redis"
 )
 
 const (
@@ -47,8 +54,12 @@ const (
 )
 
 func main() {
+	configFile := flag.String("config", "", "load the specified redis.conf format file")
 	isDebugEnabled := flag.Bool("debug", false, "enable debugging log output")
 	isProfileEnabled := flag.Bool("profile", false, "enable profiling server")
+	metricsPort := flag.Int("metrics-port", 0, "enable Prometheus metrics server with the specified port")
+	bind := flag.String("bind", "", "listen on the specified addresses separated by spaces")
+	isProtectedModeEnabled := flag.Bool("protected-mode", redis.DefaultProtectedMode, "accept only loopback clients when no password is set")
 	flag.Parse()
 
 	logLevel := clog.LevelTrace
@@ -65,6 +76,24 @@ func main() {
 	}
 
 	server := server.NewServer()
+	if 0 < len(*configFile) {
+		if err := server.LoadConfigFile(*configFile); err != nil {
+			clog.Errorf("%s couldn't load %s (%s)", programName, *configFile, err.Error())
+			os.Exit(1)
+		}
+	}
+
+	// The specified options override the config file.
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "metrics-port":
+			server.SetMetricsPort(*metricsPort)
+		case "bind":
+			server.SetBind(strings.Fields(*bind)...)
+		case "protected-mode":
+			server.SetProtectedMode(*isProtectedModeEnabled)
+		}
+	})
 	if err := server.Start(); err != nil {
 		clog.Errorf("%s couldn't be started (%s)", programName, err.Error())
 		os.Exit(1)
@@ -82,21 +111,31 @@ func main() {
 
 	go func() {
 		for {
-			s := <-sigCh
-			switch s {
-			case syscall.SIGHUP:
-				clog.Infof("caught SIGHUP, restarting...")
-				if err := server.Restart(); err != nil {
-					clog.Errorf("%s couldn't be restarted (%s)", programName, err.Error())
-					os.Exit(1)
-				}
-			case syscall.SIGINT, syscall.SIGTERM:
-				clog.Infof("caught %s, stopping...", s.String())
-				if err := server.Stop(); err != nil {
-					clog.Errorf("%s couldn't be stopped (%s)", programName, err.Error())
-					os.Exit(1)
+			select {
+			case s := <-sigCh:
+				switch s {
+				case syscall.SIGHUP:
+					clog.Infof("caught SIGHUP, restarting...")
+					if err := server.Restart(); err != nil {
+						clog.Errorf("%s couldn't be restarted (%s)", programName, err.Error())
+						os.Exit(1)
+					}
+				case syscall.SIGINT, syscall.SIGTERM:
+					clog.Infof("caught %s, stopping...", s.String())
+					ctx, cancel := context.WithTimeout(context.Background(), redis.DefaultShutdownTimeout)
+					err := server.Shutdown(ctx)
+					cancel()
+					if err != nil {
+						clog.Errorf("%s couldn't be stopped (%s)", programName, err.Error())
+						os.Exit(1)
+					}
+					exitCh <- 0
+					return
 				}
+			case <-server.Done():
+				clog.Infof("%s shut down", programName)
 				exitCh <- 0
+				return
 			}
 		}
 	}()
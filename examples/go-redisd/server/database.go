@@ -15,7 +15,6 @@
 package server
 
 import (
-	"fmt"
 	"time"
 
 	"github.com/cybergarage/go-redis/redis"
@@ -42,7 +41,7 @@ func (db *Database) GetListRecord(key string) (*Record, *List, error) {
 		var ok bool
 		list, ok = record.Data.(*List)
 		if !ok {
-			return nil, nil, fmt.Errorf(errorInvalidStoredDataType, record.Data)
+			return nil, nil, redis.ErrWrongType
 		}
 	}
 	if !hasRecord {
@@ -65,7 +64,7 @@ func (db *Database) GetSetRecord(key string) (*Record, *Set, error) {
 		var ok bool
 		set, ok = record.Data.(*Set)
 		if !ok {
-			return nil, nil, fmt.Errorf(errorInvalidStoredDataType, record.Data)
+			return nil, nil, redis.ErrWrongType
 		}
 	}
 	if !hasRecord {
@@ -88,7 +87,7 @@ func (db *Database) GetZSetRecord(key string) (*Record, *ZSet, error) {
 		var ok bool
 		zset, ok = record.Data.(*ZSet)
 		if !ok {
-			return nil, nil, fmt.Errorf(errorInvalidStoredDataType, record.Data)
+			return nil, nil, redis.ErrWrongType
 		}
 	}
 	if !hasRecord {
@@ -45,3 +45,32 @@ func (dbs *Databases) GetDatabase(id redis.DatabaseID) (*Database, bool) {
 	db, ok := v.(*Database)
 	return db, ok
 }
+
+// KeyspaceStats returns the keyspace statistics of all databases.
+func (dbs *Databases) KeyspaceStats() (map[redis.DatabaseID]*redis.KeyspaceStats, error) {
+	keyspace := map[redis.DatabaseID]*redis.KeyspaceStats{}
+	dbs.Range(func(key, value any) bool {
+		db, ok := value.(*Database)
+		if !ok {
+			return true
+		}
+		stats := &redis.KeyspaceStats{
+			Keys:    0,
+			Expires: 0,
+		}
+		db.Records.Range(func(key, value any) bool {
+			record, ok := value.(*Record)
+			if !ok {
+				return true
+			}
+			stats.Keys++
+			if 0 < record.TTL {
+				stats.Expires++
+			}
+			return true
+		})
+		keyspace[db.ID] = stats
+		return true
+	})
+	return keyspace, nil
+}
@@ -15,11 +15,7 @@
 package server
 
 import (
-	"errors"
+	"github.com/cybergarage/go-redis/redis"
 )
 
-var ErrNotFound = errors.New("not found")
-
-const (
-	errorInvalidStoredDataType = "invalid stored data type (%T)"
-)
+var ErrNotFound = redis.ErrNoSuchKey
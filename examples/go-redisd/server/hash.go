@@ -67,7 +67,7 @@ func (server *Server) HDel(conn *redis.Conn, key string, fields []string) (*redi
 	}
 	hash, ok := record.Data.(Hash)
 	if !ok {
-		return redis.NewIntegerMessage(0), nil
+		return nil, redis.ErrWrongType
 	}
 	return redis.NewIntegerMessage(hash.Del(fields)), nil
 }
@@ -85,7 +85,7 @@ func (server *Server) HSet(conn *redis.Conn, key string, field string, val strin
 		var ok bool
 		hash, ok = record.Data.(Hash)
 		if !ok {
-			hasRecord = false
+			return nil, redis.ErrWrongType
 		}
 	}
 	if !hasRecord {
@@ -115,7 +115,7 @@ func (server *Server) HGet(conn *redis.Conn, key string, field string) (*redis.M
 	}
 	hash, ok := record.Data.(Hash)
 	if !ok {
-		return redis.NewNilMessage(), nil
+		return nil, redis.ErrWrongType
 	}
 	hashData, ok := hash[field]
 	if !ok {
@@ -138,7 +138,7 @@ func (server *Server) HGetAll(conn *redis.Conn, key string) (*redis.Message, err
 
 	hash, ok := record.Data.(Hash)
 	if !ok {
-		return arrayMsg, nil
+		return nil, redis.ErrWrongType
 	}
 
 	array, _ := arrayMsg.Array()
@@ -0,0 +1,189 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cybergarage/go-redis/redis"
+	"github.com/cybergarage/go-redis/redis/rdb"
+)
+
+// WriteSnapshot writes all records of the databases as an RDB snapshot.
+func (server *Server) WriteSnapshot(w *rdb.Writer) error {
+	now := time.Now()
+	var err error
+	server.Databases.Range(func(key, value any) bool {
+		db, ok := value.(*Database)
+		if !ok {
+			return true
+		}
+		db.Records.Range(func(key, value any) bool {
+			record, ok := value.(*Record)
+			if !ok {
+				return true
+			}
+			var entry *rdb.Entry
+			entry, err = newSnapshotEntry(db.ID, record)
+			if err != nil {
+				return false
+			}
+			if entry.HasExpireAt() && !now.Before(entry.ExpireAt) {
+				return true
+			}
+			err = w.WriteEntry(entry)
+			return err == nil
+		})
+		return err == nil
+	})
+	return err
+}
+
+// LoadSnapshot replaces all records of the databases with the specified RDB snapshot.
+func (server *Server) LoadSnapshot(r *rdb.Reader) error {
+	dbs := NewDatabases()
+	now := time.Now()
+	for {
+		entry, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		record, err := newSnapshotRecord(entry, now)
+		if err != nil {
+			return err
+		}
+		if record == nil {
+			continue
+		}
+		db, ok := dbs.GetDatabase(entry.DatabaseID)
+		if !ok {
+			db = NewDatabaseWithID(entry.DatabaseID)
+			dbs.SetDatabase(db)
+		}
+		db.SetRecord(record)
+	}
+	server.Databases.Range(func(key, value any) bool {
+		server.Databases.Delete(key)
+		return true
+	})
+	dbs.Range(func(key, value any) bool {
+		server.Databases.Store(key, value)
+		return true
+	})
+	return nil
+}
+
+// newSnapshotEntry returns a new RDB entry of the specified record.
+func newSnapshotEntry(id redis.DatabaseID, record *Record) (*rdb.Entry, error) {
+	entry := &rdb.Entry{
+		DatabaseID: id,
+		Key:        record.Key,
+		Type:       rdb.StringType,
+		Value:      nil,
+		ExpireAt:   time.Time{},
+	}
+	if 0 < record.TTL {
+		entry.ExpireAt = record.Timestamp.Add(record.TTL)
+	}
+	switch data := record.Data.(type) {
+	case string:
+		entry.Type = rdb.StringType
+		entry.Value = data
+	case *List:
+		entry.Type = rdb.ListType
+		entry.Value = append([]string{}, data.elements...)
+	case *Set:
+		entry.Type = rdb.SetType
+		entry.Value = append([]string{}, data.members...)
+	case Hash:
+		hash := map[string]string{}
+		for field, val := range data {
+			hash[field] = val
+		}
+		entry.Type = rdb.HashType
+		entry.Value = hash
+	case *ZSet:
+		members := []*rdb.ZSetMember{}
+		for _, member := range data.members {
+			members = append(members, &rdb.ZSetMember{Score: member.Score, Member: member.Member})
+		}
+		entry.Type = rdb.ZSet2Type
+		entry.Value = members
+	default:
+		return nil, fmt.Errorf("%w record type (%s)", redis.ErrNotSupported, record.Key)
+	}
+	return entry, nil
+}
+
+// newSnapshotRecord returns a new record of the specified RDB entry, or nil if the entry has already expired.
+func newSnapshotRecord(entry *rdb.Entry, now time.Time) (*Record, error) {
+	record := &Record{
+		Key:       entry.Key,
+		Data:      nil,
+		Timestamp: now,
+		TTL:       0,
+	}
+	if entry.HasExpireAt() {
+		record.TTL = entry.ExpireAt.Sub(now)
+		if record.TTL <= 0 {
+			return nil, nil
+		}
+	}
+	switch entry.Type {
+	case rdb.StringType:
+		val, ok := entry.Value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w string value (%s)", redis.ErrInvalid, entry.Key)
+		}
+		record.Data = val
+	case rdb.ListType:
+		elems, ok := entry.Value.([]string)
+		if !ok {
+			return nil, fmt.Errorf("%w list value (%s)", redis.ErrInvalid, entry.Key)
+		}
+		record.Data = &List{elements: elems}
+	case rdb.SetType:
+		members, ok := entry.Value.([]string)
+		if !ok {
+			return nil, fmt.Errorf("%w set value (%s)", redis.ErrInvalid, entry.Key)
+		}
+		record.Data = &Set{members: members}
+	case rdb.HashType:
+		hash, ok := entry.Value.(map[string]string)
+		if !ok {
+			return nil, fmt.Errorf("%w hash value (%s)", redis.ErrInvalid, entry.Key)
+		}
+		record.Data = Hash(hash)
+	case rdb.ZSetType, rdb.ZSet2Type:
+		members, ok := entry.Value.([]*rdb.ZSetMember)
+		if !ok {
+			return nil, fmt.Errorf("%w zset value (%s)", redis.ErrInvalid, entry.Key)
+		}
+		zset := NewZSet()
+		for _, member := range members {
+			zset.members = append(zset.members, NewZSetMember(member.Score, member.Member))
+		}
+		record.Data = zset
+	default:
+		return nil, fmt.Errorf("%w record type (%s)", redis.ErrNotSupported, entry.Key)
+	}
+	return record, nil
+}
@@ -77,8 +77,8 @@ func (server *Server) Get(conn *redis.Conn, key string) (*redis.Message, error)
 		return redis.NewNilMessage(), nil
 	}
 	stringData, ok := record.Data.(string)
-	if ok {
-		return redis.NewStringMessage(stringData), nil
+	if !ok {
+		return nil, redis.ErrWrongType
 	}
-	return redis.NewNilMessage(), nil
+	return redis.NewStringMessage(stringData), nil
 }
@@ -1,11 +1,12 @@
 module github.com/cybergarage/go-redis
 
-go 1.22
+go 1.23
 
 require (
 	github.com/cybergarage/go-logger v1.3.4
 	github.com/cybergarage/go-tracing v1.1.3
 	github.com/go-redis/redis v6.15.9+incompatible
+	github.com/yuin/gopher-lua v1.1.2
 )
 
 require (
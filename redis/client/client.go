@@ -0,0 +1,207 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package client implements a simple Redis client which speaks the Redis serialization protocol (RESP).
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/cybergarage/go-redis/redis/proto"
+)
+
+// DefaultDialTimeout is the default timeout to connect to servers.
+const DefaultDialTimeout = time.Second * 5
+
+var (
+	// ErrNotConnected is returned when the client is not connected.
+	ErrNotConnected = errors.New("not connected")
+	// ErrReply is returned when the server replies an error message.
+	ErrReply = errors.New("error reply")
+)
+
+// Client represents a RESP client connection.
+type Client struct {
+	conn       net.Conn
+	parser     *proto.Parser
+	writeMutex sync.Mutex
+}
+
+// NewClient returns a new client which is not connected.
+func NewClient() *Client {
+	return &Client{
+		conn:       nil,
+		parser:     nil,
+		writeMutex: sync.Mutex{},
+	}
+}
+
+// Open connects to the specified address with the default timeout.
+func (client *Client) Open(addr string) error {
+	return client.OpenWithTimeout(addr, DefaultDialTimeout)
+}
+
+// OpenWithTimeout connects to the specified address with the specified timeout.
+func (client *Client) OpenWithTimeout(addr string, timeout time.Duration) error {
+	network := "tcp"
+	if 0 < len(addr) && addr[0] == '/' {
+		network = "unix"
+	}
+	conn, err := net.DialTimeout(network, addr, timeout)
+	if err != nil {
+		return err
+	}
+	client.conn = conn
+	client.parser = proto.NewParserWithReader(client)
+	return nil
+}
+
+// Close closes the connection.
+func (client *Client) Close() error {
+	if client.conn == nil {
+		return nil
+	}
+	return client.conn.Close()
+}
+
+// NetConn returns the underlying network connection.
+func (client *Client) NetConn() net.Conn {
+	return client.conn
+}
+
+// LocalAddr returns the local network address.
+func (client *Client) LocalAddr() net.Addr {
+	if client.conn == nil {
+		return nil
+	}
+	return client.conn.LocalAddr()
+}
+
+// RemoteAddr returns the remote network address.
+func (client *Client) RemoteAddr() net.Addr {
+	if client.conn == nil {
+		return nil
+	}
+	return client.conn.RemoteAddr()
+}
+
+// SetDeadline sets the read and write deadline of the connection.
+func (client *Client) SetDeadline(t time.Time) error {
+	if client.conn == nil {
+		return ErrNotConnected
+	}
+	return client.conn.SetDeadline(t)
+}
+
+// SetReadDeadline sets the deadline for the future Receive and Read calls.
+func (client *Client) SetReadDeadline(t time.Time) error {
+	if client.conn == nil {
+		return ErrNotConnected
+	}
+	return client.conn.SetReadDeadline(t)
+}
+
+// Read reads the raw bytes from the connection. The received messages are not buffered,
+// so Read can be used to read a payload which is not a RESP message between Receive calls.
+func (client *Client) Read(p []byte) (int, error) {
+	if client.conn == nil {
+		return 0, ErrNotConnected
+	}
+	return client.conn.Read(p)
+}
+
+// ReadLine reads the raw bytes until CRLF and returns them without CRLF.
+func (client *Client) ReadLine() (string, error) {
+	line := []byte{}
+	b := make([]byte, 1)
+	for {
+		if _, err := io.ReadFull(client, b); err != nil {
+			return "", err
+		}
+		if b[0] == '\n' {
+			break
+		}
+		line = append(line, b[0])
+	}
+	if 0 < len(line) && line[len(line)-1] == '\r' {
+		line = line[:len(line)-1]
+	}
+	return string(line), nil
+}
+
+// Write writes the raw bytes to the connection exclusively.
+func (client *Client) Write(p []byte) (int, error) {
+	if client.conn == nil {
+		return 0, ErrNotConnected
+	}
+	client.writeMutex.Lock()
+	defer client.writeMutex.Unlock()
+	return client.conn.Write(p)
+}
+
+// NewCommandBytes returns the RESP bytes of the specified command arguments.
+func NewCommandBytes(args ...string) ([]byte, error) {
+	array := proto.NewArray()
+	for _, arg := range args {
+		array.Append(proto.NewMessageWithType(proto.BulkMessage).SetBytes([]byte(arg)))
+	}
+	return proto.NewMessageWithType(proto.ArrayMessage).SetArray(array).RESPBytes()
+}
+
+// Send sends the specified command arguments without waiting for the reply.
+func (client *Client) Send(args ...string) error {
+	b, err := NewCommandBytes(args...)
+	if err != nil {
+		return err
+	}
+	_, err = client.Write(b)
+	return err
+}
+
+// Receive receives a next message. It returns io.EOF if the connection is closed by the server.
+func (client *Client) Receive() (*proto.Message, error) {
+	if client.parser == nil {
+		return nil, ErrNotConnected
+	}
+	msg, err := client.parser.Next()
+	if err != nil {
+		return nil, err
+	}
+	if msg == nil {
+		return nil, io.EOF
+	}
+	return msg, nil
+}
+
+// Do sends the specified command arguments and receives the reply.
+// It returns the reply with ErrReply if the server replies an error message.
+func (client *Client) Do(args ...string) (*proto.Message, error) {
+	if err := client.Send(args...); err != nil {
+		return nil, err
+	}
+	msg, err := client.Receive()
+	if err != nil {
+		return nil, err
+	}
+	if msg.IsError() {
+		b, _ := msg.Bytes()
+		return msg, fmt.Errorf("%w: %s", ErrReply, string(b))
+	}
+	return msg, nil
+}
@@ -0,0 +1,66 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cybergarage/go-redis/redis"
+	"github.com/cybergarage/go-redis/redis/client"
+)
+
+func TestClient(t *testing.T) {
+	server := redis.NewServer()
+	server.SetPort(0)
+	server.Use(func(next redis.Executor) redis.Executor {
+		return func(conn *redis.Conn, cmd string, args redis.Arguments) (*redis.Message, error) {
+			if strings.ToUpper(cmd) == "ECHO" {
+				msg, err := args.NextString()
+				if err != nil {
+					return nil, err
+				}
+				return redis.NewBulkMessage(msg), nil
+			}
+			return nil, redis.ErrSyntax
+		}
+	})
+	if err := server.Start(); err != nil {
+		t.Error(err)
+		return
+	}
+	defer server.Stop()
+
+	cli := client.NewClient()
+	if err := cli.Open(server.ListenAddrs()[0]); err != nil {
+		t.Error(err)
+		return
+	}
+	defer cli.Close()
+
+	msg, err := cli.Do("ECHO", "hello\r\nworld")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if str, _ := msg.String(); str != "hello\r\nworld" {
+		t.Errorf("%q != %q", str, "hello\r\nworld")
+	}
+
+	if _, err := cli.Do("PING"); !errors.Is(err, client.ErrReply) {
+		t.Error(err)
+	}
+}
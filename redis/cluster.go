@@ -0,0 +1,240 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+)
+
+// ClusterSlotRange represents a range of the hash slots.
+type ClusterSlotRange struct {
+	Start int
+	End   int
+}
+
+// ClusterNode represents a node of the cluster.
+type ClusterNode struct {
+	// ID is the node ID.
+	ID string
+	// Host is the host name or the IP address which the clients connect to.
+	Host string
+	// Port is the port number which the clients connect to.
+	Port int
+	// MasterID is the node ID of the master if the node is a replica, otherwise empty.
+	MasterID string
+	// Slots is the hash slots assigned to the node when the node is added to the cluster.
+	Slots []*ClusterSlotRange
+}
+
+// NewClusterNode returns a new cluster node with a random node ID.
+func NewClusterNode(host string, port int) *ClusterNode {
+	return &ClusterNode{
+		ID:       newRunID(),
+		Host:     host,
+		Port:     port,
+		MasterID: "",
+		Slots:    []*ClusterSlotRange{},
+	}
+}
+
+// AddSlots adds the specified range of the hash slots to the node.
+func (node *ClusterNode) AddSlots(start int, end int) *ClusterNode {
+	node.Slots = append(node.Slots, &ClusterSlotRange{Start: start, End: end})
+	return node
+}
+
+// Addr returns the address which the clients connect to.
+func (node *ClusterNode) Addr() string {
+	return net.JoinHostPort(node.Host, strconv.Itoa(node.Port))
+}
+
+// IsReplica returns true if the node is a replica.
+func (node *ClusterNode) IsReplica() bool {
+	return 0 < len(node.MasterID)
+}
+
+// Cluster represents a static configuration of the cluster nodes and the hash slots.
+type Cluster struct {
+	sync.RWMutex
+	myID      string
+	nodes     []*ClusterNode
+	slots     [ClusterSlots]*ClusterNode
+	migrating map[int]*ClusterNode
+	importing map[int]*ClusterNode
+}
+
+// NewCluster returns a new cluster which has no nodes.
+func NewCluster() *Cluster {
+	return &Cluster{
+		RWMutex:   sync.RWMutex{},
+		myID:      newRunID(),
+		nodes:     []*ClusterNode{},
+		slots:     [ClusterSlots]*ClusterNode{},
+		migrating: map[int]*ClusterNode{},
+		importing: map[int]*ClusterNode{},
+	}
+}
+
+// SetMyID sets the node ID of the server in the cluster.
+func (cluster *Cluster) SetMyID(id string) {
+	cluster.Lock()
+	defer cluster.Unlock()
+	cluster.myID = id
+}
+
+// MyID returns the node ID of the server in the cluster.
+func (cluster *Cluster) MyID() string {
+	cluster.RLock()
+	defer cluster.RUnlock()
+	return cluster.myID
+}
+
+// AddNode adds the specified node and assigns the hash slots of the node.
+func (cluster *Cluster) AddNode(node *ClusterNode) error {
+	cluster.Lock()
+	defer cluster.Unlock()
+	if _, ok := cluster.lookupNodeLocked(node.ID); ok {
+		return fmt.Errorf("%w: %s", ErrInvalid, node.ID)
+	}
+	if node.IsReplica() {
+		if _, ok := cluster.lookupNodeLocked(node.MasterID); !ok {
+			return fmt.Errorf("%w: %s", ErrNoSuchNode, node.MasterID)
+		}
+		cluster.nodes = append(cluster.nodes, node)
+		return nil
+	}
+	for _, r := range node.Slots {
+		if r.Start < 0 || ClusterSlots <= r.End || r.End < r.Start {
+			return fmt.Errorf("%w slot range (%d-%d)", ErrInvalid, r.Start, r.End)
+		}
+		for slot := r.Start; slot <= r.End; slot++ {
+			if cluster.slots[slot] != nil {
+				return fmt.Errorf("%w (%d)", ErrSlotAssigned, slot)
+			}
+		}
+	}
+	for _, r := range node.Slots {
+		for slot := r.Start; slot <= r.End; slot++ {
+			cluster.slots[slot] = node
+		}
+	}
+	cluster.nodes = append(cluster.nodes, node)
+	return nil
+}
+
+// Nodes returns all nodes of the cluster in the added order.
+func (cluster *Cluster) Nodes() []*ClusterNode {
+	cluster.RLock()
+	defer cluster.RUnlock()
+	return append([]*ClusterNode{}, cluster.nodes...)
+}
+
+// LookupNode returns the node of the specified node ID.
+func (cluster *Cluster) LookupNode(id string) (*ClusterNode, bool) {
+	cluster.RLock()
+	defer cluster.RUnlock()
+	return cluster.lookupNodeLocked(id)
+}
+
+func (cluster *Cluster) lookupNodeLocked(id string) (*ClusterNode, bool) {
+	for _, node := range cluster.nodes {
+		if node.ID == id {
+			return node, true
+		}
+	}
+	return nil, false
+}
+
+// SlotNode returns the master node serving the specified hash slot.
+func (cluster *Cluster) SlotNode(slot int) (*ClusterNode, bool) {
+	if slot < 0 || ClusterSlots <= slot {
+		return nil, false
+	}
+	cluster.RLock()
+	defer cluster.RUnlock()
+	node := cluster.slots[slot]
+	return node, node != nil
+}
+
+// SetSlotMigrating marks the specified hash slot served by the server as migrating to the specified node.
+func (cluster *Cluster) SetSlotMigrating(slot int, nodeID string) error {
+	return cluster.setSlotState(cluster.migrating, slot, nodeID)
+}
+
+// SetSlotImporting marks the specified hash slot as importing from the specified node to the server.
+func (cluster *Cluster) SetSlotImporting(slot int, nodeID string) error {
+	return cluster.setSlotState(cluster.importing, slot, nodeID)
+}
+
+func (cluster *Cluster) setSlotState(states map[int]*ClusterNode, slot int, nodeID string) error {
+	if slot < 0 || ClusterSlots <= slot {
+		return ErrInvalidSlot
+	}
+	cluster.Lock()
+	defer cluster.Unlock()
+	node, ok := cluster.lookupNodeLocked(nodeID)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoSuchNode, nodeID)
+	}
+	states[slot] = node
+	return nil
+}
+
+// SetSlotNode assigns the specified hash slot to the specified node and clears the migrating and importing states.
+func (cluster *Cluster) SetSlotNode(slot int, nodeID string) error {
+	if slot < 0 || ClusterSlots <= slot {
+		return ErrInvalidSlot
+	}
+	cluster.Lock()
+	defer cluster.Unlock()
+	node, ok := cluster.lookupNodeLocked(nodeID)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoSuchNode, nodeID)
+	}
+	cluster.slots[slot] = node
+	delete(cluster.migrating, slot)
+	delete(cluster.importing, slot)
+	return nil
+}
+
+// slotRangesLocked returns the contiguous ranges of the hash slots served by the specified node.
+func (cluster *Cluster) slotRangesLocked(node *ClusterNode) []*ClusterSlotRange {
+	ranges := []*ClusterSlotRange{}
+	for slot := 0; slot < ClusterSlots; slot++ {
+		if cluster.slots[slot] != node {
+			continue
+		}
+		if n := len(ranges); 0 < n && ranges[n-1].End == slot-1 {
+			ranges[n-1].End = slot
+			continue
+		}
+		ranges = append(ranges, &ClusterSlotRange{Start: slot, End: slot})
+	}
+	return ranges
+}
+
+// replicasLocked returns the replicas of the specified master node.
+func (cluster *Cluster) replicasLocked(master *ClusterNode) []*ClusterNode {
+	replicas := []*ClusterNode{}
+	for _, node := range cluster.nodes {
+		if node.MasterID == master.ID {
+			replicas = append(replicas, node)
+		}
+	}
+	return replicas
+}
@@ -0,0 +1,59 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"strings"
+)
+
+// ClusterSlots is the number of the hash slots of the cluster.
+const ClusterSlots = 16384
+
+// crc16Table is the lookup table of CRC16-CCITT (XMODEM) which Redis Cluster uses for the hash slots.
+var crc16Table = func() [256]uint16 {
+	var table [256]uint16
+	for n := 0; n < 256; n++ {
+		crc := uint16(n) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+		table[n] = crc
+	}
+	return table
+}()
+
+// crc16 returns the CRC16-CCITT (XMODEM) checksum of the specified bytes.
+func crc16(key string) uint16 {
+	crc := uint16(0)
+	for n := 0; n < len(key); n++ {
+		crc = crc<<8 ^ crc16Table[byte(crc>>8)^key[n]]
+	}
+	return crc
+}
+
+// KeySlot returns the hash slot of the specified key. Only the substring between the first { and the following }
+// is hashed if it is not empty, so that the keys with the same hash tag are assigned to the same slot.
+func KeySlot(key string) int {
+	if start := strings.IndexByte(key, '{'); 0 <= start {
+		if end := strings.IndexByte(key[start+1:], '}'); 0 < end {
+			key = key[start+1 : start+1+end]
+		}
+	}
+	return int(crc16(key) & (ClusterSlots - 1))
+}
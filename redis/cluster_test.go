@@ -0,0 +1,134 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cybergarage/go-redis/redis/proto"
+)
+
+func TestKeySlot(t *testing.T) {
+	if crc16("123456789") != 0x31C3 {
+		t.Errorf("%X != 31C3", crc16("123456789"))
+	}
+
+	tests := []struct {
+		key  string
+		slot int
+	}{
+		{"", 0},
+		{"foo", 12182},
+		{"bar", 5061},
+		{"{user1000}.following", 3443},
+		{"{user1000}.followers", 3443},
+		{"foo{}{bar}", 8363},
+		{"foo{{bar}}zap", 4015},
+		{"foo{bar}{zap}", 5061},
+	}
+	for _, test := range tests {
+		if slot := KeySlot(test.key); slot != test.slot {
+			t.Errorf("%s: %d != %d", test.key, slot, test.slot)
+		}
+	}
+}
+
+func TestClusterAddNode(t *testing.T) {
+	cluster := NewCluster()
+
+	master := NewClusterNode("localhost", 7000).AddSlots(0, 8191)
+	if err := cluster.AddNode(master); err != nil {
+		t.Error(err)
+	}
+	if err := cluster.AddNode(NewClusterNode("localhost", 7001).AddSlots(8191, ClusterSlots-1)); !errors.Is(err, ErrSlotAssigned) {
+		t.Errorf("overlapped slots are added (%v)", err)
+	}
+	if err := cluster.AddNode(NewClusterNode("localhost", 7001).AddSlots(8192, ClusterSlots)); !errors.Is(err, ErrInvalid) {
+		t.Errorf("invalid slots are added (%v)", err)
+	}
+
+	replica := NewClusterNode("localhost", 7002)
+	replica.MasterID = "unknown"
+	if err := cluster.AddNode(replica); !errors.Is(err, ErrNoSuchNode) {
+		t.Errorf("replica of unknown master is added (%v)", err)
+	}
+	replica.MasterID = master.ID
+	if err := cluster.AddNode(replica); err != nil {
+		t.Error(err)
+	}
+
+	if node, ok := cluster.SlotNode(8191); !ok || node != master {
+		t.Errorf("slot 8191 is not served by the master")
+	}
+	if _, ok := cluster.SlotNode(8192); ok {
+		t.Errorf("slot 8192 is served")
+	}
+
+	cluster.RLock()
+	ranges := cluster.slotRangesLocked(master)
+	replicas := cluster.replicasLocked(master)
+	cluster.RUnlock()
+	if len(ranges) != 1 || ranges[0].Start != 0 || ranges[0].End != 8191 {
+		t.Errorf("invalid slot ranges (%v)", ranges)
+	}
+	if len(replicas) != 1 || replicas[0] != replica {
+		t.Errorf("invalid replicas (%v)", replicas)
+	}
+}
+
+func TestClusterNumKeysCommands(t *testing.T) {
+	server := NewServer()
+	server.SetClusterEnabled(true)
+	server.SetFallbackExecutor(func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		return NewOKMessage(), nil
+	})
+
+	conn := newConnWith(nil)
+	conn.SetSpanContext(server.Tracer.StartSpan(PackageName))
+	conn.SetAuthrized(true)
+	execCommand := func(args ...string) error {
+		array := proto.NewArray()
+		for _, arg := range args {
+			array.Append(NewBulkMessage(arg))
+		}
+		_, err := server.handleArrayMessage(conn, array)
+		return err
+	}
+
+	for _, cmd := range []string{"EVAL", "EVAL_RO", "EVALSHA", "EVALSHA_RO", "FCALL", "FCALL_RO"} {
+		if err := execCommand(cmd, "f", "2", "foo", "bar"); !errors.Is(err, ErrCrossSlot) {
+			t.Errorf("%s: %v != %v", cmd, err, ErrCrossSlot)
+		}
+		// The arguments which follow the keys are not checked.
+		if err := execCommand(cmd, "f", "1", "foo", "bar"); errors.Is(err, ErrCrossSlot) {
+			t.Errorf("%s: %v", cmd, err)
+		}
+	}
+}
+
+func TestClusterKeysWithoutHandler(t *testing.T) {
+	server := NewServer()
+	conn := newConnWith(nil)
+	if _, err := server.ClusterCountKeysInSlot(conn, 0); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("%v != %v", err, ErrNotSupported)
+	}
+	if _, err := server.ClusterGetKeysInSlot(conn, 0, 1); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("%v != %v", err, ErrNotSupported)
+	}
+	if _, err := server.countExistingKeys(conn, []string{"key"}); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("%v != %v", err, ErrNotSupported)
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Command flags.
+const (
+	WriteFlag       = "write"
+	ReadonlyFlag    = "readonly"
+	DenyOOMFlag     = "denyoom"
+	AdminFlag       = "admin"
+	PubSubFlag      = "pubsub"
+	NoScriptFlag    = "noscript"
+	BlockingFlag    = "blocking"
+	LoadingFlag     = "loading"
+	StaleFlag       = "stale"
+	SkipMonitorFlag = "skip_monitor"
+	FastFlag        = "fast"
+	NoAuthFlag      = "no_auth"
+	AllowBusyFlag   = "allow_busy"
+	MovableKeysFlag = "movablekeys"
+)
+
+// Command ACL categories.
+const (
+	KeyspaceCategory   = "@keyspace"
+	ReadCategory       = "@read"
+	WriteCategory      = "@write"
+	StringCategory     = "@string"
+	HashCategory       = "@hash"
+	ListCategory       = "@list"
+	SetCategory        = "@set"
+	SortedSetCategory  = "@sortedset"
+	AdminCategory      = "@admin"
+	FastCategory       = "@fast"
+	SlowCategory       = "@slow"
+	DangerousCategory  = "@dangerous"
+	ConnectionCategory = "@connection"
+	ScriptingCategory  = "@scripting"
+	PubSubCategory     = "@pubsub"
+)
+
+// Command groups.
+const (
+	ConnectionGroup = "connection"
+	ServerGroup     = "server"
+	GenericGroup    = "generic"
+	StringGroup     = "string"
+	HashGroup       = "hash"
+	ListGroup       = "list"
+	SetGroup        = "set"
+	SortedSetGroup  = "sorted-set"
+	ScriptingGroup  = "scripting"
+	PubSubGroup     = "pubsub"
+	ClusterGroup    = "cluster"
+	SentinelGroup   = "sentinel"
+)
+
+// CommandInfo represents metadata of a command for the command table.
+type CommandInfo struct {
+	// Name is the command name.
+	Name string
+	// Arity is the number of the arguments including the command name. A negative value means that the number is greater than or equal to the absolute value.
+	Arity int
+	// Flags is the command flags such as write and readonly.
+	Flags []string
+	// FirstKey is the position of the first key argument, or zero if the command has no key arguments.
+	FirstKey int
+	// LastKey is the position of the last key argument. A negative value is counted from the end of the arguments.
+	LastKey int
+	// Step is the step between the key arguments.
+	Step int
+	// ACLCategories is the ACL categories such as @read and @write.
+	ACLCategories []string
+	// Group is the command group such as string and hash.
+	Group string
+	// Since is the Redis version which the command was added.
+	Since string
+	// Summary is the short description of the command.
+	Summary string
+	// Complexity is the time complexity of the command.
+	Complexity string
+}
+
+// newDefaultCommandInfo returns a command metadata with no restriction for the specified command.
+func newDefaultCommandInfo(name string) *CommandInfo {
+	return &CommandInfo{
+		Name:          strings.ToUpper(name),
+		Arity:         -1,
+		Flags:         []string{},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{},
+		Group:         "",
+		Since:         "",
+		Summary:       "",
+		Complexity:    "",
+	}
+}
+
+// HasFlag returns true if the command has the specified flag.
+func (info *CommandInfo) HasFlag(flag string) bool {
+	for _, f := range info.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// IsWrite returns true if the command may modify the dataset.
+func (info *CommandInfo) IsWrite() bool {
+	return info.HasFlag(WriteFlag)
+}
+
+// IsUserCommand returns true if the command is a data command handled by the user command handler.
+func (info *CommandInfo) IsUserCommand() bool {
+	switch info.Group {
+	case GenericGroup, StringGroup, HashGroup, ListGroup, SetGroup, SortedSetGroup:
+		return true
+	}
+	return false
+}
+
+// IsValidArity returns true if the specified number of the arguments including the command name satisfies the arity.
+func (info *CommandInfo) IsValidArity(argc int) bool {
+	if 0 <= info.Arity {
+		return argc == info.Arity
+	}
+	return -info.Arity <= argc
+}
+
+// KeyPositions returns the positions of the key arguments in the specified number of the arguments including the command name.
+func (info *CommandInfo) KeyPositions(argc int) []int {
+	if info.FirstKey <= 0 || argc <= info.FirstKey {
+		return []int{}
+	}
+	last := info.LastKey
+	if last < 0 {
+		last = argc + last
+	}
+	if argc <= last {
+		last = argc - 1
+	}
+	step := info.Step
+	if step <= 0 {
+		step = 1
+	}
+	positions := []int{}
+	for n := info.FirstKey; n <= last; n += step {
+		positions = append(positions, n)
+	}
+	return positions
+}
+
+// numKeysCommands is the commands which have the number of the keys at the specified position before the keys.
+var numKeysCommands = map[string]int{
+	"EVAL":       2,
+	"EVAL_RO":    2,
+	"EVALSHA":    2,
+	"EVALSHA_RO": 2,
+	"FCALL":      2,
+	"FCALL_RO":   2,
+}
+
+// Keys returns the key arguments in the specified arguments including the command name.
+func (info *CommandInfo) Keys(args []string) []string {
+	if pos, ok := numKeysCommands[info.Name]; ok {
+		return numKeys(args, pos)
+	}
+	keys := []string{}
+	for _, n := range info.KeyPositions(len(args)) {
+		keys = append(keys, args[n])
+	}
+	return keys
+}
+
+// numKeys returns the keys which follow the number of the keys at the specified position.
+func numKeys(args []string, pos int) []string {
+	if len(args) <= pos {
+		return []string{}
+	}
+	n, err := strconv.Atoi(args[pos])
+	if err != nil || n < 0 || len(args) < pos+1+n {
+		return []string{}
+	}
+	return args[pos+1 : pos+1+n]
+}
+
+// CommandTable represents a command metadata table.
+type CommandTable map[string]*CommandInfo
+
+// NewCommandTable returns a new command table.
+func NewCommandTable() CommandTable {
+	return CommandTable{}
+}
+
+// SetCommandInfo sets the specified command metadata.
+func (table CommandTable) SetCommandInfo(info *CommandInfo) {
+	table[strings.ToUpper(info.Name)] = info
+}
+
+// LookupCommandInfo returns the command metadata of the specified command.
+func (table CommandTable) LookupCommandInfo(name string) (*CommandInfo, bool) {
+	info, ok := table[strings.ToUpper(name)]
+	return info, ok
+}
+
+// CommandInfos returns all command metadata sorted by the command name.
+func (table CommandTable) CommandInfos() []*CommandInfo {
+	infos := make([]*CommandInfo, 0, len(table))
+	for _, info := range table {
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
@@ -0,0 +1,157 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UnknownCommandStatName is the statistics name of the commands which are not in the command table.
+const UnknownCommandStatName = "unknown"
+
+// CommandStat represents execution statistics of a command.
+type CommandStat struct {
+	Name          string
+	Calls         int64
+	Usec          int64
+	RejectedCalls int64
+	FailedCalls   int64
+	Latency       *LatencyHistogram
+}
+
+// newCommandStat returns a new command statistics.
+func newCommandStat(name string) *CommandStat {
+	return &CommandStat{
+		Name:          name,
+		Calls:         0,
+		Usec:          0,
+		RejectedCalls: 0,
+		FailedCalls:   0,
+		Latency:       NewLatencyHistogram(),
+	}
+}
+
+// UsecPerCall returns the average execution time per call in microseconds.
+func (stat *CommandStat) UsecPerCall() float64 {
+	if stat.Calls == 0 {
+		return 0
+	}
+	return float64(stat.Usec) / float64(stat.Calls)
+}
+
+// copy returns a copy of the command statistics.
+func (stat *CommandStat) copy() *CommandStat {
+	return &CommandStat{
+		Name:          stat.Name,
+		Calls:         stat.Calls,
+		Usec:          stat.Usec,
+		RejectedCalls: stat.RejectedCalls,
+		FailedCalls:   stat.FailedCalls,
+		Latency:       stat.Latency.Copy(),
+	}
+}
+
+// CommandStats represents execution statistics of all commands.
+type CommandStats struct {
+	sync.Mutex
+	stats map[string]*CommandStat
+}
+
+// NewCommandStats returns a new command statistics set.
+func NewCommandStats() *CommandStats {
+	return &CommandStats{
+		Mutex: sync.Mutex{},
+		stats: map[string]*CommandStat{},
+	}
+}
+
+// commandStat returns the statistics of the specified command, creating it if necessary.
+func (stats *CommandStats) commandStat(name string) *CommandStat {
+	name = strings.ToLower(name)
+	stat, ok := stats.stats[name]
+	if !ok {
+		stat = newCommandStat(name)
+		stats.stats[name] = stat
+	}
+	return stat
+}
+
+// RecordCall records an executed call of the specified command.
+func (stats *CommandStats) RecordCall(name string, d time.Duration, failed bool) {
+	usec := d.Microseconds()
+	stats.Lock()
+	defer stats.Unlock()
+	stat := stats.commandStat(name)
+	stat.Calls++
+	stat.Usec += usec
+	if failed {
+		stat.FailedCalls++
+	}
+	stat.Latency.Record(usec)
+}
+
+// RecordRejectedCall records a rejected call of the specified command which is not executed.
+func (stats *CommandStats) RecordRejectedCall(name string) {
+	stats.Lock()
+	defer stats.Unlock()
+	stat := stats.commandStat(name)
+	stat.RejectedCalls++
+}
+
+// CommandStat returns a copy of the statistics of the specified command.
+func (stats *CommandStats) CommandStat(name string) (*CommandStat, bool) {
+	stats.Lock()
+	defer stats.Unlock()
+	stat, ok := stats.stats[strings.ToLower(name)]
+	if !ok {
+		return nil, false
+	}
+	return stat.copy(), true
+}
+
+// CommandStats returns copies of all command statistics sorted by the command name.
+func (stats *CommandStats) CommandStats() []*CommandStat {
+	stats.Lock()
+	defer stats.Unlock()
+	statArray := make([]*CommandStat, 0, len(stats.stats))
+	for _, stat := range stats.stats {
+		statArray = append(statArray, stat.copy())
+	}
+	sort.Slice(statArray, func(i, j int) bool {
+		return statArray[i].Name < statArray[j].Name
+	})
+	return statArray
+}
+
+// TotalCalls returns the total number of the executed calls.
+func (stats *CommandStats) TotalCalls() int64 {
+	stats.Lock()
+	defer stats.Unlock()
+	total := int64(0)
+	for _, stat := range stats.stats {
+		total += stat.Calls
+	}
+	return total
+}
+
+// Reset clears all command statistics.
+func (stats *CommandStats) Reset() {
+	stats.Lock()
+	defer stats.Unlock()
+	stats.stats = map[string]*CommandStat{}
+}
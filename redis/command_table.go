@@ -0,0 +1,1278 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+// coreCommandInfos is the metadata of the built-in commands based on the Redis command specifications.
+var coreCommandInfos = []*CommandInfo{
+	{
+		Name:          "AUTH",
+		Arity:         -2,
+		Flags:         []string{NoScriptFlag, LoadingFlag, StaleFlag, FastFlag, NoAuthFlag, AllowBusyFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{FastCategory, ConnectionCategory},
+		Group:         ConnectionGroup,
+		Since:         "1.0.0",
+		Summary:       "Authenticates the connection.",
+		Complexity:    "O(N) where N is the number of passwords defined for the user",
+	},
+	{
+		Name:          "CLIENT",
+		Arity:         -2,
+		Flags:         []string{NoScriptFlag, LoadingFlag, StaleFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{SlowCategory, ConnectionCategory},
+		Group:         ConnectionGroup,
+		Since:         "2.4.0",
+		Summary:       "A container for client connection commands.",
+		Complexity:    "Depends on subcommand.",
+	},
+	{
+		Name:          "ECHO",
+		Arity:         2,
+		Flags:         []string{FastFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{FastCategory, ConnectionCategory},
+		Group:         ConnectionGroup,
+		Since:         "1.0.0",
+		Summary:       "Returns the given string.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "PING",
+		Arity:         -1,
+		Flags:         []string{FastFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{FastCategory, ConnectionCategory},
+		Group:         ConnectionGroup,
+		Since:         "1.0.0",
+		Summary:       "Returns the server's liveliness response.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "QUIT",
+		Arity:         -1,
+		Flags:         []string{AllowBusyFlag, NoScriptFlag, LoadingFlag, StaleFlag, FastFlag, NoAuthFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{FastCategory, ConnectionCategory},
+		Group:         ConnectionGroup,
+		Since:         "1.0.0",
+		Summary:       "Closes the connection.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "SELECT",
+		Arity:         2,
+		Flags:         []string{LoadingFlag, StaleFlag, FastFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{FastCategory, ConnectionCategory},
+		Group:         ConnectionGroup,
+		Since:         "1.0.0",
+		Summary:       "Changes the selected database.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "COMMAND",
+		Arity:         -1,
+		Flags:         []string{LoadingFlag, StaleFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{SlowCategory, ConnectionCategory},
+		Group:         ServerGroup,
+		Since:         "2.8.13",
+		Summary:       "Returns detailed information about all commands.",
+		Complexity:    "O(N) where N is the total number of Redis commands",
+	},
+	{
+		Name:          "CONFIG",
+		Arity:         -2,
+		Flags:         []string{AdminFlag, NoScriptFlag, LoadingFlag, StaleFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{AdminCategory, SlowCategory, DangerousCategory},
+		Group:         ServerGroup,
+		Since:         "2.0.0",
+		Summary:       "A container for server configuration commands.",
+		Complexity:    "Depends on subcommand.",
+	},
+	{
+		Name:          "INFO",
+		Arity:         -1,
+		Flags:         []string{LoadingFlag, StaleFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{SlowCategory, DangerousCategory},
+		Group:         ServerGroup,
+		Since:         "1.0.0",
+		Summary:       "Returns information and statistics about the server.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "MONITOR",
+		Arity:         1,
+		Flags:         []string{AdminFlag, NoScriptFlag, LoadingFlag, StaleFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{AdminCategory, SlowCategory, DangerousCategory},
+		Group:         ServerGroup,
+		Since:         "1.0.0",
+		Summary:       "Listens for all requests received by the server in real-time.",
+		Complexity:    "",
+	},
+	{
+		Name:          "SLOWLOG",
+		Arity:         -2,
+		Flags:         []string{AdminFlag, LoadingFlag, StaleFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{AdminCategory, SlowCategory, DangerousCategory},
+		Group:         ServerGroup,
+		Since:         "2.2.12",
+		Summary:       "A container for slow log commands.",
+		Complexity:    "Depends on subcommand.",
+	},
+	{
+		Name:          "SHUTDOWN",
+		Arity:         -1,
+		Flags:         []string{AdminFlag, NoScriptFlag, LoadingFlag, StaleFlag, AllowBusyFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{AdminCategory, SlowCategory, DangerousCategory},
+		Group:         ServerGroup,
+		Since:         "1.0.0",
+		Summary:       "Synchronously saves the database(s) to disk and shuts down the Redis server.",
+		Complexity:    "O(N) when saving, where N is the total number of keys in all databases when saving data, otherwise O(1)",
+	},
+	{
+		Name:          "REPLICAOF",
+		Arity:         3,
+		Flags:         []string{AdminFlag, NoScriptFlag, StaleFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{AdminCategory, SlowCategory, DangerousCategory},
+		Group:         ServerGroup,
+		Since:         "5.0.0",
+		Summary:       "Configures a server as replica of another, or promotes it to a master.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "SLAVEOF",
+		Arity:         3,
+		Flags:         []string{AdminFlag, NoScriptFlag, StaleFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{AdminCategory, SlowCategory, DangerousCategory},
+		Group:         ServerGroup,
+		Since:         "1.0.0",
+		Summary:       "Sets a Redis server as a replica of another, or promotes it to being a master.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "ROLE",
+		Arity:         1,
+		Flags:         []string{NoScriptFlag, LoadingFlag, StaleFlag, FastFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{AdminCategory, FastCategory, DangerousCategory},
+		Group:         ServerGroup,
+		Since:         "2.8.12",
+		Summary:       "Returns the replication role.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "WAIT",
+		Arity:         3,
+		Flags:         []string{NoScriptFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{SlowCategory},
+		Group:         ServerGroup,
+		Since:         "3.0.0",
+		Summary:       "Blocks until the asynchronous replication of all preceding write commands sent by the connection is completed.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "PSYNC",
+		Arity:         -3,
+		Flags:         []string{AdminFlag, NoScriptFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{AdminCategory, SlowCategory, DangerousCategory},
+		Group:         ServerGroup,
+		Since:         "2.8.0",
+		Summary:       "An internal command used in replication.",
+		Complexity:    "",
+	},
+	{
+		Name:          "REPLCONF",
+		Arity:         -1,
+		Flags:         []string{AdminFlag, NoScriptFlag, LoadingFlag, StaleFlag, AllowBusyFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{AdminCategory, SlowCategory, DangerousCategory},
+		Group:         ServerGroup,
+		Since:         "3.0.0",
+		Summary:       "An internal command for configuring the replication stream.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "SUBSCRIBE",
+		Arity:         -2,
+		Flags:         []string{PubSubFlag, NoScriptFlag, LoadingFlag, StaleFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{PubSubCategory, SlowCategory},
+		Group:         PubSubGroup,
+		Since:         "2.0.0",
+		Summary:       "Listens for messages published to channels.",
+		Complexity:    "O(N) where N is the number of channels to subscribe to.",
+	},
+	{
+		Name:          "UNSUBSCRIBE",
+		Arity:         -1,
+		Flags:         []string{PubSubFlag, NoScriptFlag, LoadingFlag, StaleFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{PubSubCategory, SlowCategory},
+		Group:         PubSubGroup,
+		Since:         "2.0.0",
+		Summary:       "Stops listening to messages posted to channels.",
+		Complexity:    "O(N) where N is the number of channels to unsubscribe.",
+	},
+	{
+		Name:          "PSUBSCRIBE",
+		Arity:         -2,
+		Flags:         []string{PubSubFlag, NoScriptFlag, LoadingFlag, StaleFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{PubSubCategory, SlowCategory},
+		Group:         PubSubGroup,
+		Since:         "2.0.0",
+		Summary:       "Listens for messages published to channels that match one or more patterns.",
+		Complexity:    "O(N) where N is the number of patterns to subscribe to.",
+	},
+	{
+		Name:          "PUNSUBSCRIBE",
+		Arity:         -1,
+		Flags:         []string{PubSubFlag, NoScriptFlag, LoadingFlag, StaleFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{PubSubCategory, SlowCategory},
+		Group:         PubSubGroup,
+		Since:         "2.0.0",
+		Summary:       "Stops listening to messages published to channels that match one or more patterns.",
+		Complexity:    "O(N) where N is the number of patterns to unsubscribe.",
+	},
+	{
+		Name:          "PUBLISH",
+		Arity:         3,
+		Flags:         []string{PubSubFlag, LoadingFlag, StaleFlag, FastFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{PubSubCategory, FastCategory},
+		Group:         PubSubGroup,
+		Since:         "2.0.0",
+		Summary:       "Posts a message to a channel.",
+		Complexity:    "O(N+M) where N is the number of clients subscribed to the receiving channel and M is the total number of subscribed patterns (by any client).",
+	},
+	{
+		Name:          "PUBSUB",
+		Arity:         -2,
+		Flags:         []string{},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{SlowCategory},
+		Group:         PubSubGroup,
+		Since:         "2.8.0",
+		Summary:       "A container for Pub/Sub commands.",
+		Complexity:    "Depends on subcommand.",
+	},
+	{
+		Name:          "EVAL",
+		Arity:         -3,
+		Flags:         []string{NoScriptFlag, SkipMonitorFlag, StaleFlag, MovableKeysFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{SlowCategory, ScriptingCategory},
+		Group:         ScriptingGroup,
+		Since:         "2.6.0",
+		Summary:       "Executes a server-side Lua script.",
+		Complexity:    "Depends on the script that is executed.",
+	},
+	{
+		Name:          "EVAL_RO",
+		Arity:         -3,
+		Flags:         []string{NoScriptFlag, SkipMonitorFlag, StaleFlag, ReadonlyFlag, MovableKeysFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{SlowCategory, ScriptingCategory},
+		Group:         ScriptingGroup,
+		Since:         "7.0.0",
+		Summary:       "Executes a read-only server-side Lua script.",
+		Complexity:    "Depends on the script that is executed.",
+	},
+	{
+		Name:          "EVALSHA",
+		Arity:         -3,
+		Flags:         []string{NoScriptFlag, SkipMonitorFlag, StaleFlag, MovableKeysFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{SlowCategory, ScriptingCategory},
+		Group:         ScriptingGroup,
+		Since:         "2.6.0",
+		Summary:       "Executes a server-side Lua script by SHA1 digest.",
+		Complexity:    "Depends on the script that is executed.",
+	},
+	{
+		Name:          "EVALSHA_RO",
+		Arity:         -3,
+		Flags:         []string{NoScriptFlag, SkipMonitorFlag, StaleFlag, ReadonlyFlag, MovableKeysFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{SlowCategory, ScriptingCategory},
+		Group:         ScriptingGroup,
+		Since:         "7.0.0",
+		Summary:       "Executes a read-only server-side Lua script by SHA1 digest.",
+		Complexity:    "Depends on the script that is executed.",
+	},
+	{
+		Name:          "FCALL",
+		Arity:         -3,
+		Flags:         []string{NoScriptFlag, SkipMonitorFlag, StaleFlag, MovableKeysFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{SlowCategory, ScriptingCategory},
+		Group:         ScriptingGroup,
+		Since:         "7.0.0",
+		Summary:       "Invokes a function.",
+		Complexity:    "Depends on the function that is executed.",
+	},
+	{
+		Name:          "FCALL_RO",
+		Arity:         -3,
+		Flags:         []string{NoScriptFlag, SkipMonitorFlag, StaleFlag, ReadonlyFlag, MovableKeysFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{SlowCategory, ScriptingCategory},
+		Group:         ScriptingGroup,
+		Since:         "7.0.0",
+		Summary:       "Invokes a read-only function.",
+		Complexity:    "Depends on the function that is executed.",
+	},
+	{
+		Name:          "FUNCTION",
+		Arity:         -2,
+		Flags:         []string{NoScriptFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{SlowCategory, ScriptingCategory},
+		Group:         ScriptingGroup,
+		Since:         "7.0.0",
+		Summary:       "A container for function commands.",
+		Complexity:    "Depends on subcommand.",
+	},
+	{
+		Name:          "SCRIPT",
+		Arity:         -2,
+		Flags:         []string{NoScriptFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{SlowCategory},
+		Group:         ScriptingGroup,
+		Since:         "2.6.0",
+		Summary:       "A container for Lua scripts management commands.",
+		Complexity:    "Depends on subcommand.",
+	},
+	{
+		Name:          "CLUSTER",
+		Arity:         -2,
+		Flags:         []string{},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{SlowCategory},
+		Group:         ClusterGroup,
+		Since:         "3.0.0",
+		Summary:       "A container for Redis Cluster commands.",
+		Complexity:    "Depends on subcommand.",
+	},
+	{
+		Name:          "ASKING",
+		Arity:         1,
+		Flags:         []string{FastFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{FastCategory, ConnectionCategory},
+		Group:         ClusterGroup,
+		Since:         "3.0.0",
+		Summary:       "Signals that a cluster client is following an -ASK redirect.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "SENTINEL",
+		Arity:         -2,
+		Flags:         []string{},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{SlowCategory},
+		Group:         SentinelGroup,
+		Since:         "2.8.4",
+		Summary:       "A container for Redis Sentinel commands.",
+		Complexity:    "Depends on subcommand.",
+	},
+	{
+		Name:          "DEL",
+		Arity:         -2,
+		Flags:         []string{WriteFlag},
+		FirstKey:      1,
+		LastKey:       -1,
+		Step:          1,
+		ACLCategories: []string{KeyspaceCategory, WriteCategory, SlowCategory},
+		Group:         GenericGroup,
+		Since:         "1.0.0",
+		Summary:       "Deletes one or more keys.",
+		Complexity:    "O(N) where N is the number of keys that will be removed.",
+	},
+	{
+		Name:          "EXISTS",
+		Arity:         -2,
+		Flags:         []string{ReadonlyFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       -1,
+		Step:          1,
+		ACLCategories: []string{KeyspaceCategory, ReadCategory, FastCategory},
+		Group:         GenericGroup,
+		Since:         "1.0.0",
+		Summary:       "Determines whether one or more keys exist.",
+		Complexity:    "O(N) where N is the number of keys to check.",
+	},
+	{
+		Name:          "EXPIRE",
+		Arity:         -3,
+		Flags:         []string{WriteFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{KeyspaceCategory, WriteCategory, FastCategory},
+		Group:         GenericGroup,
+		Since:         "1.0.0",
+		Summary:       "Sets the expiration time of a key in seconds.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "EXPIREAT",
+		Arity:         -3,
+		Flags:         []string{WriteFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{KeyspaceCategory, WriteCategory, FastCategory},
+		Group:         GenericGroup,
+		Since:         "1.2.0",
+		Summary:       "Sets the expiration time of a key to a Unix timestamp.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "KEYS",
+		Arity:         2,
+		Flags:         []string{ReadonlyFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{KeyspaceCategory, ReadCategory, SlowCategory, DangerousCategory},
+		Group:         GenericGroup,
+		Since:         "1.0.0",
+		Summary:       "Returns all key names that match a pattern.",
+		Complexity:    "O(N) with N being the number of keys in the database",
+	},
+	{
+		Name:          "RENAME",
+		Arity:         3,
+		Flags:         []string{WriteFlag},
+		FirstKey:      1,
+		LastKey:       2,
+		Step:          1,
+		ACLCategories: []string{KeyspaceCategory, WriteCategory, SlowCategory},
+		Group:         GenericGroup,
+		Since:         "1.0.0",
+		Summary:       "Renames a key and overwrites the destination.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "RENAMENX",
+		Arity:         3,
+		Flags:         []string{WriteFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       2,
+		Step:          1,
+		ACLCategories: []string{KeyspaceCategory, WriteCategory, FastCategory},
+		Group:         GenericGroup,
+		Since:         "1.0.0",
+		Summary:       "Renames a key only when the target key name doesn't exist.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "SCAN",
+		Arity:         -2,
+		Flags:         []string{ReadonlyFlag},
+		FirstKey:      0,
+		LastKey:       0,
+		Step:          0,
+		ACLCategories: []string{KeyspaceCategory, ReadCategory, SlowCategory},
+		Group:         GenericGroup,
+		Since:         "2.8.0",
+		Summary:       "Iterates over the key names in the database.",
+		Complexity:    "O(1) for every call. O(N) for a complete iteration.",
+	},
+	{
+		Name:          "TTL",
+		Arity:         2,
+		Flags:         []string{ReadonlyFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{KeyspaceCategory, ReadCategory, FastCategory},
+		Group:         GenericGroup,
+		Since:         "1.0.0",
+		Summary:       "Returns the expiration time in seconds of a key.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "TYPE",
+		Arity:         2,
+		Flags:         []string{ReadonlyFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{KeyspaceCategory, ReadCategory, FastCategory},
+		Group:         GenericGroup,
+		Since:         "1.0.0",
+		Summary:       "Determines the type of value stored at a key.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "APPEND",
+		Arity:         3,
+		Flags:         []string{WriteFlag, DenyOOMFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{WriteCategory, StringCategory, FastCategory},
+		Group:         StringGroup,
+		Since:         "2.0.0",
+		Summary:       "Appends a string to the value of a key. Creates the key if it doesn't exist.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "DECR",
+		Arity:         2,
+		Flags:         []string{WriteFlag, DenyOOMFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{WriteCategory, StringCategory, FastCategory},
+		Group:         StringGroup,
+		Since:         "1.0.0",
+		Summary:       "Decrements the integer value of a key by one. Uses 0 as initial value if the key doesn't exist.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "DECRBY",
+		Arity:         3,
+		Flags:         []string{WriteFlag, DenyOOMFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{WriteCategory, StringCategory, FastCategory},
+		Group:         StringGroup,
+		Since:         "1.0.0",
+		Summary:       "Decrements a number from the integer value of a key. Uses 0 as initial value if the key doesn't exist.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "GET",
+		Arity:         2,
+		Flags:         []string{ReadonlyFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{ReadCategory, StringCategory, FastCategory},
+		Group:         StringGroup,
+		Since:         "1.0.0",
+		Summary:       "Returns the string value of a key.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "GETRANGE",
+		Arity:         4,
+		Flags:         []string{ReadonlyFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{ReadCategory, StringCategory, SlowCategory},
+		Group:         StringGroup,
+		Since:         "2.4.0",
+		Summary:       "Returns a substring of the string stored at a key.",
+		Complexity:    "O(N) where N is the length of the returned string.",
+	},
+	{
+		Name:          "GETSET",
+		Arity:         3,
+		Flags:         []string{WriteFlag, DenyOOMFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{WriteCategory, StringCategory, FastCategory},
+		Group:         StringGroup,
+		Since:         "1.0.0",
+		Summary:       "Returns the previous string value of a key after setting it to a new value.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "INCR",
+		Arity:         2,
+		Flags:         []string{WriteFlag, DenyOOMFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{WriteCategory, StringCategory, FastCategory},
+		Group:         StringGroup,
+		Since:         "1.0.0",
+		Summary:       "Increments the integer value of a key by one. Uses 0 as initial value if the key doesn't exist.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "INCRBY",
+		Arity:         3,
+		Flags:         []string{WriteFlag, DenyOOMFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{WriteCategory, StringCategory, FastCategory},
+		Group:         StringGroup,
+		Since:         "1.0.0",
+		Summary:       "Increments the integer value of a key by a number. Uses 0 as initial value if the key doesn't exist.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "MGET",
+		Arity:         -2,
+		Flags:         []string{ReadonlyFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       -1,
+		Step:          1,
+		ACLCategories: []string{ReadCategory, StringCategory, FastCategory},
+		Group:         StringGroup,
+		Since:         "1.0.0",
+		Summary:       "Atomically returns the string values of one or more keys.",
+		Complexity:    "O(N) where N is the number of keys to retrieve.",
+	},
+	{
+		Name:          "MSET",
+		Arity:         -3,
+		Flags:         []string{WriteFlag, DenyOOMFlag},
+		FirstKey:      1,
+		LastKey:       -1,
+		Step:          2,
+		ACLCategories: []string{WriteCategory, StringCategory, SlowCategory},
+		Group:         StringGroup,
+		Since:         "1.0.1",
+		Summary:       "Atomically creates or modifies the string values of one or more keys.",
+		Complexity:    "O(N) where N is the number of keys to set.",
+	},
+	{
+		Name:          "MSETNX",
+		Arity:         -3,
+		Flags:         []string{WriteFlag, DenyOOMFlag},
+		FirstKey:      1,
+		LastKey:       -1,
+		Step:          2,
+		ACLCategories: []string{WriteCategory, StringCategory, SlowCategory},
+		Group:         StringGroup,
+		Since:         "1.0.1",
+		Summary:       "Atomically modifies the string values of one or more keys only when all keys don't exist.",
+		Complexity:    "O(N) where N is the number of keys to set.",
+	},
+	{
+		Name:          "SET",
+		Arity:         -3,
+		Flags:         []string{WriteFlag, DenyOOMFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{WriteCategory, StringCategory, SlowCategory},
+		Group:         StringGroup,
+		Since:         "1.0.0",
+		Summary:       "Sets the string value of a key, ignoring its type. The key is created if it doesn't exist.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "SETEX",
+		Arity:         4,
+		Flags:         []string{WriteFlag, DenyOOMFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{WriteCategory, StringCategory, SlowCategory},
+		Group:         StringGroup,
+		Since:         "2.0.0",
+		Summary:       "Sets the string value and expiration time of a key. Creates the key if it doesn't exist.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "SETNX",
+		Arity:         3,
+		Flags:         []string{WriteFlag, DenyOOMFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{WriteCategory, StringCategory, FastCategory},
+		Group:         StringGroup,
+		Since:         "1.0.0",
+		Summary:       "Set the string value of a key only when the key doesn't exist.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "STRLEN",
+		Arity:         2,
+		Flags:         []string{ReadonlyFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{ReadCategory, StringCategory, FastCategory},
+		Group:         StringGroup,
+		Since:         "2.2.0",
+		Summary:       "Returns the length of a string value.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "SUBSTR",
+		Arity:         4,
+		Flags:         []string{ReadonlyFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{ReadCategory, StringCategory, SlowCategory},
+		Group:         StringGroup,
+		Since:         "1.0.0",
+		Summary:       "Returns a substring from a string value.",
+		Complexity:    "O(N) where N is the length of the returned string.",
+	},
+	{
+		Name:          "HDEL",
+		Arity:         -3,
+		Flags:         []string{WriteFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{WriteCategory, HashCategory, FastCategory},
+		Group:         HashGroup,
+		Since:         "2.0.0",
+		Summary:       "Deletes one or more fields and their values from a hash. Deletes the hash if no fields remain.",
+		Complexity:    "O(N) where N is the number of fields to be removed.",
+	},
+	{
+		Name:          "HEXISTS",
+		Arity:         3,
+		Flags:         []string{ReadonlyFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{ReadCategory, HashCategory, FastCategory},
+		Group:         HashGroup,
+		Since:         "2.0.0",
+		Summary:       "Determines whether a field exists in a hash.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "HGET",
+		Arity:         3,
+		Flags:         []string{ReadonlyFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{ReadCategory, HashCategory, FastCategory},
+		Group:         HashGroup,
+		Since:         "2.0.0",
+		Summary:       "Returns the value of a field in a hash.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "HGETALL",
+		Arity:         2,
+		Flags:         []string{ReadonlyFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{ReadCategory, HashCategory, SlowCategory},
+		Group:         HashGroup,
+		Since:         "2.0.0",
+		Summary:       "Returns all fields and values in a hash.",
+		Complexity:    "O(N) where N is the size of the hash.",
+	},
+	{
+		Name:          "HKEYS",
+		Arity:         2,
+		Flags:         []string{ReadonlyFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{ReadCategory, HashCategory, SlowCategory},
+		Group:         HashGroup,
+		Since:         "2.0.0",
+		Summary:       "Returns all fields in a hash.",
+		Complexity:    "O(N) where N is the size of the hash.",
+	},
+	{
+		Name:          "HLEN",
+		Arity:         2,
+		Flags:         []string{ReadonlyFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{ReadCategory, HashCategory, FastCategory},
+		Group:         HashGroup,
+		Since:         "2.0.0",
+		Summary:       "Returns the number of fields in a hash.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "HMGET",
+		Arity:         -3,
+		Flags:         []string{ReadonlyFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{ReadCategory, HashCategory, FastCategory},
+		Group:         HashGroup,
+		Since:         "2.0.0",
+		Summary:       "Returns the values of all fields in a hash.",
+		Complexity:    "O(N) where N is the number of fields being requested.",
+	},
+	{
+		Name:          "HMSET",
+		Arity:         -4,
+		Flags:         []string{WriteFlag, DenyOOMFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{WriteCategory, HashCategory, FastCategory},
+		Group:         HashGroup,
+		Since:         "2.0.0",
+		Summary:       "Sets the values of multiple fields.",
+		Complexity:    "O(N) where N is the number of fields being set.",
+	},
+	{
+		Name:          "HSET",
+		Arity:         -4,
+		Flags:         []string{WriteFlag, DenyOOMFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{WriteCategory, HashCategory, FastCategory},
+		Group:         HashGroup,
+		Since:         "2.0.0",
+		Summary:       "Creates or modifies the value of a field in a hash.",
+		Complexity:    "O(1) for each field/value pair added, so O(N) to add N field/value pairs when the command is called with multiple field/value pairs.",
+	},
+	{
+		Name:          "HSETNX",
+		Arity:         4,
+		Flags:         []string{WriteFlag, DenyOOMFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{WriteCategory, HashCategory, FastCategory},
+		Group:         HashGroup,
+		Since:         "2.0.0",
+		Summary:       "Sets the value of a field in a hash only when the field doesn't exist.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "HSTRLEN",
+		Arity:         3,
+		Flags:         []string{ReadonlyFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{ReadCategory, HashCategory, FastCategory},
+		Group:         HashGroup,
+		Since:         "3.2.0",
+		Summary:       "Returns the length of the value of a field.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "HVALS",
+		Arity:         2,
+		Flags:         []string{ReadonlyFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{ReadCategory, HashCategory, SlowCategory},
+		Group:         HashGroup,
+		Since:         "2.0.0",
+		Summary:       "Returns all values in a hash.",
+		Complexity:    "O(N) where N is the size of the hash.",
+	},
+	{
+		Name:          "LINDEX",
+		Arity:         3,
+		Flags:         []string{ReadonlyFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{ReadCategory, ListCategory, SlowCategory},
+		Group:         ListGroup,
+		Since:         "1.0.0",
+		Summary:       "Returns an element from a list by its index.",
+		Complexity:    "O(N) where N is the number of elements to traverse to get to the element at index.",
+	},
+	{
+		Name:          "LLEN",
+		Arity:         2,
+		Flags:         []string{ReadonlyFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{ReadCategory, ListCategory, FastCategory},
+		Group:         ListGroup,
+		Since:         "1.0.0",
+		Summary:       "Returns the length of a list.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "LPOP",
+		Arity:         -2,
+		Flags:         []string{WriteFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{WriteCategory, ListCategory, FastCategory},
+		Group:         ListGroup,
+		Since:         "1.0.0",
+		Summary:       "Returns the first elements in a list after removing it. Deletes the list if the last element was popped.",
+		Complexity:    "O(N) where N is the number of elements returned",
+	},
+	{
+		Name:          "LPUSH",
+		Arity:         -3,
+		Flags:         []string{WriteFlag, DenyOOMFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{WriteCategory, ListCategory, FastCategory},
+		Group:         ListGroup,
+		Since:         "1.0.0",
+		Summary:       "Prepends one or more elements to a list. Creates the key if it doesn't exist.",
+		Complexity:    "O(1) for each element added, so O(N) to add N elements when the command is called with multiple arguments.",
+	},
+	{
+		Name:          "LPUSHX",
+		Arity:         -3,
+		Flags:         []string{WriteFlag, DenyOOMFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{WriteCategory, ListCategory, FastCategory},
+		Group:         ListGroup,
+		Since:         "2.2.0",
+		Summary:       "Prepends one or more elements to a list only when the list exists.",
+		Complexity:    "O(1) for each element added, so O(N) to add N elements when the command is called with multiple arguments.",
+	},
+	{
+		Name:          "LRANGE",
+		Arity:         4,
+		Flags:         []string{ReadonlyFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{ReadCategory, ListCategory, SlowCategory},
+		Group:         ListGroup,
+		Since:         "1.0.0",
+		Summary:       "Returns a range of elements from a list.",
+		Complexity:    "O(S+N) where S is the distance of start offset from HEAD for small lists, from nearest end (HEAD or TAIL) for large lists; and N is the number of elements in the specified range.",
+	},
+	{
+		Name:          "RPOP",
+		Arity:         -2,
+		Flags:         []string{WriteFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{WriteCategory, ListCategory, FastCategory},
+		Group:         ListGroup,
+		Since:         "1.0.0",
+		Summary:       "Returns and removes the last elements of a list. Deletes the list if the last element was popped.",
+		Complexity:    "O(N) where N is the number of elements returned",
+	},
+	{
+		Name:          "RPUSH",
+		Arity:         -3,
+		Flags:         []string{WriteFlag, DenyOOMFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{WriteCategory, ListCategory, FastCategory},
+		Group:         ListGroup,
+		Since:         "1.0.0",
+		Summary:       "Appends one or more elements to a list. Creates the key if it doesn't exist.",
+		Complexity:    "O(1) for each element added, so O(N) to add N elements when the command is called with multiple arguments.",
+	},
+	{
+		Name:          "RPUSHX",
+		Arity:         -3,
+		Flags:         []string{WriteFlag, DenyOOMFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{WriteCategory, ListCategory, FastCategory},
+		Group:         ListGroup,
+		Since:         "2.2.0",
+		Summary:       "Appends an element to a list only when the list exists.",
+		Complexity:    "O(1) for each element added, so O(N) to add N elements when the command is called with multiple arguments.",
+	},
+	{
+		Name:          "SADD",
+		Arity:         -3,
+		Flags:         []string{WriteFlag, DenyOOMFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{WriteCategory, SetCategory, FastCategory},
+		Group:         SetGroup,
+		Since:         "1.0.0",
+		Summary:       "Adds one or more members to a set. Creates the key if it doesn't exist.",
+		Complexity:    "O(1) for each element added, so O(N) to add N elements when the command is called with multiple arguments.",
+	},
+	{
+		Name:          "SCARD",
+		Arity:         2,
+		Flags:         []string{ReadonlyFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{ReadCategory, SetCategory, FastCategory},
+		Group:         SetGroup,
+		Since:         "1.0.0",
+		Summary:       "Returns the number of members in a set.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "SISMEMBER",
+		Arity:         3,
+		Flags:         []string{ReadonlyFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{ReadCategory, SetCategory, FastCategory},
+		Group:         SetGroup,
+		Since:         "1.0.0",
+		Summary:       "Determines whether a member belongs to a set.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "SMEMBERS",
+		Arity:         2,
+		Flags:         []string{ReadonlyFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{ReadCategory, SetCategory, SlowCategory},
+		Group:         SetGroup,
+		Since:         "1.0.0",
+		Summary:       "Returns all members of a set.",
+		Complexity:    "O(N) where N is the set cardinality.",
+	},
+	{
+		Name:          "SREM",
+		Arity:         -3,
+		Flags:         []string{WriteFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{WriteCategory, SetCategory, FastCategory},
+		Group:         SetGroup,
+		Since:         "1.0.0",
+		Summary:       "Removes one or more members from a set. Deletes the set if the last member was removed.",
+		Complexity:    "O(N) where N is the number of members to be removed.",
+	},
+	{
+		Name:          "ZADD",
+		Arity:         -4,
+		Flags:         []string{WriteFlag, DenyOOMFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{WriteCategory, SortedSetCategory, FastCategory},
+		Group:         SortedSetGroup,
+		Since:         "1.2.0",
+		Summary:       "Adds one or more members to a sorted set, or updates their scores. Creates the key if it doesn't exist.",
+		Complexity:    "O(log(N)) for each item added, where N is the number of elements in the sorted set.",
+	},
+	{
+		Name:          "ZCARD",
+		Arity:         2,
+		Flags:         []string{ReadonlyFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{ReadCategory, SortedSetCategory, FastCategory},
+		Group:         SortedSetGroup,
+		Since:         "1.2.0",
+		Summary:       "Returns the number of members in a sorted set.",
+		Complexity:    "O(1)",
+	},
+	{
+		Name:          "ZINCRBY",
+		Arity:         4,
+		Flags:         []string{WriteFlag, DenyOOMFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{WriteCategory, SortedSetCategory, FastCategory},
+		Group:         SortedSetGroup,
+		Since:         "1.2.0",
+		Summary:       "Increments the score of a member in a sorted set.",
+		Complexity:    "O(log(N)) where N is the number of elements in the sorted set.",
+	},
+	{
+		Name:          "ZRANGE",
+		Arity:         -4,
+		Flags:         []string{ReadonlyFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{ReadCategory, SortedSetCategory, SlowCategory},
+		Group:         SortedSetGroup,
+		Since:         "1.2.0",
+		Summary:       "Returns members in a sorted set within a range of indexes.",
+		Complexity:    "O(log(N)+M) with N being the number of elements in the sorted set and M the number of elements returned.",
+	},
+	{
+		Name:          "ZRANGEBYSCORE",
+		Arity:         -4,
+		Flags:         []string{ReadonlyFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{ReadCategory, SortedSetCategory, SlowCategory},
+		Group:         SortedSetGroup,
+		Since:         "1.0.5",
+		Summary:       "Returns members in a sorted set within a range of scores.",
+		Complexity:    "O(log(N)+M) with N being the number of elements in the sorted set and M the number of elements being returned.",
+	},
+	{
+		Name:          "ZREM",
+		Arity:         -3,
+		Flags:         []string{WriteFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{WriteCategory, SortedSetCategory, FastCategory},
+		Group:         SortedSetGroup,
+		Since:         "1.2.0",
+		Summary:       "Removes one or more members from a sorted set. Deletes the sorted set if all members were removed.",
+		Complexity:    "O(M*log(N)) with N being the number of elements in the sorted set and M the number of elements to be removed.",
+	},
+	{
+		Name:          "ZREVRANGE",
+		Arity:         -4,
+		Flags:         []string{ReadonlyFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{ReadCategory, SortedSetCategory, SlowCategory},
+		Group:         SortedSetGroup,
+		Since:         "1.2.0",
+		Summary:       "Returns members in a sorted set within a range of indexes in reverse order.",
+		Complexity:    "O(log(N)+M) with N being the number of elements in the sorted set and M the number of elements returned.",
+	},
+	{
+		Name:          "ZREVRANGEBYSCORE",
+		Arity:         -4,
+		Flags:         []string{ReadonlyFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{ReadCategory, SortedSetCategory, SlowCategory},
+		Group:         SortedSetGroup,
+		Since:         "2.2.0",
+		Summary:       "Returns members in a sorted set within a range of scores in reverse order.",
+		Complexity:    "O(log(N)+M) with N being the number of elements in the sorted set and M the number of elements being returned.",
+	},
+	{
+		Name:          "ZSCORE",
+		Arity:         3,
+		Flags:         []string{ReadonlyFlag, FastFlag},
+		FirstKey:      1,
+		LastKey:       1,
+		Step:          1,
+		ACLCategories: []string{ReadCategory, SortedSetCategory, FastCategory},
+		Group:         SortedSetGroup,
+		Since:         "1.2.0",
+		Summary:       "Returns the score of a member in a sorted set.",
+		Complexity:    "O(1)",
+	},
+}
+
+// coreCommandTable is the command table of the built-in commands.
+var coreCommandTable = newCoreCommandTable()
+
+func newCoreCommandTable() CommandTable {
+	table := NewCommandTable()
+	for _, info := range coreCommandInfos {
+		table.SetCommandInfo(info)
+	}
+	return table
+}
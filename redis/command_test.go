@@ -0,0 +1,53 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCommandInfoKeys(t *testing.T) {
+	records := []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{"GET", "a"}, []string{"a"}},
+		{[]string{"MSET", "a", "1", "b", "2"}, []string{"a", "b"}},
+		{[]string{"DEL", "a", "b", "c"}, []string{"a", "b", "c"}},
+		{[]string{"RENAME", "a", "b"}, []string{"a", "b"}},
+		{[]string{"PING"}, []string{}},
+		{[]string{"EVAL", "return 1", "2", "a", "b", "c"}, []string{"a", "b"}},
+		{[]string{"FCALL", "f", "0", "a"}, []string{}},
+	}
+	for _, r := range records {
+		t.Run(strings.Join(r.args, " "), func(t *testing.T) {
+			info, ok := coreCommandTable.LookupCommandInfo(r.args[0])
+			if !ok {
+				t.Errorf("%s is not found", r.args[0])
+				return
+			}
+			if !info.IsValidArity(len(r.args)) {
+				t.Errorf("%d is invalid arity (%d)", len(r.args), info.Arity)
+				return
+			}
+			keys := info.Keys(r.args)
+			if fmt.Sprintf("%v", keys) != fmt.Sprintf("%v", r.expected) {
+				t.Errorf("%v != %v", keys, r.expected)
+			}
+		})
+	}
+}
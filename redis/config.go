@@ -14,46 +14,337 @@
 
 package redis
 
-import "strings"
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/cybergarage/go-logger/log"
+	"github.com/cybergarage/go-redis/redis/glob"
+)
 
 const (
 	ConfigSep = " "
 )
 
-// Config represents a server configuration.
+// configCallback represents a change callback of the registered parameters.
+type configCallback struct {
+	names    []string
+	callback ConfigChangeCallback
+}
+
+// configState represents an immutable state of the configuration which is replaced on every change.
+type configState struct {
+	params     map[string]string
+	defs       map[string]*ConfigParam
+	callbacks  []*configCallback
+	configFile string
+	cache      sync.Map
+}
+
+// copy returns a shallow copy of the state.
+func (state *configState) copy() *configState {
+	params := make(map[string]string, len(state.params))
+	for key, value := range state.params {
+		params[key] = value
+	}
+	defs := make(map[string]*ConfigParam, len(state.defs))
+	for name, param := range state.defs {
+		defs[name] = param
+	}
+	return &configState{
+		params:     params,
+		defs:       defs,
+		callbacks:  append([]*configCallback{}, state.callbacks...),
+		configFile: state.configFile,
+		cache:      sync.Map{},
+	}
+}
+
+// Config represents a server configuration. Config is safe for concurrent use.
+// The reads are lock-free snapshot reads, and the writes replace the whole state.
 type Config struct {
-	params map[string]string
+	state    atomic.Pointer[configState]
+	mutex    sync.Mutex
+	setMutex sync.Mutex
 }
 
 // newConfig returns a new configuration.
 func newConfig() *Config {
-	return &Config{
-		params: map[string]string{},
+	cfg := &Config{
+		state:    atomic.Pointer[configState]{},
+		mutex:    sync.Mutex{},
+		setMutex: sync.Mutex{},
 	}
+	cfg.state.Store(&configState{
+		params:     map[string]string{},
+		defs:       map[string]*ConfigParam{},
+		callbacks:  []*configCallback{},
+		configFile: "",
+		cache:      sync.Map{},
+	})
+	return cfg
+}
+
+// load returns the current state.
+func (cfg *Config) load() *configState {
+	return cfg.state.Load()
+}
+
+// update replaces the current state with the state which is modified by the specified function.
+func (cfg *Config) update(fn func(state *configState)) {
+	cfg.mutex.Lock()
+	defer cfg.mutex.Unlock()
+	state := cfg.load().copy()
+	fn(state)
+	cfg.state.Store(state)
 }
 
-// SetConfig sets a specified parameter.
+// cachedValue returns the value which the specified function derives from the specified parameter.
+// The value is cached in the current state, so it is derived only once per configuration change.
+func (cfg *Config) cachedValue(name string, derive func(value string, ok bool) any) any {
+	state := cfg.load()
+	if value, ok := state.cache.Load(name); ok {
+		return value
+	}
+	param, ok := state.params[name]
+	value, _ := state.cache.LoadOrStore(name, derive(param, ok))
+	return value
+}
+
+// Snapshot returns a snapshot of the configuration which is not affected by the later changes.
+// The changes to the snapshot are not applied to the configuration.
+func (cfg *Config) Snapshot() *Config {
+	snapshot := newConfig()
+	snapshot.state.Store(cfg.load())
+	return snapshot
+}
+
+// SetConfig sets a specified parameter without the validation and the change callbacks.
 func (cfg *Config) SetConfig(key string, params string) {
-	cfg.params[key] = params
+	cfg.update(func(state *configState) {
+		state.params[key] = params
+	})
 }
 
 // AppendConfig appends a specified parameter.
 func (cfg *Config) AppendConfig(key string, params string) {
-	currParams, ok := cfg.params[key]
-	if !ok {
-		cfg.params[key] = params
-		return
-	}
-	cfg.params[key] = strings.Join([]string{currParams, params}, ConfigSep)
+	cfg.update(func(state *configState) {
+		currParams, ok := state.params[key]
+		if !ok {
+			state.params[key] = params
+			return
+		}
+		state.params[key] = strings.Join([]string{currParams, params}, ConfigSep)
+	})
 }
 
 // ConfigParameter return the specified parameter.
 func (cfg *Config) ConfigParameter(key string) (string, bool) {
-	params, ok := cfg.params[key]
+	params, ok := cfg.load().params[key]
 	return params, ok
 }
 
 // RemoveConfig removes the specified parameter.
 func (cfg *Config) RemoveConfig(key string) {
-	delete(cfg.params, key)
+	cfg.update(func(state *configState) {
+		delete(state.params, key)
+	})
+}
+
+// RegisterConfigParam registers the specified parameter definitions.
+func (cfg *Config) RegisterConfigParam(params ...*ConfigParam) {
+	cfg.update(func(state *configState) {
+		for _, param := range params {
+			state.defs[strings.ToLower(param.Name)] = param
+		}
+	})
+}
+
+// LookupConfigParam returns the definition of the specified parameter.
+func (cfg *Config) LookupConfigParam(name string) (*ConfigParam, bool) {
+	param, ok := cfg.load().defs[strings.ToLower(name)]
+	return param, ok
+}
+
+// ConfigParams returns all registered parameter definitions sorted by the name.
+func (cfg *Config) ConfigParams() []*ConfigParam {
+	defs := cfg.load().defs
+	params := make([]*ConfigParam, 0, len(defs))
+	for _, param := range defs {
+		params = append(params, param)
+	}
+	sort.Slice(params, func(i, j int) bool {
+		return params[i].Name < params[j].Name
+	})
+	return params
+}
+
+// AddConfigChangeCallback adds a callback which is called when any of the specified parameters is changed by SetConfigs.
+func (cfg *Config) AddConfigChangeCallback(callback ConfigChangeCallback, names ...string) {
+	lnames := make([]string, len(names))
+	for n, name := range names {
+		lnames[n] = strings.ToLower(name)
+	}
+	cfg.update(func(state *configState) {
+		state.callbacks = append(state.callbacks, &configCallback{
+			names:    lnames,
+			callback: callback,
+		})
+	})
+}
+
+// ConfigValue returns the specified parameter, or the default value if the registered parameter is not set.
+func (cfg *Config) ConfigValue(name string) (string, bool) {
+	return cfg.load().configValue(name)
+}
+
+func (state *configState) configValue(name string) (string, bool) {
+	name = strings.ToLower(name)
+	if value, ok := state.params[name]; ok {
+		return value, true
+	}
+	if param, ok := state.defs[name]; ok {
+		return param.Default, true
+	}
+	return "", false
+}
+
+// ConfigValues returns the parameters which match any of the specified glob-style patterns.
+func (cfg *Config) ConfigValues(patterns ...string) (map[string]string, error) {
+	globs := make([]*glob.Glob, len(patterns))
+	for n, pattern := range patterns {
+		g, err := glob.Compile(strings.ToLower(pattern))
+		if err != nil {
+			return nil, err
+		}
+		globs[n] = g
+	}
+	state := cfg.load()
+	names := map[string]bool{}
+	for name := range state.defs {
+		names[name] = true
+	}
+	for name := range state.params {
+		names[name] = true
+	}
+	values := map[string]string{}
+	for name := range names {
+		for _, g := range globs {
+			if !g.MatchString(name) {
+				continue
+			}
+			values[name], _ = state.configValue(name)
+			break
+		}
+	}
+	return values, nil
+}
+
+// SetConfigs validates and sets the specified parameters atomically, and calls the change callbacks.
+// If any parameter is invalid or any callback fails, no parameter is changed.
+// The concurrent calls are serialized to keep the callbacks in the same order as the changes.
+func (cfg *Config) SetConfigs(params map[string]string) error {
+	cfg.setMutex.Lock()
+	defer cfg.setMutex.Unlock()
+
+	state := cfg.load()
+	changes := []*ConfigChange{}
+	for key, value := range params {
+		param, ok := state.defs[strings.ToLower(key)]
+		if !ok {
+			return newErrorWith(ErrUnknownConfig, fmt.Sprintf(errorUnknownConfig, key))
+		}
+		if param.Immutable {
+			return newConfigSetError(param.Name, ErrImmutableConfig)
+		}
+		normalized, err := param.Normalize(value)
+		if err != nil {
+			return newConfigSetError(param.Name, err)
+		}
+		oldValue, _ := state.configValue(param.Name)
+		changes = append(changes, &ConfigChange{
+			Name:     param.Name,
+			OldValue: oldValue,
+			NewValue: normalized,
+		})
+	}
+
+	oldParams := map[string]*string{}
+	cfg.update(func(state *configState) {
+		for _, change := range changes {
+			if old, ok := state.params[change.Name]; ok {
+				oldParams[change.Name] = &old
+			} else {
+				oldParams[change.Name] = nil
+			}
+			state.params[change.Name] = change.NewValue
+		}
+	})
+
+	called := []*configCallback{}
+	for _, callback := range state.callbacks {
+		callbackChanges := callback.changes(changes)
+		if len(callbackChanges) == 0 {
+			continue
+		}
+		called = append(called, callback)
+		if err := callback.callback(callbackChanges); err != nil {
+			cfg.restoreConfigs(oldParams, changes, called)
+			return newConfigSetError(callback.changeNames(callbackChanges), err)
+		}
+	}
+
+	return nil
+}
+
+// restoreConfigs restores the changed parameters and calls the called callbacks again with the reverted changes.
+func (cfg *Config) restoreConfigs(oldParams map[string]*string, changes []*ConfigChange, called []*configCallback) {
+	cfg.update(func(state *configState) {
+		for name, old := range oldParams {
+			if old == nil {
+				delete(state.params, name)
+				continue
+			}
+			state.params[name] = *old
+		}
+	})
+	reverted := make([]*ConfigChange, len(changes))
+	for n, change := range changes {
+		reverted[n] = &ConfigChange{
+			Name:     change.Name,
+			OldValue: change.NewValue,
+			NewValue: change.OldValue,
+		}
+	}
+	for _, callback := range called {
+		if err := callback.callback(callback.changes(reverted)); err != nil {
+			log.Error(err)
+		}
+	}
+}
+
+// changes returns the specified changes which the callback is registered for.
+func (callback *configCallback) changes(changes []*ConfigChange) []*ConfigChange {
+	callbackChanges := []*ConfigChange{}
+	for _, change := range changes {
+		for _, name := range callback.names {
+			if change.Name == name {
+				callbackChanges = append(callbackChanges, change)
+				break
+			}
+		}
+	}
+	return callbackChanges
+}
+
+// changeNames returns the parameter names of the specified changes.
+func (callback *configCallback) changeNames(changes []*ConfigChange) string {
+	names := make([]string, len(changes))
+	for n, change := range changes {
+		names[n] = change.Name
+	}
+	return strings.Join(names, " ")
 }
@@ -0,0 +1,435 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cybergarage/go-logger/log"
+)
+
+const (
+	configIncludeDirective = "include"
+	configRewriteSignature = "# Generated by CONFIG REWRITE"
+)
+
+// splitConfigArgs splits the specified config line into the arguments in the same way as redis.conf.
+// The arguments can be quoted by double quotes with escape sequences or single quotes.
+func splitConfigArgs(line string) ([]string, error) {
+	args := []string{}
+	n := 0
+	for {
+		for n < len(line) && isConfigSpace(line[n]) {
+			n++
+		}
+		if len(line) <= n {
+			return args, nil
+		}
+		var arg strings.Builder
+		inDoubleQuotes := false
+		inSingleQuotes := false
+		done := false
+		for !done {
+			switch {
+			case inDoubleQuotes:
+				if len(line) <= n {
+					return nil, ErrUnbalancedQuotes
+				}
+				c := line[n]
+				switch {
+				case c == '\\' && n+3 < len(line) && line[n+1] == 'x' && isHexDigit(line[n+2]) && isHexDigit(line[n+3]):
+					b, _ := strconv.ParseUint(line[n+2:n+4], 16, 8)
+					arg.WriteByte(byte(b))
+					n += 3
+				case c == '\\' && n+1 < len(line):
+					n++
+					switch line[n] {
+					case 'n':
+						arg.WriteByte('\n')
+					case 'r':
+						arg.WriteByte('\r')
+					case 't':
+						arg.WriteByte('\t')
+					case 'b':
+						arg.WriteByte('\b')
+					case 'a':
+						arg.WriteByte('\a')
+					default:
+						arg.WriteByte(line[n])
+					}
+				case c == '"':
+					// The closing quote must be followed by a space or nothing at all.
+					if n+1 < len(line) && !isConfigSpace(line[n+1]) {
+						return nil, ErrUnbalancedQuotes
+					}
+					done = true
+				default:
+					arg.WriteByte(c)
+				}
+			case inSingleQuotes:
+				if len(line) <= n {
+					return nil, ErrUnbalancedQuotes
+				}
+				c := line[n]
+				switch {
+				case c == '\\' && n+1 < len(line) && line[n+1] == '\'':
+					n++
+					arg.WriteByte('\'')
+				case c == '\'':
+					if n+1 < len(line) && !isConfigSpace(line[n+1]) {
+						return nil, ErrUnbalancedQuotes
+					}
+					done = true
+				default:
+					arg.WriteByte(c)
+				}
+			default:
+				if len(line) <= n {
+					done = true
+					break
+				}
+				switch c := line[n]; c {
+				case ' ', '\n', '\r', '\t', 0:
+					done = true
+				case '"':
+					inDoubleQuotes = true
+				case '\'':
+					inSingleQuotes = true
+				default:
+					arg.WriteByte(c)
+				}
+			}
+			if n < len(line) {
+				n++
+			}
+		}
+		args = append(args, arg.String())
+	}
+}
+
+func isConfigSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == 0
+}
+
+func isHexDigit(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+// quoteConfigArg returns the specified argument quoted by double quotes if necessary.
+func quoteConfigArg(arg string) string {
+	needsQuotes := len(arg) == 0
+	for _, c := range []byte(arg) {
+		if c <= ' ' || 0x7e < c || c == '"' || c == '\'' || c == '\\' {
+			needsQuotes = true
+			break
+		}
+	}
+	if !needsQuotes {
+		return arg
+	}
+	var quoted strings.Builder
+	quoted.WriteByte('"')
+	for _, c := range []byte(arg) {
+		switch c {
+		case '\\', '"':
+			quoted.WriteByte('\\')
+			quoted.WriteByte(c)
+		case '\n':
+			quoted.WriteString("\\n")
+		case '\r':
+			quoted.WriteString("\\r")
+		case '\t':
+			quoted.WriteString("\\t")
+		case '\a':
+			quoted.WriteString("\\a")
+		case '\b':
+			quoted.WriteString("\\b")
+		default:
+			if c < 0x20 || 0x7e < c {
+				fmt.Fprintf(&quoted, "\\x%02x", c)
+			} else {
+				quoted.WriteByte(c)
+			}
+		}
+	}
+	quoted.WriteByte('"')
+	return quoted.String()
+}
+
+// joinConfigArgs returns the specified arguments joined by spaces with the quotes if necessary.
+func joinConfigArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for n, arg := range args {
+		quoted[n] = quoteConfigArg(arg)
+	}
+	return strings.Join(quoted, ConfigSep)
+}
+
+// LoadConfigFile loads the specified redis.conf format file. The include directives are loaded recursively,
+// and the repeated directives of the multiple parameters such as rename-command are appended.
+// The directives which are not registered are kept as they are.
+func (cfg *Config) LoadConfigFile(path string) error {
+	if err := cfg.loadConfigFile(path, map[string]bool{}, map[string]bool{}); err != nil {
+		return err
+	}
+	cfg.update(func(state *configState) {
+		state.configFile = path
+	})
+	return nil
+}
+
+func (cfg *Config) loadConfigFile(path string, includes map[string]bool, loaded map[string]bool) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if includes[absPath] {
+		return fmt.Errorf("%w include (%s)", ErrInvalid, path)
+	}
+	includes[absPath] = true
+	defer delete(includes, absPath)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		args, err := splitConfigArgs(line)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+		if len(args) == 0 {
+			continue
+		}
+		if err := cfg.loadConfigDirective(args, includes, loaded); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+	}
+	return scanner.Err()
+}
+
+func (cfg *Config) loadConfigDirective(args []string, includes map[string]bool, loaded map[string]bool) error {
+	name := strings.ToLower(args[0])
+	values := args[1:]
+
+	if name == configIncludeDirective {
+		if len(values) != 1 {
+			return ErrWrongNumberOfArguments
+		}
+		paths, err := filepath.Glob(values[0])
+		if err != nil {
+			return err
+		}
+		if len(paths) == 0 {
+			return fmt.Errorf("%w include (%s)", ErrNoSuchFile, values[0])
+		}
+		for _, path := range paths {
+			if err := cfg.loadConfigFile(path, includes, loaded); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	param, ok := cfg.LookupConfigParam(name)
+	if !ok {
+		log.Warnf("%s/%s unknown directive (%s)", PackageName, Version, name)
+		if loaded[name] {
+			cfg.AppendConfig(name, strings.Join(values, ConfigSep))
+		} else {
+			cfg.SetConfig(name, strings.Join(values, ConfigSep))
+		}
+		loaded[name] = true
+		return nil
+	}
+
+	if 0 < param.DirectiveArgs && len(values) != param.DirectiveArgs {
+		return ErrWrongNumberOfArguments
+	}
+	value := strings.Join(values, ConfigSep)
+	if param.Multiple {
+		value = joinConfigArgs(values)
+	}
+	value, err := param.Normalize(value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if param.Multiple && loaded[name] {
+		cfg.AppendConfig(param.Name, value)
+	} else {
+		cfg.SetConfig(param.Name, value)
+	}
+	loaded[name] = true
+	return nil
+}
+
+// ConfigFile returns the path of the loaded config file.
+func (cfg *Config) ConfigFile() (string, bool) {
+	path := cfg.load().configFile
+	return path, 0 < len(path)
+}
+
+// configDirectiveLines returns the directive lines of the current value of the specified parameter.
+func (cfg *Config) configDirectiveLines(param *ConfigParam) []string {
+	value, _ := cfg.ConfigValue(param.Name)
+	var args []string
+	switch {
+	case param.Multiple:
+		args, _ = splitConfigArgs(value)
+	case param.Type == StringConfig:
+		args = []string{value}
+	default:
+		args = strings.Fields(value)
+	}
+	if param.Multiple {
+		if len(args) == 0 {
+			return []string{}
+		}
+		lines := []string{}
+		step := param.DirectiveArgs
+		if step <= 0 {
+			step = len(args)
+		}
+		for n := 0; n+step <= len(args); n += step {
+			lines = append(lines, configDirectiveLine(param.Name, args[n:n+step]))
+		}
+		return lines
+	}
+	return []string{configDirectiveLine(param.Name, args)}
+}
+
+func configDirectiveLine(name string, args []string) string {
+	line := name
+	if len(args) == 0 {
+		return line + ` ""`
+	}
+	for _, arg := range args {
+		line += " " + quoteConfigArg(arg)
+	}
+	return line
+}
+
+// isConfigChanged returns true if the specified parameter is different from the default value.
+func (cfg *Config) isConfigChanged(param *ConfigParam) bool {
+	value, _ := cfg.ConfigValue(param.Name)
+	return value != param.Default
+}
+
+// RewriteConfigFile rewrites the loaded config file with the current parameters. The comments and the order of the
+// directives are preserved, the repeated directives are replaced with the current values, and the changed parameters
+// which are not in the file are appended.
+func (cfg *Config) RewriteConfigFile() error {
+	cfg.setMutex.Lock()
+	defer cfg.setMutex.Unlock()
+
+	path, ok := cfg.ConfigFile()
+	if !ok {
+		return ErrNoConfigFile
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	lines := []string{}
+	rewritten := map[string]bool{}
+	if 0 < len(content) {
+		for _, line := range strings.Split(strings.TrimSuffix(string(content), "\n"), "\n") {
+			if line == configRewriteSignature {
+				continue
+			}
+			trimmed := strings.TrimSpace(line)
+			if len(trimmed) == 0 || strings.HasPrefix(trimmed, "#") {
+				lines = append(lines, line)
+				continue
+			}
+			args, err := splitConfigArgs(trimmed)
+			if err != nil || len(args) == 0 {
+				lines = append(lines, line)
+				continue
+			}
+			param, ok := cfg.LookupConfigParam(args[0])
+			if !ok {
+				lines = append(lines, line)
+				continue
+			}
+			// Replaces the first directive with the current value, and removes the repeated directives.
+			if rewritten[param.Name] {
+				continue
+			}
+			rewritten[param.Name] = true
+			lines = append(lines, cfg.configDirectiveLines(param)...)
+		}
+	}
+
+	appended := false
+	for _, param := range cfg.ConfigParams() {
+		if rewritten[param.Name] || !cfg.isConfigChanged(param) {
+			continue
+		}
+		if !appended {
+			lines = append(lines, configRewriteSignature)
+			appended = true
+		}
+		lines = append(lines, cfg.configDirectiveLines(param)...)
+	}
+
+	return writeFileAtomically(path, []byte(strings.Join(lines, "\n")+"\n"))
+}
+
+// writeFileAtomically writes the specified content into a temporary file and renames it to the specified path.
+func writeFileAtomically(path string, content []byte) error {
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode()
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+	if _, err := tmpFile.Write(content); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, mode); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
@@ -0,0 +1,142 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSplitConfigArgs(t *testing.T) {
+	lines := []struct {
+		line     string
+		expected []string
+	}{
+		{"port 6379", []string{"port", "6379"}},
+		{"  bind   127.0.0.1 -::1  ", []string{"bind", "127.0.0.1", "-::1"}},
+		{`requirepass "foo bar"`, []string{"requirepass", "foo bar"}},
+		{`requirepass "a\"b\\c\n\x41"`, []string{"requirepass", "a\"b\\c\nA"}},
+		{`requirepass 'it\'s'`, []string{"requirepass", "it's"}},
+		{`unixsocket ""`, []string{"unixsocket", ""}},
+	}
+	for _, line := range lines {
+		t.Run(line.line, func(t *testing.T) {
+			args, err := splitConfigArgs(line.line)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if !reflect.DeepEqual(args, line.expected) {
+				t.Errorf("%q != %q", args, line.expected)
+			}
+			for _, arg := range args {
+				quoted, err := splitConfigArgs(quoteConfigArg(arg))
+				if err != nil || len(quoted) != 1 || quoted[0] != arg {
+					t.Errorf("%q != %q (%v)", quoted, arg, err)
+				}
+			}
+		})
+	}
+
+	for _, line := range []string{`requirepass "foo`, `requirepass "foo"bar`, `requirepass 'foo`} {
+		if _, err := splitConfigArgs(line); err == nil {
+			t.Errorf("%s is parsed", line)
+		}
+	}
+}
+
+func TestConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	includePath := filepath.Join(dir, "include.conf")
+	include := "maxclients 100\n"
+	if err := os.WriteFile(includePath, []byte(include), 0o600); err != nil {
+		t.Error(err)
+		return
+	}
+
+	path := filepath.Join(dir, "redis.conf")
+	conf := "# Network\n" +
+		"bind 127.0.0.1 ::1\n" +
+		"port 6380\n" +
+		"\n" +
+		"# Security\n" +
+		"requirepass \"foo bar\"\n" +
+		"include " + includePath + "\n" +
+		"rename-command FLUSHDB \"\"\n" +
+		"rename-command CONFIG CONFIG2\n" +
+		"save 900 1\n" +
+		"save 300 10\n"
+	if err := os.WriteFile(path, []byte(conf), 0o600); err != nil {
+		t.Error(err)
+		return
+	}
+
+	cfg := NewDefaultServerConfig()
+	if err := cfg.LoadConfigFile(path); err != nil {
+		t.Error(err)
+		return
+	}
+
+	params := []struct {
+		name     string
+		expected string
+	}{
+		{"bind", "127.0.0.1 ::1"},
+		{"port", "6380"},
+		{"requirepass", "foo bar"},
+		{"maxclients", "100"},
+		{"rename-command", `FLUSHDB "" CONFIG CONFIG2`},
+		{"save", "900 1 300 10"},
+	}
+	for _, param := range params {
+		if val, _ := cfg.ConfigValue(param.name); val != param.expected {
+			t.Errorf("%s: %q != %q", param.name, val, param.expected)
+		}
+	}
+
+	if err := cfg.SetConfigs(map[string]string{"port": "6381", "slowlog-max-len": "64"}); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := cfg.RewriteConfigFile(); err != nil {
+		t.Error(err)
+		return
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	expected := "# Network\n" +
+		"bind 127.0.0.1 ::1\n" +
+		"port 6381\n" +
+		"\n" +
+		"# Security\n" +
+		"requirepass \"foo bar\"\n" +
+		"include " + includePath + "\n" +
+		"rename-command FLUSHDB \"\"\n" +
+		"rename-command CONFIG CONFIG2\n" +
+		"save 900 1\n" +
+		"save 300 10\n" +
+		configRewriteSignature + "\n" +
+		"maxclients 100\n" +
+		"slowlog-max-len 64\n"
+	if string(content) != expected {
+		t.Errorf("\n%s\n!=\n%s", string(content), expected)
+	}
+}
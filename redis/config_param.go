@@ -0,0 +1,232 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConfigType represents a value type of the configuration parameter.
+type ConfigType int
+
+const (
+	// StringConfig is a string parameter.
+	StringConfig ConfigType = iota
+	// IntegerConfig is an integer parameter.
+	IntegerConfig
+	// BooleanConfig is a yes or no parameter.
+	BooleanConfig
+	// EnumConfig is a parameter which is one of the enumerated values.
+	EnumConfig
+	// MemoryConfig is a memory size parameter such as 100mb.
+	MemoryConfig
+	// DurationConfig is a duration parameter which is an integer in the unit or a duration string such as 5m.
+	DurationConfig
+	// StringListConfig is a parameter of the strings separated by spaces.
+	StringListConfig
+)
+
+// ConfigParam represents a definition of a configuration parameter.
+type ConfigParam struct {
+	// Name is the parameter name.
+	Name string
+	// Type is the value type.
+	Type ConfigType
+	// Default is the default value.
+	Default string
+	// Immutable is true if the parameter can't be changed at runtime by CONFIG SET.
+	Immutable bool
+	// Min is the minimum value of the integer, memory and duration parameters.
+	Min int64
+	// Max is the maximum value of the integer, memory and duration parameters.
+	Max int64
+	// Enums is the allowed values of the enum parameter.
+	Enums []string
+	// Unit is the unit of the duration parameter.
+	Unit time.Duration
+	// Validator is an additional validation function of the normalized value.
+	Validator func(value string) error
+	// Multiple is true if the repeated directives in the config file are appended such as rename-command.
+	// The arguments of the multiple parameters are quoted in the same way as the config file.
+	Multiple bool
+	// DirectiveArgs is the number of the arguments of a directive in the config file, or zero if it is variable.
+	DirectiveArgs int
+}
+
+// newConfigParam returns a new configuration parameter with the specified type.
+func newConfigParam(name string, t ConfigType, defaultValue string) *ConfigParam {
+	return &ConfigParam{
+		Name:          strings.ToLower(name),
+		Type:          t,
+		Default:       defaultValue,
+		Immutable:     false,
+		Min:           0,
+		Max:           0,
+		Enums:         []string{},
+		Unit:          0,
+		Validator:     nil,
+		Multiple:      false,
+		DirectiveArgs: 0,
+	}
+}
+
+// NewStringConfigParam returns a new string parameter.
+func NewStringConfigParam(name string, defaultValue string) *ConfigParam {
+	return newConfigParam(name, StringConfig, defaultValue)
+}
+
+// NewIntegerConfigParam returns a new integer parameter in the specified range.
+func NewIntegerConfigParam(name string, defaultValue int64, minValue int64, maxValue int64) *ConfigParam {
+	param := newConfigParam(name, IntegerConfig, strconv.FormatInt(defaultValue, 10))
+	param.Min = minValue
+	param.Max = maxValue
+	return param
+}
+
+// NewBooleanConfigParam returns a new yes or no parameter.
+func NewBooleanConfigParam(name string, defaultValue bool) *ConfigParam {
+	return newConfigParam(name, BooleanConfig, formatBoolean(defaultValue))
+}
+
+// NewEnumConfigParam returns a new parameter which is one of the specified values.
+func NewEnumConfigParam(name string, defaultValue string, enums ...string) *ConfigParam {
+	param := newConfigParam(name, EnumConfig, defaultValue)
+	param.Enums = enums
+	return param
+}
+
+// NewMemoryConfigParam returns a new memory size parameter in the specified range of bytes.
+func NewMemoryConfigParam(name string, defaultValue int64, minValue int64, maxValue int64) *ConfigParam {
+	param := newConfigParam(name, MemoryConfig, strconv.FormatInt(defaultValue, 10))
+	param.Min = minValue
+	param.Max = maxValue
+	return param
+}
+
+// NewDurationConfigParam returns a new duration parameter in the specified unit and range.
+func NewDurationConfigParam(name string, defaultValue time.Duration, unit time.Duration, minValue time.Duration, maxValue time.Duration) *ConfigParam {
+	param := newConfigParam(name, DurationConfig, strconv.FormatInt(int64(defaultValue/unit), 10))
+	param.Unit = unit
+	param.Min = int64(minValue / unit)
+	param.Max = int64(maxValue / unit)
+	return param
+}
+
+// NewStringListConfigParam returns a new parameter of the strings separated by spaces.
+func NewStringListConfigParam(name string, defaultValues ...string) *ConfigParam {
+	return newConfigParam(name, StringListConfig, strings.Join(defaultValues, ConfigSep))
+}
+
+// SetImmutable sets the immutable flag which prevents changing the parameter at runtime.
+func (param *ConfigParam) SetImmutable(immutable bool) *ConfigParam {
+	param.Immutable = immutable
+	return param
+}
+
+// SetValidator sets an additional validation function of the normalized value.
+func (param *ConfigParam) SetValidator(validator func(value string) error) *ConfigParam {
+	param.Validator = validator
+	return param
+}
+
+// SetMultiple sets the multiple flag which appends the repeated directives with the specified number of the arguments in the config file.
+func (param *ConfigParam) SetMultiple(directiveArgs int) *ConfigParam {
+	param.Multiple = true
+	param.DirectiveArgs = directiveArgs
+	return param
+}
+
+// Normalize validates the specified value and returns the normalized value.
+func (param *ConfigParam) Normalize(value string) (string, error) {
+	normalized, err := param.normalize(value)
+	if err != nil {
+		return "", err
+	}
+	if param.Validator != nil {
+		if err := param.Validator(normalized); err != nil {
+			return "", err
+		}
+	}
+	return normalized, nil
+}
+
+func (param *ConfigParam) normalize(value string) (string, error) {
+	switch param.Type {
+	case StringConfig:
+		return value, nil
+	case IntegerConfig:
+		val, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return "", errors.New("argument couldn't be parsed into an integer")
+		}
+		return param.normalizeRange(val)
+	case BooleanConfig:
+		switch strings.ToLower(value) {
+		case "yes":
+			return "yes", nil
+		case "no":
+			return "no", nil
+		}
+		return "", errors.New("argument must be 'yes' or 'no'")
+	case EnumConfig:
+		for _, enum := range param.Enums {
+			if strings.EqualFold(value, enum) {
+				return enum, nil
+			}
+		}
+		return "", fmt.Errorf("argument(s) must be one of the following: %s", strings.Join(param.Enums, ", "))
+	case MemoryConfig:
+		val, err := parseMemorySize(value)
+		if err != nil {
+			return "", errors.New("argument must be a memory value")
+		}
+		return param.normalizeRange(val)
+	case DurationConfig:
+		val, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return "", errors.New("argument couldn't be parsed into a duration")
+			}
+			val = int64(d / param.Unit)
+		}
+		return param.normalizeRange(val)
+	case StringListConfig:
+		return strings.Join(strings.Fields(value), ConfigSep), nil
+	}
+	return value, nil
+}
+
+func (param *ConfigParam) normalizeRange(val int64) (string, error) {
+	if val < param.Min || param.Max < val {
+		return "", fmt.Errorf("argument must be between %d and %d inclusive", param.Min, param.Max)
+	}
+	return strconv.FormatInt(val, 10), nil
+}
+
+// ConfigChange represents a changed parameter by CONFIG SET.
+type ConfigChange struct {
+	Name     string
+	OldValue string
+	NewValue string
+}
+
+// ConfigChangeCallback is a function which is called when the registered parameters are changed by CONFIG SET.
+// If the callback returns an error, all changed parameters are restored.
+type ConfigChangeCallback func(changes []*ConfigChange) error
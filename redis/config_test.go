@@ -0,0 +1,137 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConfigParamNormalize(t *testing.T) {
+	params := []struct {
+		param    *ConfigParam
+		value    string
+		expected string
+	}{
+		{NewStringConfigParam("str", ""), "abc", "abc"},
+		{NewIntegerConfigParam("int", 0, -1, 100), "-1", "-1"},
+		{NewIntegerConfigParam("int", 0, -1, 100), "100", "100"},
+		{NewBooleanConfigParam("bool", false), "YES", "yes"},
+		{NewEnumConfigParam("enum", "a", "allkeys-lru", "noeviction"), "NoEviction", "noeviction"},
+		{NewMemoryConfigParam("mem", 0, 0, 1<<40), "100mb", "104857600"},
+		{NewDurationConfigParam("duration", 0, time.Second, 0, time.Hour), "300", "300"},
+		{NewDurationConfigParam("duration", 0, time.Second, 0, time.Hour), "5m", "300"},
+		{NewStringListConfigParam("list"), " 127.0.0.1   ::1 ", "127.0.0.1 ::1"},
+	}
+	for _, param := range params {
+		t.Run(param.param.Name+"/"+param.value, func(t *testing.T) {
+			val, err := param.param.Normalize(param.value)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if val != param.expected {
+				t.Errorf("%s != %s", val, param.expected)
+			}
+		})
+	}
+
+	invalidParams := []struct {
+		param *ConfigParam
+		value string
+	}{
+		{NewIntegerConfigParam("int", 0, -1, 100), "abc"},
+		{NewIntegerConfigParam("int", 0, -1, 100), "101"},
+		{NewBooleanConfigParam("bool", false), "true"},
+		{NewEnumConfigParam("enum", "a", "allkeys-lru", "noeviction"), "lru"},
+		{NewMemoryConfigParam("mem", 0, 0, 1<<40), "100tb"},
+		{NewDurationConfigParam("duration", 0, time.Second, 0, time.Hour), "2h"},
+	}
+	for _, param := range invalidParams {
+		t.Run(param.param.Name+"/"+param.value, func(t *testing.T) {
+			if _, err := param.param.Normalize(param.value); err == nil {
+				t.Errorf("%s is accepted", param.value)
+			}
+		})
+	}
+}
+
+func TestConfigSetConfigs(t *testing.T) {
+	cfg := newConfig()
+	cfg.RegisterConfigParam(
+		NewIntegerConfigParam("maxclients", 10000, 0, 100000),
+		NewIntegerConfigParam("maxclients-per-ip", 0, 0, 100000),
+		NewIntegerConfigParam("port", 6379, 0, 65535),
+		NewIntegerConfigParam("metrics-port", 0, 0, 65535).SetImmutable(true),
+	)
+
+	calls := 0
+	cfg.AddConfigChangeCallback(func(changes []*ConfigChange) error {
+		calls++
+		if changes[0].NewValue == "1" {
+			return errors.New("unavailable port")
+		}
+		return nil
+	}, "port")
+
+	if err := cfg.SetConfigs(map[string]string{"maxclients": "100", "port": "6380"}); err != nil {
+		t.Error(err)
+		return
+	}
+	if val, _ := cfg.ConfigValue("maxclients"); val != "100" {
+		t.Errorf("%s != %s", val, "100")
+	}
+	if calls != 1 {
+		t.Errorf("%d != %d", calls, 1)
+	}
+
+	errs := []struct {
+		params   map[string]string
+		expected error
+	}{
+		{map[string]string{"unknown": "1"}, ErrUnknownConfig},
+		{map[string]string{"maxclients": "abc"}, ErrConfigSet},
+		{map[string]string{"metrics-port": "9181"}, ErrImmutableConfig},
+		{map[string]string{"maxclients": "200", "port": "1"}, ErrConfigSet},
+	}
+	for _, e := range errs {
+		if err := cfg.SetConfigs(e.params); !errors.Is(err, e.expected) {
+			t.Errorf("%v != %v", err, e.expected)
+		}
+	}
+
+	// The parameters are restored if the callback fails.
+	for name, expected := range map[string]string{"maxclients": "100", "port": "6380"} {
+		if val, _ := cfg.ConfigValue(name); val != expected {
+			t.Errorf("%s != %s", val, expected)
+		}
+	}
+
+	values, err := cfg.ConfigValues("max*")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	expected := map[string]string{"maxclients": "100", "maxclients-per-ip": "0"}
+	if len(values) != len(expected) {
+		t.Errorf("%v != %v", values, expected)
+	}
+	for name, val := range expected {
+		if values[name] != val {
+			t.Errorf("%s != %s", values[name], val)
+		}
+	}
+}
@@ -15,32 +15,108 @@
 package redis
 
 import (
+	"context"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cybergarage/go-tracing/tracer"
 )
 
+// ClientType represents a client class of the connection.
+type ClientType int32
+
+const (
+	// NormalClient is a normal client.
+	NormalClient ClientType = iota
+	// PubSubClient is a client which subscribes to channels or patterns.
+	PubSubClient
+	// ReplicaClient is a replica server connected to the server.
+	ReplicaClient
+	// MonitorClient is a client which is running MONITOR command.
+	MonitorClient
+	// MasterClient is the connection of a replica to the master which sends the replication stream.
+	MasterClient
+)
+
+// String returns the client type name.
+func (t ClientType) String() string {
+	switch t {
+	case NormalClient:
+		return "normal"
+	case PubSubClient:
+		return "pubsub"
+	case ReplicaClient:
+		return "replica"
+	case MonitorClient:
+		return "monitor"
+	case MasterClient:
+		return "master"
+	}
+	return ""
+}
+
+// outputBufferClass returns the client class of the output buffer limits. Monitor and master clients are limited as normal clients.
+func (t ClientType) outputBufferClass() ClientType {
+	if t == MonitorClient || t == MasterClient {
+		return NormalClient
+	}
+	return t
+}
+
 // Conn represents a database connection.
 type Conn struct {
 	net.Conn
-	id        DatabaseID
-	authrized bool
+	id         DatabaseID
+	name       string
+	authrized  bool
+	writeMutex sync.Mutex
 	sync.Map
 	ts time.Time
 	tracer.Context
+	ctx             context.Context
+	cmdCtx          context.Context
+	clientType      atomic.Int32
+	blocked         atomic.Bool
+	asking          atomic.Bool
+	scripting       atomic.Bool
+	lastInteraction atomic.Int64
+	output          *outputBuffer
+}
+
+func newConnWith(netConn net.Conn) *Conn {
+	conn := &Conn{
+		Conn:            netConn,
+		authrized:       false,
+		writeMutex:      sync.Mutex{},
+		id:              0,
+		name:            "",
+		Map:             sync.Map{},
+		ts:              time.Now(),
+		Context:         nil,
+		ctx:             context.Background(),
+		cmdCtx:          nil,
+		clientType:      atomic.Int32{},
+		blocked:         atomic.Bool{},
+		asking:          atomic.Bool{},
+		scripting:       atomic.Bool{},
+		lastInteraction: atomic.Int64{},
+		output:          nil,
+	}
+	conn.lastInteraction.Store(conn.ts.UnixNano())
+	return conn
 }
 
-func newConnWith(conn net.Conn) *Conn {
-	return &Conn{
-		Conn:      conn,
-		authrized: false,
-		id:        0,
-		Map:       sync.Map{},
-		ts:        time.Now(),
-		Context:   nil,
+// Write writes the specified bytes to the connection exclusively.
+// The bytes are queued and written asynchronously if the output writer is started.
+func (conn *Conn) Write(b []byte) (int, error) {
+	if conn.output != nil {
+		return conn.enqueueOutput(b, false)
 	}
+	conn.writeMutex.Lock()
+	defer conn.writeMutex.Unlock()
+	return conn.Conn.Write(b)
 }
 
 // SetDatabase sets the selected database number to the connection.
@@ -53,6 +129,16 @@ func (conn *Conn) Database() DatabaseID {
 	return conn.id
 }
 
+// SetName sets the client name to the connection.
+func (conn *Conn) SetName(name string) {
+	conn.name = name
+}
+
+// Name returns the client name of the connection.
+func (conn *Conn) Name() string {
+	return conn.name
+}
+
 // SetAuthrized sets the authrized flag to the connection.
 func (conn *Conn) SetAuthrized(authrized bool) {
 	conn.authrized = authrized
@@ -77,3 +163,74 @@ func (conn *Conn) SetSpanContext(span tracer.Context) {
 func (conn *Conn) SpanContext() tracer.Context {
 	return conn.Context
 }
+
+// setContext sets the connection context which is cancelled when the connection is closed.
+func (conn *Conn) setContext(ctx context.Context) {
+	conn.ctx = ctx
+}
+
+// SetCommandContext sets the context of the current command.
+// The context should be derived from the current command context to keep the cancellation.
+func (conn *Conn) SetCommandContext(ctx context.Context) {
+	conn.cmdCtx = ctx
+}
+
+// CommandContext returns the context of the current command.
+// The context is cancelled when the client disconnects or the server is stopped, and it carries
+// the tracer span context of the command. It has no deadline.
+func (conn *Conn) CommandContext() context.Context {
+	if conn.cmdCtx != nil {
+		return conn.cmdCtx
+	}
+	return conn.ctx
+}
+
+// SetClientType sets the client class of the connection.
+func (conn *Conn) SetClientType(t ClientType) {
+	conn.clientType.Store(int32(t))
+}
+
+// ClientType returns the client class of the connection.
+func (conn *Conn) ClientType() ClientType {
+	return ClientType(conn.clientType.Load())
+}
+
+// SetBlocked sets the blocked flag which is true while the connection is executing or waiting in a command.
+func (conn *Conn) SetBlocked(blocked bool) {
+	conn.blocked.Store(blocked)
+}
+
+// IsBlocked returns true if the connection is executing or waiting in a command.
+func (conn *Conn) IsBlocked() bool {
+	return conn.blocked.Load()
+}
+
+// setAsking sets the ASKING flag which allows the next command to access an importing hash slot.
+func (conn *Conn) setAsking(asking bool) {
+	conn.asking.Store(asking)
+}
+
+// IsAsking returns true if the connection has sent ASKING command before the current command.
+func (conn *Conn) IsAsking() bool {
+	return conn.asking.Load()
+}
+
+// setScripting sets the flag which indicates the connection is running a script.
+func (conn *Conn) setScripting(scripting bool) {
+	conn.scripting.Store(scripting)
+}
+
+// IsScripting returns true if the command of the connection is called from a script.
+func (conn *Conn) IsScripting() bool {
+	return conn.scripting.Load()
+}
+
+// updateLastInteraction updates the last interaction time of the connection.
+func (conn *Conn) updateLastInteraction() {
+	conn.lastInteraction.Store(time.Now().UnixNano())
+}
+
+// LastInteraction returns the last interaction time of the connection.
+func (conn *Conn) LastInteraction() time.Time {
+	return time.Unix(0, conn.lastInteraction.Load())
+}
@@ -0,0 +1,271 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// outputFlushTimeout is the timeout to write the queued bytes when the output writer is stopped.
+const outputFlushTimeout = 5 * time.Second
+
+// outputBuffer represents the output bytes of a connection which are pending to be written to the network.
+type outputBuffer struct {
+	sync.Mutex
+	chunks         []outputChunk
+	pending        int64
+	bulk           int64
+	softLimitTime  time.Time
+	softLimitTimer *time.Timer
+	closed         bool
+	limit         func(ClientType) ClientOutputBufferLimit
+	onLimit       func(conn *Conn)
+	notifyCh      chan struct{}
+	doneCh        chan struct{}
+}
+
+// outputChunk represents the queued bytes. The bytes of a bulk transfer such as the RDB snapshot to a replica
+// are not counted against the output buffer limit.
+type outputChunk struct {
+	bytes  []byte
+	isBulk bool
+}
+
+// startWriter starts the output writer which writes the queued bytes asynchronously.
+// The specified limit function returns the output buffer limit of the client class, and
+// the specified callback is called when the connection is closed by the limit.
+func (conn *Conn) startWriter(limit func(ClientType) ClientOutputBufferLimit, onLimit func(conn *Conn)) {
+	conn.output = &outputBuffer{
+		Mutex:          sync.Mutex{},
+		chunks:         []outputChunk{},
+		pending:        0,
+		bulk:           0,
+		softLimitTime:  time.Time{},
+		softLimitTimer: nil,
+		closed:         false,
+		limit:          limit,
+		onLimit:        onLimit,
+		notifyCh:       make(chan struct{}, 1),
+		doneCh:         make(chan struct{}),
+	}
+	go conn.writeOutputs()
+}
+
+// stopWriter stops the output writer after the queued bytes are written.
+// The queued bytes are given up if the client doesn't read them for the flush timeout.
+func (conn *Conn) stopWriter() {
+	output := conn.output
+	if output == nil {
+		return
+	}
+	output.Lock()
+	output.closed = true
+	output.stopSoftLimitTimer()
+	output.Unlock()
+	output.notify()
+	conn.Conn.SetWriteDeadline(time.Now().Add(outputFlushTimeout))
+	<-output.doneCh
+}
+
+// OutputBufferLength returns the number of the bytes which are pending to be written to the connection.
+func (conn *Conn) OutputBufferLength() int64 {
+	output := conn.output
+	if output == nil {
+		return 0
+	}
+	output.Lock()
+	defer output.Unlock()
+	return output.pending
+}
+
+// notify wakes up the output writer.
+func (output *outputBuffer) notify() {
+	select {
+	case output.notifyCh <- struct{}{}:
+	default:
+	}
+}
+
+// writeBulk writes the specified bytes of a bulk transfer such as the RDB snapshot to a replica.
+// The bytes are not counted against the output buffer limit of the client class.
+func (conn *Conn) writeBulk(b []byte) (int, error) {
+	if conn.output == nil {
+		return conn.Write(b)
+	}
+	return conn.enqueueOutput(b, true)
+}
+
+// enqueueOutput queues the specified bytes to the output writer, and closes the connection if the output buffer limit is reached.
+// The bytes of a bulk transfer are not counted against the limit.
+func (conn *Conn) enqueueOutput(b []byte, isBulk bool) (int, error) {
+	output := conn.output
+	output.Lock()
+	if output.closed {
+		output.Unlock()
+		return 0, net.ErrClosed
+	}
+	buf := make([]byte, len(b))
+	copy(buf, b)
+	output.chunks = append(output.chunks, outputChunk{bytes: buf, isBulk: isBulk})
+	output.pending += int64(len(buf))
+	if isBulk {
+		output.bulk += int64(len(buf))
+	}
+	isLimitReached := conn.checkOutputLimit(time.Now())
+	output.Unlock()
+
+	if isLimitReached {
+		conn.closeByOutputLimit()
+		return 0, ErrOutputLimit
+	}
+
+	output.notify()
+	return len(b), nil
+}
+
+// checkOutputLimit returns true and discards the queued bytes if the output buffer limit is reached.
+// While the pending bytes exceed the soft limit, the soft limit timer checks the limit again after the soft limit
+// duration even if no bytes are queued or written. It should be called with the output buffer locked.
+func (conn *Conn) checkOutputLimit(now time.Time) bool {
+	output := conn.output
+	if output.closed {
+		return false
+	}
+	if output.isLimitReached(conn.ClientType(), now) {
+		output.closed = true
+		output.discard()
+		output.stopSoftLimitTimer()
+		return true
+	}
+	if output.softLimitTime.IsZero() {
+		output.stopSoftLimitTimer()
+		return false
+	}
+	if output.softLimitTimer == nil {
+		softSeconds := time.Duration(output.limit(conn.ClientType()).SoftSeconds) * time.Second
+		output.softLimitTimer = time.AfterFunc(output.softLimitTime.Add(softSeconds).Sub(now), conn.onSoftLimitTimer)
+	}
+	return false
+}
+
+// onSoftLimitTimer checks the output buffer limit when the soft limit duration passes.
+func (conn *Conn) onSoftLimitTimer() {
+	output := conn.output
+	output.Lock()
+	output.softLimitTimer = nil
+	isLimitReached := conn.checkOutputLimit(time.Now())
+	output.Unlock()
+
+	if isLimitReached {
+		conn.closeByOutputLimit()
+	}
+}
+
+// closeByOutputLimit closes the connection which reaches the output buffer limit.
+func (conn *Conn) closeByOutputLimit() {
+	conn.output.onLimit(conn)
+	conn.Conn.Close()
+}
+
+// stopSoftLimitTimer stops the soft limit timer. It should be called with the output buffer locked.
+func (output *outputBuffer) stopSoftLimitTimer() {
+	if output.softLimitTimer == nil {
+		return
+	}
+	output.softLimitTimer.Stop()
+	output.softLimitTimer = nil
+}
+
+// discard discards the queued bytes which are not written yet.
+func (output *outputBuffer) discard() {
+	for _, chunk := range output.chunks {
+		output.pending -= int64(len(chunk.bytes))
+		if chunk.isBulk {
+			output.bulk -= int64(len(chunk.bytes))
+		}
+	}
+	output.chunks = []outputChunk{}
+}
+
+// isLimitReached returns true if the pending bytes except the bulk transfers reach the hard limit,
+// or exceed the soft limit for the soft limit duration.
+func (output *outputBuffer) isLimitReached(t ClientType, now time.Time) bool {
+	limit := output.limit(t)
+	pending := output.pending - output.bulk
+	if 0 < limit.HardLimit && limit.HardLimit <= pending {
+		return true
+	}
+	if limit.SoftLimit <= 0 || pending < limit.SoftLimit {
+		output.softLimitTime = time.Time{}
+		return false
+	}
+	if output.softLimitTime.IsZero() {
+		output.softLimitTime = now
+	}
+	return time.Duration(limit.SoftSeconds)*time.Second < now.Sub(output.softLimitTime)
+}
+
+// writeOutputs writes the queued bytes to the network until the writer is stopped.
+func (conn *Conn) writeOutputs() {
+	output := conn.output
+	defer close(output.doneCh)
+	for {
+		output.Lock()
+		chunks := output.chunks
+		output.chunks = []outputChunk{}
+		closed := output.closed
+		output.Unlock()
+
+		if len(chunks) == 0 {
+			if closed {
+				return
+			}
+			<-output.notifyCh
+			continue
+		}
+
+		for _, chunk := range chunks {
+			// Extends the flush timeout while the client reads the queued bytes after the writer is stopped.
+			if closed {
+				conn.Conn.SetWriteDeadline(time.Now().Add(outputFlushTimeout))
+			}
+			_, err := conn.Conn.Write(chunk.bytes)
+			output.Lock()
+			output.pending -= int64(len(chunk.bytes))
+			if chunk.isBulk {
+				output.bulk -= int64(len(chunk.bytes))
+			}
+			isLimitReached := false
+			if err != nil {
+				output.closed = true
+				output.chunks = []outputChunk{}
+				output.pending = 0
+				output.bulk = 0
+				output.stopSoftLimitTimer()
+			} else {
+				isLimitReached = conn.checkOutputLimit(time.Now())
+			}
+			output.Unlock()
+			if isLimitReached {
+				conn.closeByOutputLimit()
+			}
+			if err != nil || isLimitReached {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestConnOutputSoftLimit(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+
+	limited := make(chan struct{})
+	conn := newConnWith(serverConn)
+	conn.startWriter(func(ClientType) ClientOutputBufferLimit {
+		return ClientOutputBufferLimit{HardLimit: 0, SoftLimit: 1024, SoftSeconds: 1}
+	}, func(conn *Conn) {
+		close(limited)
+	})
+	defer conn.stopWriter()
+
+	// The client never reads the output, so the pending bytes stay over the soft limit without new outputs.
+	if _, err := conn.enqueueOutput(make([]byte, 2048), false); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-limited:
+	case <-time.After(time.Second * 5):
+		t.Fatalf("connection is not closed by the soft limit")
+	}
+	clientConn.SetReadDeadline(time.Now().Add(time.Second * 5))
+	if _, err := io.ReadAll(clientConn); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConnOutputStopWriter(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	conn := newConnWith(serverConn)
+	conn.startWriter(func(ClientType) ClientOutputBufferLimit {
+		return ClientOutputBufferLimit{HardLimit: 0, SoftLimit: 0, SoftSeconds: 0}
+	}, func(conn *Conn) {})
+
+	// The writer should be stopped even if the client never reads the queued bytes.
+	if _, err := conn.enqueueOutput(make([]byte, 2048), false); err != nil {
+		t.Fatal(err)
+	}
+	stopped := make(chan struct{})
+	go func() {
+		conn.stopWriter()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(outputFlushTimeout * 2):
+		t.Fatalf("writer is not stopped")
+	}
+	if _, err := conn.enqueueOutput(make([]byte, 1), false); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("%v != %v", err, net.ErrClosed)
+	}
+}
+
+func TestConnOutputBulk(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	conn := newConnWith(serverConn)
+	conn.startWriter(func(ClientType) ClientOutputBufferLimit {
+		return ClientOutputBufferLimit{HardLimit: 1024, SoftLimit: 0, SoftSeconds: 0}
+	}, func(conn *Conn) {})
+	defer conn.stopWriter()
+
+	// The bulk transfer such as the RDB snapshot is not counted against the output buffer limit.
+	if _, err := conn.writeBulk(make([]byte, 4096)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.enqueueOutput(make([]byte, 512), false); err != nil {
+		t.Error(err)
+	}
+	if _, err := conn.enqueueOutput(make([]byte, 512), false); !errors.Is(err, ErrOutputLimit) {
+		t.Errorf("%v != %v", err, ErrOutputLimit)
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"net"
+	"sync"
+)
+
+// Conns represents a connection set.
+type Conns struct {
+	sync.RWMutex
+	conns map[*Conn]struct{}
+}
+
+// NewConns returns a new connection set.
+func NewConns() *Conns {
+	return &Conns{
+		RWMutex: sync.RWMutex{},
+		conns:   map[*Conn]struct{}{},
+	}
+}
+
+// Add adds the specified connection.
+func (conns *Conns) Add(conn *Conn) {
+	conns.Lock()
+	defer conns.Unlock()
+	conns.conns[conn] = struct{}{}
+}
+
+// AddWithLimits adds the specified connection unless the number of the connections or
+// the number of the connections from the same host reaches the specified limit. Zero or a negative limit means no limit.
+func (conns *Conns) AddWithLimits(conn *Conn, maxConns int, maxConnsPerHost int) error {
+	conns.Lock()
+	defer conns.Unlock()
+	if 0 < maxConns && maxConns <= len(conns.conns) {
+		return ErrMaxClients
+	}
+	if 0 < maxConnsPerHost {
+		host := connHost(conn)
+		hostConns := 0
+		for c := range conns.conns {
+			if connHost(c) == host {
+				hostConns++
+			}
+		}
+		if maxConnsPerHost <= hostConns {
+			return ErrMaxClientsPerIP
+		}
+	}
+	conns.conns[conn] = struct{}{}
+	return nil
+}
+
+// connHost returns the remote host of the specified connection.
+func connHost(conn *Conn) string {
+	if conn.Conn == nil {
+		return ""
+	}
+	addr := conn.RemoteAddr().String()
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+// Remove removes the specified connection.
+func (conns *Conns) Remove(conn *Conn) {
+	conns.Lock()
+	defer conns.Unlock()
+	delete(conns.conns, conn)
+}
+
+// Has returns true if the set has the specified connection.
+func (conns *Conns) Has(conn *Conn) bool {
+	conns.RLock()
+	defer conns.RUnlock()
+	_, ok := conns.conns[conn]
+	return ok
+}
+
+// Len returns the number of the connections.
+func (conns *Conns) Len() int {
+	conns.RLock()
+	defer conns.RUnlock()
+	return len(conns.conns)
+}
+
+// Conns returns a snapshot of the connections.
+func (conns *Conns) Conns() []*Conn {
+	conns.RLock()
+	defer conns.RUnlock()
+	connArray := make([]*Conn, 0, len(conns.conns))
+	for conn := range conns.conns {
+		connArray = append(connArray, conn)
+	}
+	return connArray
+}
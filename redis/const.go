@@ -14,6 +14,10 @@
 
 package redis
 
+import (
+	"time"
+)
+
 const (
 	// PackageName is the package name.
 	PackageName = "go-redis"
@@ -27,6 +31,8 @@ const (
 	DefaultScanPattern = "*"
 	// DefaultScanType is the default scan type.
 	DefaultScanType = KeyScan
+	// DefaultShutdownTimeout is the default timeout to wait for the in-flight commands by SHUTDOWN command.
+	DefaultShutdownTimeout = 10 * time.Second
 )
 
 const (
@@ -0,0 +1,35 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"context"
+
+	"github.com/cybergarage/go-tracing/tracer"
+)
+
+// spanContextKey is the context key of the tracer span context.
+type spanContextKey struct{}
+
+// contextWithSpanContext returns a copy of the specified context which carries the specified span context.
+func contextWithSpanContext(ctx context.Context, span tracer.Context) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, span)
+}
+
+// SpanContextFromContext returns the tracer span context carried by the specified context.
+func SpanContextFromContext(ctx context.Context) (tracer.Context, bool) {
+	span, ok := ctx.Value(spanContextKey{}).(tracer.Context)
+	return span, ok
+}
@@ -16,6 +16,7 @@ package redis
 
 import (
 	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
@@ -47,7 +48,11 @@ func (server *Server) registerCoreExecutors() {
 			return NewErrorNotSupportedMessage("AUTH"), nil
 		}
 
-		return server.authCommandHandler.Auth(conn, user, passwd)
+		msg, err := server.authCommandHandler.Auth(conn, user, passwd)
+		if err != nil || (msg != nil && msg.IsError()) {
+			server.serverStats.authFailures.Add(1)
+		}
+		return msg, err
 	})
 
 	server.RegisterExexutor("PING", func(conn *Conn, cmd string, args Arguments) (*Message, error) {
@@ -75,6 +80,9 @@ func (server *Server) registerCoreExecutors() {
 		if err != nil {
 			return nil, newMissingArgumentError(cmd, "id", err)
 		}
+		if id != 0 && server.ConfigClusterEnabled() {
+			return nil, ErrSelectInCluster
+		}
 		msg, err := server.systemCommandHandler.Select(conn, id)
 		if err == nil {
 			conn.SetDatabase(id)
@@ -86,6 +94,24 @@ func (server *Server) registerCoreExecutors() {
 		return server.systemCommandHandler.Quit(conn)
 	})
 
+	server.RegisterExexutor("CLIENT", func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		opt, err := nextStringArgument(cmd, "subcommand", args)
+		if err != nil {
+			return nil, err
+		}
+		switch strings.ToUpper(opt) {
+		case "SETNAME":
+			name, err := nextStringArgument(cmd, "name", args)
+			if err != nil {
+				return nil, err
+			}
+			return server.systemCommandHandler.ClientSetName(conn, name)
+		case "GETNAME":
+			return server.systemCommandHandler.ClientGetName(conn)
+		}
+		return nil, newUnknownSubcommandError(cmd, opt)
+	})
+
 	// Server management commands.
 
 	server.RegisterExexutor("CONFIG", func(conn *Conn, cmd string, args Arguments) (*Message, error) {
@@ -111,9 +137,468 @@ func (server *Server) registerCoreExecutors() {
 				return nil, err
 			}
 			return server.systemCommandHandler.ConfigGet(conn, params)
+		case "RESETSTAT":
+			return server.systemCommandHandler.ConfigResetStat(conn)
+		case "REWRITE":
+			return server.systemCommandHandler.ConfigRewrite(conn)
+		}
+
+		return nil, newUnknownSubcommandError(cmd, opt)
+	})
+
+	server.RegisterExexutor("INFO", func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		sections, err := nextStringArrayArguments(cmd, "section", args)
+		if err != nil {
+			return nil, err
+		}
+		return server.systemCommandHandler.Info(conn, sections)
+	})
+
+	server.RegisterExexutor("COMMAND", func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		opt, err := nextStringArgument(cmd, "subcommand", args)
+		if err != nil {
+			if errors.Is(err, proto.ErrEOM) {
+				return server.systemCommandHandler.Command(conn)
+			}
+			return nil, err
+		}
+		params, err := nextStringArrayArguments(cmd, "params", args)
+		if err != nil {
+			return nil, err
+		}
+		switch strings.ToUpper(opt) {
+		case "COUNT":
+			return server.systemCommandHandler.CommandCount(conn)
+		case "LIST":
+			return server.systemCommandHandler.CommandList(conn)
+		case "INFO":
+			return server.systemCommandHandler.CommandInfo(conn, params)
+		case "DOCS":
+			return server.systemCommandHandler.CommandDocs(conn, params)
+		case "GETKEYS":
+			return server.systemCommandHandler.CommandGetKeys(conn, params)
+		}
+		return nil, newUnknownSubcommandError(cmd, opt)
+	})
+
+	server.RegisterExexutor("MONITOR", func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		return server.systemCommandHandler.Monitor(conn)
+	})
+
+	server.RegisterExexutor("SHUTDOWN", func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		opt, err := nextShutdownOptionArguments(cmd, args)
+		if err != nil {
+			return nil, err
+		}
+		return server.systemCommandHandler.ShutdownServer(conn, opt)
+	})
+
+	server.RegisterExexutor("SLOWLOG", func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		opt, err := nextStringArgument(cmd, "subcommand", args)
+		if err != nil {
+			return nil, err
+		}
+		switch strings.ToUpper(opt) {
+		case "GET":
+			count, err := nextIntegerArgument(cmd, "count", args)
+			if err != nil {
+				if !errors.Is(err, proto.ErrEOM) {
+					return nil, err
+				}
+				count = DefaultSlowLogGetCount
+			}
+			return server.systemCommandHandler.SlowLogGet(conn, count)
+		case "LEN":
+			return server.systemCommandHandler.SlowLogLen(conn)
+		case "RESET":
+			return server.systemCommandHandler.SlowLogReset(conn)
+		}
+		return nil, newUnknownSubcommandError(cmd, opt)
+	})
+
+	replicaOfExecutor := func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		host, err := nextStringArgument(cmd, "host", args)
+		if err != nil {
+			return nil, err
+		}
+		port, err := nextStringArgument(cmd, "port", args)
+		if err != nil {
+			return nil, err
+		}
+		if strings.EqualFold(host, "NO") && strings.EqualFold(port, "ONE") {
+			return server.systemCommandHandler.ReplicaOfNoOne(conn)
+		}
+		portNum, err := strconv.Atoi(port)
+		if err != nil || portNum < 0 || 65535 < portNum {
+			return nil, ErrInvalidMasterPort
+		}
+		return server.systemCommandHandler.ReplicaOf(conn, host, portNum)
+	}
+	server.RegisterExexutor("REPLICAOF", replicaOfExecutor)
+	server.RegisterExexutor("SLAVEOF", replicaOfExecutor)
+
+	server.RegisterExexutor("ROLE", func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		return server.systemCommandHandler.Role(conn)
+	})
+
+	server.RegisterExexutor("WAIT", func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		numReplicas, err := nextIntegerArgument(cmd, "numreplicas", args)
+		if err != nil {
+			return nil, err
+		}
+		timeout, err := nextIntegerArgument(cmd, "timeout", args)
+		if err != nil {
+			return nil, err
+		}
+		if timeout < 0 {
+			return nil, ErrNegativeTimeout
+		}
+		return server.systemCommandHandler.Wait(conn, numReplicas, time.Duration(timeout)*time.Millisecond)
+	})
+
+	server.RegisterExexutor("PSYNC", func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		replID, err := nextStringArgument(cmd, "replicationid", args)
+		if err != nil {
+			return nil, err
+		}
+		offset, err := nextIntegerArgument(cmd, "offset", args)
+		if err != nil {
+			return nil, err
+		}
+		return server.psync(conn, replID, int64(offset))
+	})
+
+	server.RegisterExexutor("REPLCONF", func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		return server.replConf(conn, nextStringArguments(args))
+	})
+
+	// Pub/Sub commands.
+
+	server.RegisterExexutor("SUBSCRIBE", func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		return server.systemCommandHandler.Subscribe(conn, nextStringArguments(args))
+	})
+
+	server.RegisterExexutor("UNSUBSCRIBE", func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		return server.systemCommandHandler.Unsubscribe(conn, nextStringArguments(args))
+	})
+
+	server.RegisterExexutor("PSUBSCRIBE", func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		return server.systemCommandHandler.PSubscribe(conn, nextStringArguments(args))
+	})
+
+	server.RegisterExexutor("PUNSUBSCRIBE", func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		return server.systemCommandHandler.PUnsubscribe(conn, nextStringArguments(args))
+	})
+
+	server.RegisterExexutor("PUBLISH", func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		channel, err := nextStringArgument(cmd, "channel", args)
+		if err != nil {
+			return nil, err
+		}
+		message, err := nextStringArgument(cmd, "message", args)
+		if err != nil {
+			return nil, err
+		}
+		return server.systemCommandHandler.PublishMessage(conn, channel, message)
+	})
+
+	server.RegisterExexutor("PUBSUB", func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		subcmd, err := nextStringArgument(cmd, "subcommand", args)
+		if err != nil {
+			return nil, err
+		}
+		switch strings.ToUpper(subcmd) {
+		case "CHANNELS":
+			pattern, err := args.NextString()
+			if err != nil {
+				pattern = "*"
+			}
+			return server.systemCommandHandler.PubSubChannels(conn, pattern)
+		case "NUMSUB":
+			return server.systemCommandHandler.PubSubNumSub(conn, nextStringArguments(args))
+		case "NUMPAT":
+			return server.systemCommandHandler.PubSubNumPat(conn)
+		}
+		return nil, newUnknownSubcommandError(cmd, subcmd)
+	})
+
+	// Scripting commands.
+
+	evalExecutor := func(readOnly bool) Executor {
+		return func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+			script, err := nextStringArgument(cmd, "script", args)
+			if err != nil {
+				return nil, err
+			}
+			keys, scriptArgs, err := nextNumKeysArguments(cmd, args)
+			if err != nil {
+				return nil, err
+			}
+			return server.systemCommandHandler.Eval(conn, script, keys, scriptArgs, readOnly)
+		}
+	}
+	server.RegisterExexutor("EVAL", evalExecutor(false))
+	server.RegisterExexutor("EVAL_RO", evalExecutor(true))
+
+	evalShaExecutor := func(readOnly bool) Executor {
+		return func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+			sha, err := nextStringArgument(cmd, "sha1", args)
+			if err != nil {
+				return nil, err
+			}
+			keys, scriptArgs, err := nextNumKeysArguments(cmd, args)
+			if err != nil {
+				return nil, err
+			}
+			return server.systemCommandHandler.EvalSha(conn, sha, keys, scriptArgs, readOnly)
+		}
+	}
+	server.RegisterExexutor("EVALSHA", evalShaExecutor(false))
+	server.RegisterExexutor("EVALSHA_RO", evalShaExecutor(true))
+
+	server.RegisterExexutor("SCRIPT", func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		subcmd, err := nextStringArgument(cmd, "subcommand", args)
+		if err != nil {
+			return nil, err
+		}
+		switch strings.ToUpper(subcmd) {
+		case "LOAD":
+			script, err := nextStringArgument(cmd, "script", args)
+			if err != nil {
+				return nil, err
+			}
+			return server.systemCommandHandler.ScriptLoad(conn, script)
+		case "EXISTS":
+			return server.systemCommandHandler.ScriptExists(conn, nextStringArguments(args))
+		case "FLUSH":
+			mode, err := args.NextString()
+			if err == nil && !strings.EqualFold(mode, "ASYNC") && !strings.EqualFold(mode, "SYNC") {
+				return nil, ErrSyntax
+			}
+			return server.systemCommandHandler.ScriptFlush(conn)
+		case "KILL":
+			return server.systemCommandHandler.ScriptKill(conn)
+		}
+		return nil, newUnknownSubcommandError(cmd, subcmd)
+	})
+
+	// Function commands.
+
+	fcallExecutor := func(readOnly bool) Executor {
+		return func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+			name, err := nextStringArgument(cmd, "function", args)
+			if err != nil {
+				return nil, err
+			}
+			keys, fnArgs, err := nextNumKeysArguments(cmd, args)
+			if err != nil {
+				return nil, err
+			}
+			return server.systemCommandHandler.FCall(conn, name, keys, fnArgs, readOnly)
 		}
+	}
+	server.RegisterExexutor("FCALL", fcallExecutor(false))
+	server.RegisterExexutor("FCALL_RO", fcallExecutor(true))
 
-		return nil, errors.New(opt)
+	server.RegisterExexutor("FUNCTION", func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		subcmd, err := nextStringArgument(cmd, "subcommand", args)
+		if err != nil {
+			return nil, err
+		}
+		switch strings.ToUpper(subcmd) {
+		case "LOAD":
+			opts := nextStringArguments(args)
+			if len(opts) == 0 {
+				return nil, newWrongNumberOfArgumentsError(cmd + "|load")
+			}
+			replace := false
+			for _, opt := range opts[:len(opts)-1] {
+				if !strings.EqualFold(opt, "REPLACE") {
+					return nil, NewError(ErrorPrefix, fmt.Sprintf(errorUnknownOption, opt))
+				}
+				replace = true
+			}
+			return server.systemCommandHandler.FunctionLoad(conn, opts[len(opts)-1], replace)
+		case "DELETE":
+			name, err := nextStringArgument(cmd, "library-name", args)
+			if err != nil {
+				return nil, err
+			}
+			return server.systemCommandHandler.FunctionDelete(conn, name)
+		case "LIST":
+			pattern := "*"
+			withCode := false
+			for {
+				opt, err := args.NextString()
+				if err != nil {
+					break
+				}
+				switch strings.ToUpper(opt) {
+				case "WITHCODE":
+					withCode = true
+				case "LIBRARYNAME":
+					pattern, err = args.NextString()
+					if err != nil {
+						return nil, ErrSyntax
+					}
+				default:
+					return nil, ErrSyntax
+				}
+			}
+			return server.systemCommandHandler.FunctionList(conn, pattern, withCode)
+		case "DUMP":
+			return server.systemCommandHandler.FunctionDump(conn)
+		case "RESTORE":
+			payload, err := nextStringArgument(cmd, "serialized-value", args)
+			if err != nil {
+				return nil, err
+			}
+			policy, err := args.NextString()
+			if err != nil {
+				policy = FunctionRestoreAppend
+			}
+			return server.systemCommandHandler.FunctionRestore(conn, payload, policy)
+		case "FLUSH":
+			mode, err := args.NextString()
+			if err == nil && !strings.EqualFold(mode, "ASYNC") && !strings.EqualFold(mode, "SYNC") {
+				return nil, ErrSyntax
+			}
+			return server.systemCommandHandler.FunctionFlush(conn)
+		case "KILL":
+			return server.systemCommandHandler.FunctionKill(conn)
+		case "STATS":
+			return server.systemCommandHandler.FunctionStats(conn)
+		}
+		return nil, newUnknownSubcommandError(cmd, subcmd)
+	})
+
+	// Cluster commands.
+
+	server.RegisterExexutor("CLUSTER", func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		subcmd, err := nextStringArgument(cmd, "subcommand", args)
+		if err != nil {
+			return nil, err
+		}
+		if !server.ConfigClusterEnabled() {
+			return nil, ErrClusterDisabled
+		}
+		switch strings.ToUpper(subcmd) {
+		case "INFO":
+			return server.systemCommandHandler.ClusterInfo(conn)
+		case "MYID":
+			return server.systemCommandHandler.ClusterMyID(conn)
+		case "NODES":
+			return server.systemCommandHandler.ClusterNodes(conn)
+		case "SLOTS":
+			return server.systemCommandHandler.ClusterSlots(conn)
+		case "SHARDS":
+			return server.systemCommandHandler.ClusterShards(conn)
+		case "KEYSLOT":
+			key, err := nextStringArgument(cmd, "key", args)
+			if err != nil {
+				return nil, err
+			}
+			return server.systemCommandHandler.ClusterKeySlot(conn, key)
+		case "COUNTKEYSINSLOT":
+			slot, err := nextIntegerArgument(cmd, "slot", args)
+			if err != nil {
+				return nil, err
+			}
+			return server.systemCommandHandler.ClusterCountKeysInSlot(conn, slot)
+		case "GETKEYSINSLOT":
+			slot, err := nextIntegerArgument(cmd, "slot", args)
+			if err != nil {
+				return nil, err
+			}
+			count, err := nextIntegerArgument(cmd, "count", args)
+			if err != nil {
+				return nil, err
+			}
+			return server.systemCommandHandler.ClusterGetKeysInSlot(conn, slot, count)
+		}
+		return nil, newUnknownSubcommandError(cmd, subcmd)
+	})
+
+	server.RegisterExexutor("ASKING", func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		if !server.ConfigClusterEnabled() {
+			return nil, ErrClusterDisabled
+		}
+		return server.systemCommandHandler.Asking(conn)
+	})
+
+	// Sentinel commands.
+
+	server.RegisterExexutor("SENTINEL", func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		if !server.IsSentinelEnabled() {
+			return nil, newUnknownCommandError(cmd, messageStrings(args.PeekMessages()))
+		}
+		subcmd, err := nextStringArgument(cmd, "subcommand", args)
+		if err != nil {
+			return nil, err
+		}
+		subcmd = strings.ToUpper(subcmd)
+		switch subcmd {
+		case "MASTERS":
+			return server.systemCommandHandler.SentinelMasters(conn)
+		case "MYID":
+			return server.systemCommandHandler.SentinelMyID(conn)
+		case "GET-MASTER-ADDR-BY-NAME", "MASTER", "REPLICAS", "SLAVES", "SENTINELS", "REMOVE", "FAILOVER":
+			name, err := nextStringArgument(cmd, "master-name", args)
+			if err != nil {
+				return nil, err
+			}
+			switch subcmd {
+			case "GET-MASTER-ADDR-BY-NAME":
+				return server.systemCommandHandler.SentinelGetMasterAddrByName(conn, name)
+			case "MASTER":
+				return server.systemCommandHandler.SentinelMaster(conn, name)
+			case "REPLICAS", "SLAVES":
+				return server.systemCommandHandler.SentinelReplicas(conn, name)
+			case "SENTINELS":
+				return server.systemCommandHandler.SentinelSentinels(conn, name)
+			case "REMOVE":
+				return server.systemCommandHandler.SentinelRemove(conn, name)
+			default:
+				return server.systemCommandHandler.SentinelFailover(conn, name)
+			}
+		case "MONITOR":
+			name, err := nextStringArgument(cmd, "name", args)
+			if err != nil {
+				return nil, err
+			}
+			host, err := nextStringArgument(cmd, "ip", args)
+			if err != nil {
+				return nil, err
+			}
+			port, err := nextIntegerArgument(cmd, "port", args)
+			if err != nil {
+				return nil, err
+			}
+			quorum, err := nextIntegerArgument(cmd, "quorum", args)
+			if err != nil {
+				return nil, err
+			}
+			return server.systemCommandHandler.SentinelMonitor(conn, name, host, port, quorum)
+		case "IS-MASTER-DOWN-BY-ADDR":
+			host, err := nextStringArgument(cmd, "ip", args)
+			if err != nil {
+				return nil, err
+			}
+			port, err := nextIntegerArgument(cmd, "port", args)
+			if err != nil {
+				return nil, err
+			}
+			epoch, err := nextIntegerArgument(cmd, "current-epoch", args)
+			if err != nil {
+				return nil, err
+			}
+			runID, err := nextStringArgument(cmd, "runid", args)
+			if err != nil {
+				return nil, err
+			}
+			return server.systemCommandHandler.SentinelIsMasterDownByAddr(conn, host, port, int64(epoch), runID)
+		}
+		return nil, newUnknownSubcommandError(cmd, subcmd)
 	})
 
 	// Generic commands.
@@ -602,6 +1087,9 @@ func (server *Server) registerCoreExecutors() {
 				opt.INCR = true
 			default:
 				score, err = strconv.ParseFloat(param, 64)
+				if err != nil {
+					return nil, newNotFloatArgumentError(err)
+				}
 				isOption = false
 			}
 			if !isOption {
@@ -17,25 +17,178 @@ package redis
 import (
 	"errors"
 	"fmt"
+	"strings"
+)
+
+// Error prefixes of the Redis error replies.
+const (
+	ErrorPrefix       = "ERR"
+	WrongTypePrefix   = "WRONGTYPE"
+	NoAuthPrefix      = "NOAUTH"
+	NoPermPrefix      = "NOPERM"
+	WrongPassPrefix   = "WRONGPASS"
+	OOMPrefix         = "OOM"
+	BusyPrefix        = "BUSY"
+	DeniedPrefix      = "DENIED"
+	ReadOnlyPrefix    = "READONLY"
+	MovedPrefix       = "MOVED"
+	AskPrefix         = "ASK"
+	CrossSlotPrefix   = "CROSSSLOT"
+	TryAgainPrefix    = "TRYAGAIN"
+	ClusterDownPrefix = "CLUSTERDOWN"
+	NoGoodSlavePrefix = "NOGOODSLAVE"
+	InProgPrefix      = "INPROG"
+	NoScriptPrefix    = "NOSCRIPT"
+	NotBusyPrefix     = "NOTBUSY"
+	UnkillablePrefix  = "UNKILLABLE"
 )
 
 var (
 	ErrNotSupported = errors.New("not supported")
 	ErrQuit         = errors.New("QUIT")
 	ErrSystem       = errors.New("internal system error")
-	ErrNotAuthrized = errors.New("not authrized")
 	ErrInvalid      = errors.New("invalid")
+	ErrOutputLimit  = errors.New("output buffer limit reached")
+	ErrNoReply      = errors.New("no reply")
+
+	ErrImmutableConfig  = errors.New("can't set immutable config")
+	ErrUnbalancedQuotes = errors.New("unbalanced quotes in configuration line")
+	ErrNoSuchFile       = errors.New("no such file")
+
+	ErrNoSuchNode   = errors.New("no such node")
+	ErrSlotAssigned = errors.New("slot is already assigned")
+
+	ErrNoSuchCommand = errors.New("no such command")
+	ErrCommandExists = errors.New("target command name already exists")
+)
+
+// Redis compatible errors which are sent to clients as they are.
+var (
+	ErrWrongType              = NewError(WrongTypePrefix, "Operation against a key holding the wrong kind of value")
+	ErrNoAuth                 = NewError(NoAuthPrefix, "Authentication required.")
+	ErrNoPerm                 = NewError(NoPermPrefix, "this user has no permissions to run this command")
+	ErrWrongPass              = NewError(WrongPassPrefix, "invalid username-password pair or user is disabled.")
+	ErrOOM                    = NewError(OOMPrefix, "command not allowed when used memory > 'maxmemory'.")
+	ErrBusy                   = NewError(BusyPrefix, "Redis is busy running a script. You can only call SCRIPT KILL or SHUTDOWN NOSCRIPT.")
+	ErrSyntax                 = NewError(ErrorPrefix, "syntax error")
+	ErrNotInteger             = NewError(ErrorPrefix, "value is not an integer or out of range")
+	ErrNotFloat               = NewError(ErrorPrefix, "value is not a valid float")
+	ErrOutOfRange             = NewError(ErrorPrefix, "value is out of range")
+	ErrIndexOutOfRange        = NewError(ErrorPrefix, "index out of range")
+	ErrNoSuchKey              = NewError(ErrorPrefix, "no such key")
+	ErrInvalidExpireTime      = NewError(ErrorPrefix, "invalid expire time")
+	ErrUnknownCommand         = NewError(ErrorPrefix, "unknown command")
+	ErrUnknownSubcommand      = NewError(ErrorPrefix, "unknown subcommand")
+	ErrWrongNumberOfArguments = NewError(ErrorPrefix, "wrong number of arguments")
+
+	ErrInvalidClientName         = NewError(ErrorPrefix, "Client names cannot contain spaces, newlines or special characters.")
+	ErrInvalidCommand            = NewError(ErrorPrefix, "Invalid command specified")
+	ErrInvalidCommandArguments   = NewError(ErrorPrefix, "Invalid number of arguments specified for command")
+	ErrNoKeyArguments            = NewError(ErrorPrefix, "The command has no key arguments")
+	ErrShutdown                  = NewError(ErrorPrefix, "Errors trying to SHUTDOWN. Check logs.")
+	ErrMaxClients                = NewError(ErrorPrefix, "max number of clients reached")
+	ErrMaxClientsPerIP           = NewError(ErrorPrefix, "max number of clients per IP reached")
+	ErrConfigSet                 = NewError(ErrorPrefix, "CONFIG SET failed")
+	ErrUnknownConfig             = NewError(ErrorPrefix, "Unknown option or number of arguments for CONFIG SET")
+	ErrNoConfigFile              = NewError(ErrorPrefix, "The server is running without a config file")
+	ErrReadOnly                  = NewError(ReadOnlyPrefix, "You can't write against a read only replica.")
+	ErrWaitReplica               = NewError(ErrorPrefix, "WAIT cannot be used with replica instances.")
+	ErrInvalidMasterPort         = NewError(ErrorPrefix, "Invalid master port")
+	ErrNegativeTimeout           = NewError(ErrorPrefix, "timeout is negative")
+	ErrMoved                     = NewError(MovedPrefix, "")
+	ErrAsk                       = NewError(AskPrefix, "")
+	ErrCrossSlot                 = NewError(CrossSlotPrefix, "Keys in request don't hash to the same slot")
+	ErrTryAgain                  = NewError(TryAgainPrefix, "Multiple keys request during rehashing of slot")
+	ErrClusterDown               = NewError(ClusterDownPrefix, "Hash slot not served")
+	ErrClusterDisabled           = NewError(ErrorPrefix, "This instance has cluster support disabled")
+	ErrInvalidSlot               = NewError(ErrorPrefix, "Invalid slot")
+	ErrNoSuchMaster              = NewError(ErrorPrefix, "No such master with that name")
+	ErrNoGoodReplica             = NewError(NoGoodSlavePrefix, "No suitable replica to promote")
+	ErrFailoverInProgress        = NewError(InProgPrefix, "Failover already in progress")
+	ErrNoScript                  = NewError(NoScriptPrefix, "No matching script. Please use EVAL.")
+	ErrNotBusy                   = NewError(NotBusyPrefix, "No scripts in execution right now.")
+	ErrUnkillable                = NewError(UnkillablePrefix, "Sorry the script already executed write commands against the dataset. You can either wait the script termination or kill the server in a hard way using the SHUTDOWN NOSAVE command.")
+	ErrScriptKilled              = NewError(ErrorPrefix, "Script killed by user with SCRIPT KILL...")
+	ErrScriptWrite               = NewError(ErrorPrefix, "Write commands are not allowed from read-only scripts.")
+	ErrScriptCommand             = NewError(ErrorPrefix, "This Redis command is not allowed from script")
+	ErrScriptArguments           = NewError(ErrorPrefix, "Lua redis lib command arguments must be strings or integers")
+	ErrNegativeNumKeys           = NewError(ErrorPrefix, "Number of keys can't be negative")
+	ErrTooManyNumKeys            = NewError(ErrorPrefix, "Number of keys can't be greater than number of args")
+	ErrNoSuchFunction            = NewError(ErrorPrefix, "Function not found")
+	ErrNoSuchLibrary             = NewError(ErrorPrefix, "Library not found")
+	ErrNoLibraryMetadata         = NewError(ErrorPrefix, "Missing library metadata")
+	ErrNoLibraryName             = NewError(ErrorPrefix, "Library name was not given")
+	ErrInvalidLibraryName        = NewError(ErrorPrefix, "Library names can only contain letters, numbers, or underscores(_) and must be at least one character long")
+	ErrInvalidFunctionName       = NewError(ErrorPrefix, "Function names can only contain letters, numbers, or underscores(_) and must be at least one character long")
+	ErrNoFunctions               = NewError(ErrorPrefix, "No functions registered")
+	ErrNoFunctionCallback        = NewError(ErrorPrefix, "redis.register_function must get a callback argument")
+	ErrRegisterFunctionArguments = NewError(ErrorPrefix, "wrong number of arguments to redis.register_function")
+	ErrRegisterFunctionArgument  = NewError(ErrorPrefix, "unknown argument given to redis.register_function")
+	ErrFunctionWriteFlag         = NewError(ErrorPrefix, "Can not execute a script with write flag using *_ro command.")
+	ErrInvalidFunctionPayload    = NewError(ErrorPrefix, "payload version or checksum are wrong")
+	ErrSelectInCluster           = NewError(ErrorPrefix, "SELECT is not allowed in cluster mode")
+	ErrProtectedMode             = NewError(DeniedPrefix, "Redis is running in protected mode because protected mode is enabled and no password is set for the default user. In this mode connections are only accepted from the loopback interface.")
+
+	// ErrNotAuthrized is an alias of ErrNoAuth for compatibility.
+	ErrNotAuthrized = ErrNoAuth
 )
 
 const (
 	errorNotSupportedCommand    = "'%s' is %w"
-	errorMissingCommandArgument = "%s: missing argument (%s) %w"
-	errorUnkownCommandArgument  = "%s: unknown argument (%s)"
-	errorInvalidCommandArgument = "%s: %w argument (%s - %s)"
-	errorUseOnlyOnce            = "%s may be used only once"
-	errorShouldBeGreaterThanInt = "%s should be greater than %d"
+	errorUnknownCommand         = "unknown command '%s', with args beginning with: %s"
+	errorUnknownSubcommand      = "unknown subcommand '%s'. Try %s HELP."
+	errorWrongNumberOfArguments = "wrong number of arguments for '%s' command"
+	errorInvalidExpireTime      = "invalid expire time in '%s' command"
+	errorMinOrMaxNotFloat       = "min or max is not a float"
+	errorConfigSetFailed        = "CONFIG SET failed (possibly related to argument '%s') - %s"
+	errorUnknownConfig          = "Unknown option or number of arguments for CONFIG SET - '%s'"
+	errorPubSubContext          = "Can't execute '%s': only (P|S)SUBSCRIBE / (P|S)UNSUBSCRIBE / PING / QUIT / RESET are allowed in this context"
+	errorEngineNotFound         = "Engine '%s' not found"
+	errorInvalidMetadata        = "Invalid metadata value given: %s"
+	errorLibraryExists          = "Library '%s' already exists"
+	errorFunctionExists         = "Function %s already exists"
+	errorUnknownFunctionFlag    = "Unknown flag given: %s"
+	errorUnknownOption          = "Unknown option given: %s"
 )
 
+// errorMaxArgumentsLength is the maximum length of the arguments reported in an unknown command error.
+const errorMaxArgumentsLength = 128
+
+// Error represents a Redis error reply which starts with an error prefix such as ERR and WRONGTYPE.
+type Error struct {
+	Prefix  string
+	Message string
+	errs    []error
+}
+
+// NewError returns a new error with the specified error prefix and message.
+func NewError(prefix string, msg string) *Error {
+	return &Error{
+		Prefix:  prefix,
+		Message: msg,
+		errs:    []error{},
+	}
+}
+
+// newErrorWith returns a new error of the same kind as the specified error with the specified message and causes.
+func newErrorWith(kind *Error, msg string, causes ...error) *Error {
+	return &Error{
+		Prefix:  kind.Prefix,
+		Message: msg,
+		errs:    append([]error{kind}, causes...),
+	}
+}
+
+// Error returns the error reply string.
+func (err *Error) Error() string {
+	return err.Prefix + " " + err.Message
+}
+
+// Unwrap returns the error kind and the causes.
+func (err *Error) Unwrap() []error {
+	return err.errs
+}
+
 // NewErrNotSupported returns a new ErrNotSupported.
 func NewErrNotSupported(target string) error {
 	return fmt.Errorf(errorNotSupportedCommand, target, ErrNotSupported)
@@ -46,14 +199,87 @@ func NewErrorNotSupportedMessage(cmd string) *Message {
 	return NewErrorMessage(NewErrNotSupported(cmd))
 }
 
+// hasErrorPrefix returns true if the specified error string starts with a Redis error prefix.
+func hasErrorPrefix(errStr string) bool {
+	prefix, _, _ := strings.Cut(errStr, " ")
+	switch prefix {
+	case ErrorPrefix, WrongTypePrefix, NoAuthPrefix, NoPermPrefix, WrongPassPrefix, OOMPrefix, BusyPrefix, DeniedPrefix, ReadOnlyPrefix,
+		MovedPrefix, AskPrefix, CrossSlotPrefix, TryAgainPrefix, ClusterDownPrefix, NoGoodSlavePrefix, InProgPrefix,
+		NoScriptPrefix, NotBusyPrefix, UnkillablePrefix:
+		return true
+	}
+	return false
+}
+
+// errorReplyReplacer replaces the newlines which are not allowed in the error replies.
+var errorReplyReplacer = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+// newErrorString returns the Redis error reply string of the specified error.
+func newErrorString(err error) string {
+	var redisErr *Error
+	if errors.As(err, &redisErr) {
+		return errorReplyReplacer.Replace(redisErr.Error())
+	}
+	errStr := errorReplyReplacer.Replace(err.Error())
+	if hasErrorPrefix(errStr) {
+		return errStr
+	}
+	return ErrorPrefix + " " + errStr
+}
+
+func newUnknownCommandError(cmd string, args []string) error {
+	var argStr strings.Builder
+	for _, arg := range args {
+		if errorMaxArgumentsLength <= argStr.Len() {
+			break
+		}
+		if len(arg) > errorMaxArgumentsLength-argStr.Len() {
+			arg = arg[:errorMaxArgumentsLength-argStr.Len()]
+		}
+		argStr.WriteString("'" + arg + "' ")
+	}
+	if errorMaxArgumentsLength < len(cmd) {
+		cmd = cmd[:errorMaxArgumentsLength]
+	}
+	return newErrorWith(ErrUnknownCommand, fmt.Sprintf(errorUnknownCommand, cmd, argStr.String()))
+}
+
+func newMovedError(slot int, addr string) error {
+	return newErrorWith(ErrMoved, fmt.Sprintf("%d %s", slot, addr))
+}
+
+func newAskError(slot int, addr string) error {
+	return newErrorWith(ErrAsk, fmt.Sprintf("%d %s", slot, addr))
+}
+
+func newPubSubContextError(cmd string) error {
+	return NewError(ErrorPrefix, fmt.Sprintf(errorPubSubContext, strings.ToLower(cmd)))
+}
+
+func newUnknownSubcommandError(cmd string, subcmd string) error {
+	return newErrorWith(ErrUnknownSubcommand, fmt.Sprintf(errorUnknownSubcommand, subcmd, strings.ToUpper(cmd)))
+}
+
+func newWrongNumberOfArgumentsError(cmd string) error {
+	return newErrorWith(ErrWrongNumberOfArguments, fmt.Sprintf(errorWrongNumberOfArguments, strings.ToLower(cmd)))
+}
+
 func newMissingArgumentError(cmd string, arg string, err error) error {
-	return fmt.Errorf(errorMissingCommandArgument, cmd, arg, err)
+	return newErrorWith(ErrWrongNumberOfArguments, fmt.Sprintf(errorWrongNumberOfArguments, strings.ToLower(cmd)), err)
+}
+
+func newNotIntegerArgumentError(err error) error {
+	return newErrorWith(ErrNotInteger, ErrNotInteger.Message, err)
+}
+
+func newNotFloatArgumentError(err error) error {
+	return newErrorWith(ErrNotFloat, ErrNotFloat.Message, err)
 }
 
-func newUnkownArgumentError(cmd string, arg string) error {
-	return fmt.Errorf(errorUnkownCommandArgument, cmd, arg)
+func newInvalidExpireTimeError(cmd string) error {
+	return newErrorWith(ErrInvalidExpireTime, fmt.Sprintf(errorInvalidExpireTime, strings.ToLower(cmd)))
 }
 
-func newInvalidArgumentError(cmd string, arg string, err error) error {
-	return fmt.Errorf(errorInvalidCommandArgument, cmd, ErrInvalid, arg, err.Error())
+func newConfigSetError(key string, err error) error {
+	return newErrorWith(ErrConfigSet, fmt.Sprintf(errorConfigSetFailed, key, err.Error()), err)
 }
@@ -0,0 +1,73 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cybergarage/go-redis/redis/proto"
+)
+
+func TestErrorString(t *testing.T) {
+	records := []struct {
+		err      error
+		expected string
+	}{
+		{errors.New("syntax error"), "ERR syntax error"},
+		{ErrNotAuthrized, "NOAUTH Authentication required."},
+		{ErrWrongPass, "WRONGPASS invalid username-password pair or user is disabled."},
+		{newWrongNumberOfArgumentsError("SET"), "ERR wrong number of arguments for 'set' command"},
+		{newMissingArgumentError("SET", "value", proto.ErrEOM), "ERR wrong number of arguments for 'set' command"},
+		{newUnknownCommandError("foo", []string{"a", "b"}), "ERR unknown command 'foo', with args beginning with: 'a' 'b' "},
+		{newUnknownSubcommandError("config", "foo"), "ERR unknown subcommand 'foo'. Try CONFIG HELP."},
+		{fmt.Errorf("%w: key", ErrWrongType), "WRONGTYPE Operation against a key holding the wrong kind of value"},
+	}
+	for _, r := range records {
+		t.Run(r.expected, func(t *testing.T) {
+			errStr := newErrorString(r.err)
+			if errStr != r.expected {
+				t.Errorf("%s != %s", errStr, r.expected)
+			}
+		})
+	}
+}
+
+func TestErrorIs(t *testing.T) {
+	records := []struct {
+		err  error
+		kind error
+	}{
+		{newMissingArgumentError("SET", "value", proto.ErrEOM), proto.ErrEOM},
+		{newMissingArgumentError("SET", "value", proto.ErrEOM), ErrWrongNumberOfArguments},
+		{newWrongNumberOfArgumentsError("SET"), ErrWrongNumberOfArguments},
+		{newUnknownCommandError("foo", []string{}), ErrUnknownCommand},
+		{newNotIntegerArgumentError(errors.New("invalid")), ErrNotInteger},
+		{fmt.Errorf("%w: key", ErrWrongType), ErrWrongType},
+		{ErrNotAuthrized, ErrNoAuth},
+	}
+	for _, r := range records {
+		t.Run(r.err.Error(), func(t *testing.T) {
+			if !errors.Is(r.err, r.kind) {
+				t.Errorf("%s is not %s", r.err, r.kind)
+			}
+		})
+	}
+
+	if errors.Is(ErrSyntax, ErrNotInteger) {
+		t.Errorf("%s is %s", ErrSyntax, ErrNotInteger)
+	}
+}
@@ -0,0 +1,473 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cybergarage/go-redis/redis/glob"
+	"github.com/cybergarage/go-redis/redis/rdb"
+	lua "github.com/yuin/gopher-lua"
+)
+
+const (
+	FunctionEngine         = "LUA"
+	FunctionRestoreFlush   = "FLUSH"
+	FunctionRestoreAppend  = "APPEND"
+	FunctionRestoreReplace = "REPLACE"
+	functionChunkName      = "@user_function"
+	functionNamePrefix     = "name="
+	functionLoadTimeout    = 500 * time.Millisecond
+)
+
+// luaFunction represents a function which is registered by a function library.
+type luaFunction struct {
+	name        string
+	description string
+	flags       []string
+	noWrites    bool
+}
+
+// luaLibrary represents a loaded function library.
+type luaLibrary struct {
+	name      string
+	code      string
+	proto     *lua.FunctionProto
+	functions []*luaFunction
+}
+
+// isValidFunctionName returns true if the specified name has only letters, numbers and underscores.
+func isValidFunctionName(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+	for _, c := range name {
+		if c != '_' && (c < 'a' || 'z' < c) && (c < 'A' || 'Z' < c) && (c < '0' || '9' < c) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseLibraryMetadata parses the shebang of the specified library code, and returns the library name and the code without the shebang.
+func parseLibraryMetadata(code string) (string, string, error) {
+	if !strings.HasPrefix(code, scriptShebang) {
+		return "", "", ErrNoLibraryMetadata
+	}
+	line, _, _ := strings.Cut(code, "\n")
+	fields := strings.Fields(line)
+	engine := strings.TrimPrefix(fields[0], scriptShebang)
+	if !strings.EqualFold(engine, FunctionEngine) {
+		return "", "", NewError(ErrorPrefix, fmt.Sprintf(errorEngineNotFound, engine))
+	}
+	name := ""
+	for _, field := range fields[1:] {
+		if !strings.HasPrefix(field, functionNamePrefix) {
+			return "", "", NewError(ErrorPrefix, fmt.Sprintf(errorInvalidMetadata, field))
+		}
+		name = strings.TrimPrefix(field, functionNamePrefix)
+	}
+	if len(name) == 0 {
+		return "", "", ErrNoLibraryName
+	}
+	if !isValidFunctionName(name) {
+		return "", "", ErrInvalidLibraryName
+	}
+	// Keeps the line numbers of the error messages.
+	return name, code[len(line):], nil
+}
+
+// newLuaLibrary returns a new library of the specified code. The code is run once to register the functions,
+// and the commands can not be called while the library is loaded.
+func newLuaLibrary(code string) (*luaLibrary, error) {
+	name, source, err := parseLibraryMetadata(code)
+	if err != nil {
+		return nil, err
+	}
+	proto, err := compileLua(source, functionChunkName)
+	if err != nil {
+		return nil, NewError(ErrorPrefix, "Error compiling function: "+err.Error())
+	}
+	lib := &luaLibrary{
+		name:      name,
+		code:      code,
+		proto:     proto,
+		functions: []*luaFunction{},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), functionLoadTimeout)
+	defer cancel()
+	call := &scriptCall{
+		server:    nil,
+		conn:      nil,
+		name:      name,
+		command:   []string{},
+		noWrites:  true,
+		wrote:     false,
+		killed:    false,
+		startTime: time.Now(),
+		ctx:       ctx,
+		cancel:    cancel,
+		done:      nil,
+	}
+	L := call.newState()
+	defer L.Close()
+	functions, _, err := registerFunctions(L, lib)
+	if err != nil {
+		return nil, err
+	}
+	if len(functions) == 0 {
+		return nil, ErrNoFunctions
+	}
+	lib.functions = functions
+	return lib, nil
+}
+
+// newLuaLibraries returns the new libraries of the specified codes.
+func newLuaLibraries(codes []string) ([]*luaLibrary, error) {
+	libs := make([]*luaLibrary, len(codes))
+	for n, code := range codes {
+		lib, err := newLuaLibrary(code)
+		if err != nil {
+			return nil, err
+		}
+		libs[n] = lib
+	}
+	return libs, nil
+}
+
+// registerFunctions runs the specified library in the specified state, and returns the registered functions and their callbacks.
+// The library runs without redis.call and redis.pcall, which are available only in the callbacks.
+func registerFunctions(L *lua.LState, lib *luaLibrary) ([]*luaFunction, map[string]*lua.LFunction, error) {
+	functions := []*luaFunction{}
+	callbacks := map[string]*lua.LFunction{}
+	redisLib, ok := L.GetGlobal("redis").(*lua.LTable)
+	if !ok {
+		return nil, nil, ErrSystem
+	}
+	redisCall, redisPCall := redisLib.RawGetString("call"), redisLib.RawGetString("pcall")
+	redisLib.RawSetString("call", lua.LNil)
+	redisLib.RawSetString("pcall", lua.LNil)
+	defer func() {
+		redisLib.RawSetString("call", redisCall)
+		redisLib.RawSetString("pcall", redisPCall)
+	}()
+	redisLib.RawSetString("register_function", L.NewFunction(func(L *lua.LState) int {
+		fn, callback, err := newLuaFunction(L)
+		if err == nil {
+			if _, ok := callbacks[fn.name]; ok {
+				err = NewError(ErrorPrefix, fmt.Sprintf(errorFunctionExists, fn.name))
+			}
+		}
+		if err != nil {
+			L.Error(newLuaReplyTable(L, "err", newErrorString(err)), 1)
+			return 0
+		}
+		functions = append(functions, fn)
+		callbacks[fn.name] = callback
+		return 0
+	}))
+	L.Push(L.NewFunctionFromProto(lib.proto))
+	if err := L.PCall(0, 0, nil); err != nil {
+		return nil, nil, newLibraryError(err)
+	}
+	return functions, callbacks, nil
+}
+
+// newLibraryError returns the error of the specified Lua error which is raised while the library is run.
+func newLibraryError(err error) error {
+	var apiErr *lua.ApiError
+	if errors.As(err, &apiErr) && apiErr.Object != nil {
+		if tbl, ok := apiErr.Object.(*lua.LTable); ok {
+			if errStr, ok := tbl.RawGetString("err").(lua.LString); ok {
+				return errors.New(string(errStr))
+			}
+		}
+		return NewError(ErrorPrefix, "Error registering functions: "+apiErr.Object.String())
+	}
+	return NewError(ErrorPrefix, "Error registering functions: "+err.Error())
+}
+
+// newLuaFunction returns the function of the arguments of redis.register_function which are a name and a callback,
+// or a table of the named arguments.
+func newLuaFunction(L *lua.LState) (*luaFunction, *lua.LFunction, error) {
+	fn := &luaFunction{
+		name:        "",
+		description: "",
+		flags:       []string{},
+		noWrites:    false,
+	}
+	var callback *lua.LFunction
+	switch L.GetTop() {
+	case 1:
+		args, ok := L.Get(1).(*lua.LTable)
+		if !ok {
+			return nil, nil, ErrRegisterFunctionArgument
+		}
+		var err error
+		args.ForEach(func(key lua.LValue, val lua.LValue) {
+			if err != nil {
+				return
+			}
+			switch key.String() {
+			case "function_name":
+				fn.name = lua.LVAsString(val)
+			case "description":
+				fn.description = lua.LVAsString(val)
+			case "callback":
+				callback, _ = val.(*lua.LFunction)
+			case "flags":
+				flags, ok := val.(*lua.LTable)
+				if !ok {
+					err = ErrRegisterFunctionArgument
+					return
+				}
+				flags.ForEach(func(_ lua.LValue, flag lua.LValue) {
+					fn.flags = append(fn.flags, lua.LVAsString(flag))
+				})
+			default:
+				err = ErrRegisterFunctionArgument
+			}
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	case 2:
+		fn.name = lua.LVAsString(L.Get(1))
+		callback, _ = L.Get(2).(*lua.LFunction)
+	default:
+		return nil, nil, ErrRegisterFunctionArguments
+	}
+	if !isValidFunctionName(fn.name) {
+		return nil, nil, ErrInvalidFunctionName
+	}
+	if callback == nil {
+		return nil, nil, ErrNoFunctionCallback
+	}
+	for _, flag := range fn.flags {
+		if !scriptFlags[flag] {
+			return nil, nil, NewError(ErrorPrefix, fmt.Sprintf(errorUnknownFunctionFlag, flag))
+		}
+		if flag == scriptNoWrites {
+			fn.noWrites = true
+		}
+	}
+	return fn, callback, nil
+}
+
+// dumpLibraries returns the payload of the specified libraries as an RDB snapshot which has only the function libraries.
+func dumpLibraries(libs []*luaLibrary) ([]byte, error) {
+	var buf bytes.Buffer
+	w := rdb.NewWriter(&buf)
+	for _, lib := range libs {
+		if err := w.WriteFunction(lib.code); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// loadLibraries returns the libraries of the specified payload which is returned by dumpLibraries.
+func loadLibraries(payload []byte) ([]*luaLibrary, error) {
+	r := rdb.NewReader(bytes.NewReader(payload))
+	for {
+		_, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, newErrorWith(ErrInvalidFunctionPayload, ErrInvalidFunctionPayload.Message, err)
+		}
+		// The payload has no keys.
+		return nil, ErrInvalidFunctionPayload
+	}
+	return newLuaLibraries(r.Functions())
+}
+
+// libraries represents the function library registry of the server.
+type libraries struct {
+	sync.Mutex
+	libs  map[string]*luaLibrary
+	funcs map[string]*luaLibrary
+}
+
+// newLibraries returns a new empty function library registry.
+func newLibraries() *libraries {
+	return &libraries{
+		Mutex: sync.Mutex{},
+		libs:  map[string]*luaLibrary{},
+		funcs: map[string]*luaLibrary{},
+	}
+}
+
+// load adds the specified libraries atomically. The existing libraries of the same names are replaced only if replace is true.
+func (l *libraries) load(newLibs []*luaLibrary, replace bool) error {
+	l.Lock()
+	defer l.Unlock()
+	libs := map[string]*luaLibrary{}
+	for name, lib := range l.libs {
+		libs[name] = lib
+	}
+	return l.installLocked(libs, newLibs, replace)
+}
+
+// restore adds the specified libraries atomically with the specified restore policy such as FLUSH, APPEND and REPLACE.
+func (l *libraries) restore(newLibs []*luaLibrary, policy string) error {
+	switch strings.ToUpper(policy) {
+	case FunctionRestoreFlush:
+		l.Lock()
+		defer l.Unlock()
+		return l.installLocked(map[string]*luaLibrary{}, newLibs, false)
+	case FunctionRestoreAppend:
+		return l.load(newLibs, false)
+	case FunctionRestoreReplace:
+		return l.load(newLibs, true)
+	}
+	return ErrSyntax
+}
+
+// installLocked adds the specified new libraries to the specified libraries, and replaces the registry with them
+// if no library and no function conflict.
+func (l *libraries) installLocked(libs map[string]*luaLibrary, newLibs []*luaLibrary, replace bool) error {
+	for _, lib := range newLibs {
+		if _, ok := libs[lib.name]; ok && !replace {
+			return NewError(ErrorPrefix, fmt.Sprintf(errorLibraryExists, lib.name))
+		}
+		libs[lib.name] = lib
+	}
+	funcs := map[string]*luaLibrary{}
+	for _, lib := range libs {
+		for _, fn := range lib.functions {
+			if _, ok := funcs[fn.name]; ok {
+				return NewError(ErrorPrefix, fmt.Sprintf(errorFunctionExists, fn.name))
+			}
+			funcs[fn.name] = lib
+		}
+	}
+	l.libs = libs
+	l.funcs = funcs
+	return nil
+}
+
+// remove removes the library of the specified name and its functions.
+func (l *libraries) remove(name string) error {
+	l.Lock()
+	defer l.Unlock()
+	lib, ok := l.libs[name]
+	if !ok {
+		return ErrNoSuchLibrary
+	}
+	for _, fn := range lib.functions {
+		delete(l.funcs, fn.name)
+	}
+	delete(l.libs, name)
+	return nil
+}
+
+// flush removes all libraries.
+func (l *libraries) flush() {
+	l.Lock()
+	defer l.Unlock()
+	l.libs = map[string]*luaLibrary{}
+	l.funcs = map[string]*luaLibrary{}
+}
+
+// lookup returns the function of the specified name and its library.
+func (l *libraries) lookup(name string) (*luaLibrary, *luaFunction, bool) {
+	l.Lock()
+	defer l.Unlock()
+	lib, ok := l.funcs[name]
+	if !ok {
+		return nil, nil, false
+	}
+	for _, fn := range lib.functions {
+		if fn.name == name {
+			return lib, fn, true
+		}
+	}
+	return nil, nil, false
+}
+
+// list returns the libraries which match the specified glob-style pattern in the name order.
+func (l *libraries) list(pattern string) ([]*luaLibrary, error) {
+	re, err := glob.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	l.Lock()
+	defer l.Unlock()
+	libs := []*luaLibrary{}
+	for name, lib := range l.libs {
+		if re.MatchString(name) {
+			libs = append(libs, lib)
+		}
+	}
+	sort.Slice(libs, func(i, j int) bool {
+		return libs[i].name < libs[j].name
+	})
+	return libs, nil
+}
+
+// codes returns the codes of all libraries in the name order.
+func (l *libraries) codes() []string {
+	libs, _ := l.list("*")
+	codes := make([]string, len(libs))
+	for n, lib := range libs {
+		codes[n] = lib.code
+	}
+	return codes
+}
+
+// counts returns the number of the libraries and the functions.
+func (l *libraries) counts() (int, int) {
+	l.Lock()
+	defer l.Unlock()
+	return len(l.libs), len(l.funcs)
+}
+
+// callFunction runs the specified function of the specified library with the specified keys and arguments.
+// The library is run in the new state of the call to register the callbacks before the function is called,
+// so its top-level code cannot run the commands on each call.
+func (server *Server) callFunction(conn *Conn, lib *luaLibrary, fn *luaFunction, keys []string, args []string, readOnly bool) (*Message, error) {
+	cmd := "FCALL"
+	if readOnly {
+		cmd = "FCALL_RO"
+	}
+	command := newScriptCommand(cmd, fn.name, keys, args)
+	return server.runScript(conn, fn.name, command, fn.noWrites, func(call *scriptCall) (*Message, error) {
+		L := call.newState()
+		defer L.Close()
+		_, callbacks, err := registerFunctions(L, lib)
+		if err != nil {
+			return nil, err
+		}
+		callback, ok := callbacks[fn.name]
+		if !ok {
+			return nil, ErrNoSuchFunction
+		}
+		return call.pcall(L, callback, newLuaStringTable(L, keys), newLuaStringTable(L, args))
+	})
+}
@@ -0,0 +1,268 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFunctionLibrary(t *testing.T) {
+	tests := []struct {
+		code      string
+		name      string
+		functions []string
+		noWrites  []bool
+		err       error
+	}{
+		{
+			"#!lua name=lib1\nredis.register_function('f1', function(keys, args) return 1 end)",
+			"lib1", []string{"f1"}, []bool{false}, nil,
+		},
+		{
+			"#!lua name=lib2\n" +
+				"redis.register_function('f1', function(keys, args) return 1 end)\n" +
+				"redis.register_function{function_name='f2', callback=function(keys, args) return 2 end, flags={'no-writes'}, description='desc'}",
+			"lib2", []string{"f1", "f2"}, []bool{false, true}, nil,
+		},
+		{"return 1", "", nil, nil, ErrNoLibraryMetadata},
+		{"#!lua\nreturn 1", "", nil, nil, ErrNoLibraryName},
+		{"#!lua name=lib-3\nreturn 1", "", nil, nil, ErrInvalidLibraryName},
+		{"#!lua name=lib4\nlocal a = 1", "", nil, nil, ErrNoFunctions},
+		{"#!lua name=lib5\nredis.register_function('f-1', function() end)", "", nil, nil, nil},
+		{"#!lua name=lib6\nredis.register_function('f1', 1)", "", nil, nil, nil},
+		{"#!lua name=lib7\nredis.call('GET', 'key')", "", nil, nil, nil},
+		{"#!lua name=lib8\nwhile true do end", "", nil, nil, nil},
+	}
+
+	for _, test := range tests {
+		lib, err := newLuaLibrary(test.code)
+		if test.name == "" {
+			if err == nil {
+				t.Errorf("%q is loaded", test.code)
+			} else if test.err != nil && !errors.Is(err, test.err) {
+				t.Errorf("%v != %v", err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if lib.name != test.name {
+			t.Errorf("%s != %s", lib.name, test.name)
+		}
+		if len(lib.functions) != len(test.functions) {
+			t.Errorf("%d != %d", len(lib.functions), len(test.functions))
+			continue
+		}
+		for n, fn := range lib.functions {
+			if fn.name != test.functions[n] {
+				t.Errorf("%s != %s", fn.name, test.functions[n])
+			}
+			if fn.noWrites != test.noWrites[n] {
+				t.Errorf("%s: %t != %t", fn.name, fn.noWrites, test.noWrites[n])
+			}
+		}
+	}
+}
+
+func TestFunctionLibraries(t *testing.T) {
+	newLibrary := func(code string) *luaLibrary {
+		lib, err := newLuaLibrary(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return lib
+	}
+	lib1 := newLibrary("#!lua name=lib1\nredis.register_function('f1', function() return 1 end)")
+	lib1v2 := newLibrary("#!lua name=lib1\nredis.register_function('f2', function() return 2 end)")
+	lib2 := newLibrary("#!lua name=lib2\nredis.register_function('f2', function() return 2 end)")
+
+	libs := newLibraries()
+	if err := libs.load([]*luaLibrary{lib1}, false); err != nil {
+		t.Error(err)
+	}
+	if err := libs.load([]*luaLibrary{lib1v2}, false); err == nil {
+		t.Errorf("%s is loaded twice", lib1v2.name)
+	}
+	if err := libs.load([]*luaLibrary{lib1v2}, true); err != nil {
+		t.Error(err)
+	}
+	if _, _, ok := libs.lookup("f1"); ok {
+		t.Errorf("replaced function f1 is found")
+	}
+	// The functions of the libraries must be unique.
+	if err := libs.load([]*luaLibrary{lib2}, false); err == nil {
+		t.Errorf("function f2 is loaded twice")
+	}
+
+	payload, err := dumpLibraries([]*luaLibrary{lib1, lib2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := loadLibraries(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := libs.restore(restored, FunctionRestoreAppend); err == nil {
+		t.Errorf("existing libraries are appended")
+	}
+	if err := libs.restore(restored, FunctionRestoreFlush); err != nil {
+		t.Error(err)
+	}
+	if numLibs, numFuncs := libs.counts(); numLibs != 2 || numFuncs != 2 {
+		t.Errorf("%d, %d != 2, 2", numLibs, numFuncs)
+	}
+	if _, err := loadLibraries(payload[:len(payload)-1]); err == nil {
+		t.Errorf("broken payload is loaded")
+	}
+
+	if err := libs.remove("lib1"); err != nil {
+		t.Error(err)
+	}
+	if err := libs.remove("lib1"); !errors.Is(err, ErrNoSuchLibrary) {
+		t.Errorf("%v != %v", err, ErrNoSuchLibrary)
+	}
+	if _, _, ok := libs.lookup("f1"); ok {
+		t.Errorf("deleted function f1 is found")
+	}
+}
+
+func TestServerFCall(t *testing.T) {
+	server := NewServer()
+	calls := []string{}
+	server.SetFallbackExecutor(func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		calls = append(calls, cmd)
+		key, err := args.NextString()
+		if err != nil {
+			return nil, err
+		}
+		return NewBulkMessage(cmd + ":" + key), nil
+	})
+
+	conn := newConnWith(nil)
+	conn.SetSpanContext(server.Tracer.StartSpan(PackageName))
+	conn.SetAuthrized(true)
+
+	code := "#!lua name=lib\n" +
+		"local function get(keys, args) return {redis.call('GET', keys[1]), args[1]} end\n" +
+		"redis.register_function{function_name='get', callback=get, flags={'no-writes'}}\n" +
+		"redis.register_function('set', function(keys, args) return redis.call('SET', keys[1], args[1]) end)"
+	if _, err := server.FunctionLoad(conn, code, false); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := server.FCall(conn, "get", []string{"key"}, []string{"arg"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	array, err := msg.Array()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"GET:key", "arg"} {
+		str, err := array.NextString()
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if str != expected {
+			t.Errorf("%s != %s", str, expected)
+		}
+	}
+
+	if _, err := server.FCall(conn, "set", []string{"key"}, []string{"val"}, true); !errors.Is(err, ErrFunctionWriteFlag) {
+		t.Errorf("%v != %v", err, ErrFunctionWriteFlag)
+	}
+	if _, err := server.FCall(conn, "nofunc", []string{}, []string{}, false); !errors.Is(err, ErrNoSuchFunction) {
+		t.Errorf("%v != %v", err, ErrNoSuchFunction)
+	}
+	if _, err := server.FunctionDelete(conn, "lib"); err != nil {
+		t.Error(err)
+	}
+	if _, err := server.FCall(conn, "get", []string{"key"}, []string{}, false); !errors.Is(err, ErrNoSuchFunction) {
+		t.Errorf("%v != %v", err, ErrNoSuchFunction)
+	}
+
+	// The top-level code of the library must not run the commands on the calls.
+	code = "#!lua name=lib\n" +
+		"if redis.call then redis.call('SET', 'key', 'val') end\n" +
+		"redis.register_function('get', function(keys, args) return redis.call('GET', keys[1]) end)"
+	if _, err := server.FunctionLoad(conn, code, false); err != nil {
+		t.Fatal(err)
+	}
+	calls = []string{}
+	if _, err := server.FCall(conn, "get", []string{"key"}, []string{}, false); err != nil {
+		t.Error(err)
+	}
+	if len(calls) != 1 || calls[0] != "GET" {
+		t.Errorf("%v != [GET]", calls)
+	}
+}
+
+type functionStore struct {
+	UserCommandHandler
+	payload []byte
+}
+
+func (store *functionStore) SaveFunctions(ctx context.Context, payload []byte) error {
+	store.payload = payload
+	return nil
+}
+
+func (store *functionStore) LoadFunctions(ctx context.Context) ([]byte, error) {
+	return store.payload, nil
+}
+
+func TestServerFunctionPersistence(t *testing.T) {
+	store := &functionStore{UserCommandHandler: nil, payload: nil}
+	newServer := func() *Server {
+		server := NewServer()
+		server.SetPort(0)
+		server.SetCommandHandler(store)
+		if err := server.Start(); err != nil {
+			t.Fatal(err)
+		}
+		return server
+	}
+
+	server := newServer()
+	conn := newConnWith(nil)
+	conn.SetSpanContext(server.Tracer.StartSpan(PackageName))
+	conn.SetAuthrized(true)
+	code := "#!lua name=lib\nredis.register_function('f1', function(keys, args) return 1 end)"
+	if _, err := server.FunctionLoad(conn, code, false); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The saved libraries are loaded when the new server is started.
+	server = newServer()
+	defer server.Stop()
+	msg, err := server.FCall(conn, "f1", []string{}, []string{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := msg.Integer(); err != nil || n != 1 {
+		t.Errorf("%d != %d (%v)", n, 1, err)
+	}
+}
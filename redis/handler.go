@@ -14,18 +14,107 @@
 
 package redis
 
+import (
+	"context"
+	"time"
+
+	"github.com/cybergarage/go-redis/redis/rdb"
+)
+
 // ConnectionManagementCommandHandler represents a hander interface for connection management commands.
 type ConnectionManagementCommandHandler interface {
 	Ping(conn *Conn, arg string) (*Message, error)
 	Echo(conn *Conn, arg string) (*Message, error)
 	Select(conn *Conn, index int) (*Message, error)
 	Quit(conn *Conn) (*Message, error)
+	ClientSetName(conn *Conn, name string) (*Message, error)
+	ClientGetName(conn *Conn) (*Message, error)
 }
 
 // ServerManagementCommandHandler represents a hander interface for server management commands.
 type ServerManagementCommandHandler interface {
 	ConfigSet(conn *Conn, params map[string]string) (*Message, error)
 	ConfigGet(conn *Conn, keys []string) (*Message, error)
+	ConfigResetStat(conn *Conn) (*Message, error)
+	ConfigRewrite(conn *Conn) (*Message, error)
+	Info(conn *Conn, sections []string) (*Message, error)
+	SlowLogGet(conn *Conn, count int) (*Message, error)
+	SlowLogLen(conn *Conn) (*Message, error)
+	SlowLogReset(conn *Conn) (*Message, error)
+	Monitor(conn *Conn) (*Message, error)
+	Command(conn *Conn) (*Message, error)
+	CommandCount(conn *Conn) (*Message, error)
+	CommandList(conn *Conn) (*Message, error)
+	CommandInfo(conn *Conn, names []string) (*Message, error)
+	CommandDocs(conn *Conn, names []string) (*Message, error)
+	CommandGetKeys(conn *Conn, args []string) (*Message, error)
+	ShutdownServer(conn *Conn, opt ShutdownOption) (*Message, error)
+	ReplicaOf(conn *Conn, host string, port int) (*Message, error)
+	ReplicaOfNoOne(conn *Conn) (*Message, error)
+	Role(conn *Conn) (*Message, error)
+	Wait(conn *Conn, numReplicas int, timeout time.Duration) (*Message, error)
+}
+
+// PubSubCommandHandler represents a hander interface for pub/sub commands.
+type PubSubCommandHandler interface {
+	Subscribe(conn *Conn, channels []string) (*Message, error)
+	Unsubscribe(conn *Conn, channels []string) (*Message, error)
+	PSubscribe(conn *Conn, patterns []string) (*Message, error)
+	PUnsubscribe(conn *Conn, patterns []string) (*Message, error)
+	PublishMessage(conn *Conn, channel string, message string) (*Message, error)
+	PubSubChannels(conn *Conn, pattern string) (*Message, error)
+	PubSubNumSub(conn *Conn, channels []string) (*Message, error)
+	PubSubNumPat(conn *Conn) (*Message, error)
+}
+
+// ScriptingCommandHandler represents a hander interface for scripting commands.
+type ScriptingCommandHandler interface {
+	Eval(conn *Conn, script string, keys []string, args []string, readOnly bool) (*Message, error)
+	EvalSha(conn *Conn, sha string, keys []string, args []string, readOnly bool) (*Message, error)
+	ScriptLoad(conn *Conn, script string) (*Message, error)
+	ScriptExists(conn *Conn, shas []string) (*Message, error)
+	ScriptFlush(conn *Conn) (*Message, error)
+	ScriptKill(conn *Conn) (*Message, error)
+}
+
+// FunctionCommandHandler represents a hander interface for function commands.
+type FunctionCommandHandler interface {
+	FunctionLoad(conn *Conn, code string, replace bool) (*Message, error)
+	FunctionDelete(conn *Conn, name string) (*Message, error)
+	FunctionList(conn *Conn, pattern string, withCode bool) (*Message, error)
+	FunctionDump(conn *Conn) (*Message, error)
+	FunctionRestore(conn *Conn, payload string, policy string) (*Message, error)
+	FunctionFlush(conn *Conn) (*Message, error)
+	FunctionKill(conn *Conn) (*Message, error)
+	FunctionStats(conn *Conn) (*Message, error)
+	FCall(conn *Conn, name string, keys []string, args []string, readOnly bool) (*Message, error)
+}
+
+// ClusterCommandHandler represents a hander interface for cluster commands.
+type ClusterCommandHandler interface {
+	ClusterInfo(conn *Conn) (*Message, error)
+	ClusterMyID(conn *Conn) (*Message, error)
+	ClusterNodes(conn *Conn) (*Message, error)
+	ClusterSlots(conn *Conn) (*Message, error)
+	ClusterShards(conn *Conn) (*Message, error)
+	ClusterKeySlot(conn *Conn, key string) (*Message, error)
+	ClusterCountKeysInSlot(conn *Conn, slot int) (*Message, error)
+	ClusterGetKeysInSlot(conn *Conn, slot int, count int) (*Message, error)
+	Asking(conn *Conn) (*Message, error)
+}
+
+// SentinelCommandHandler represents a hander interface for sentinel commands.
+type SentinelCommandHandler interface {
+	SentinelGetMasterAddrByName(conn *Conn, name string) (*Message, error)
+	SentinelMasters(conn *Conn) (*Message, error)
+	SentinelMaster(conn *Conn, name string) (*Message, error)
+	SentinelReplicas(conn *Conn, name string) (*Message, error)
+	SentinelSentinels(conn *Conn, name string) (*Message, error)
+	SentinelIsMasterDownByAddr(conn *Conn, host string, port int, epoch int64, runID string) (*Message, error)
+	SentinelMyID(conn *Conn) (*Message, error)
+	SentinelMonitor(conn *Conn, name string, host string, port int, quorum int) (*Message, error)
+	SentinelRemove(conn *Conn, name string) (*Message, error)
+	SentinelFailover(conn *Conn, name string) (*Message, error)
 }
 
 // GenericCommandHandler represents a hander interface for genelic commands.
@@ -101,6 +190,18 @@ type AuthCommandHandler interface {
 	Auth(conn *Conn, username string, password string) (*Message, error)
 }
 
+// KeyspaceStats represents statistics of a database keyspace.
+type KeyspaceStats struct {
+	Keys    int
+	Expires int
+}
+
+// KeyspaceHandler represents an optional hander interface to report the keyspace statistics.
+// The user command handler can implement it to provide the keyspace information for INFO and the metrics.
+type KeyspaceHandler interface {
+	KeyspaceStats() (map[DatabaseID]*KeyspaceStats, error)
+}
+
 // UserCommandHandler represents a command hander interface for user commands.
 type UserCommandHandler interface {
 	GenericCommandHandler
@@ -115,4 +216,28 @@ type UserCommandHandler interface {
 type SystemCommandHandler interface {
 	ConnectionManagementCommandHandler
 	ServerManagementCommandHandler
+	PubSubCommandHandler
+	ScriptingCommandHandler
+	FunctionCommandHandler
+	ClusterCommandHandler
+	SentinelCommandHandler
+}
+
+// PersistenceHandler represents an optional handler interface to save the dataset when the server is shut down.
+type PersistenceHandler interface {
+	Save(ctx context.Context) error
+}
+
+// FunctionPersistenceHandler represents an optional handler interface to save the function libraries when the server is shut down,
+// and load them when the server is started. The payload is the same format as the FUNCTION DUMP reply.
+type FunctionPersistenceHandler interface {
+	SaveFunctions(ctx context.Context, payload []byte) error
+	LoadFunctions(ctx context.Context) ([]byte, error)
+}
+
+// SnapshotHandler represents an optional handler interface to transfer the dataset as an RDB snapshot.
+// The user command handler must implement it to be replicated to the replicas.
+type SnapshotHandler interface {
+	WriteSnapshot(w *rdb.Writer) error
+	LoadSnapshot(r *rdb.Reader) error
 }
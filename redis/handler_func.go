@@ -16,7 +16,6 @@ package redis
 
 import (
 	"errors"
-	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
@@ -31,6 +30,9 @@ import (
 func nextIntegerArgument(cmd string, name string, args Arguments) (int, error) {
 	val, err := args.NextInteger()
 	if err != nil {
+		if !errors.Is(err, proto.ErrEOM) {
+			return 0, newNotIntegerArgumentError(err)
+		}
 		return 0, newMissingArgumentError(cmd, name, err)
 	}
 	return val, nil
@@ -44,6 +46,17 @@ func nextStringArgument(cmd string, name string, args Arguments) (string, error)
 	return str, nil
 }
 
+func nextStringArguments(args Arguments) []string {
+	strs := []string{}
+	for {
+		str, err := args.NextString()
+		if err != nil {
+			return strs
+		}
+		strs = append(strs, str)
+	}
+}
+
 func nextFloatArgument(cmd string, name string, args Arguments) (float64, error) {
 	str, err := args.NextString()
 	if err != nil {
@@ -51,7 +64,7 @@ func nextFloatArgument(cmd string, name string, args Arguments) (float64, error)
 	}
 	score, err := strconv.ParseFloat(str, 64)
 	if err != nil {
-		return 0, newMissingArgumentError(cmd, name, err)
+		return 0, newNotFloatArgumentError(err)
 	}
 	return score, nil
 }
@@ -79,7 +92,7 @@ func nextStringMapArguments(cmd string, args Arguments) (map[string]string, erro
 	for err == nil {
 		val, err = args.NextString()
 		if err != nil {
-			newMissingArgumentError(cmd, key, err)
+			return nil, newMissingArgumentError(cmd, key, err)
 		}
 		dir[key] = val
 		key, err = args.NextString()
@@ -100,6 +113,22 @@ func nextKeysArguments(cmd string, args Arguments) ([]string, error) {
 	return nextStringArrayArguments(cmd, "keys", args)
 }
 
+// nextNumKeysArguments returns the keys and the other arguments which follow the number of the keys.
+func nextNumKeysArguments(cmd string, args Arguments) ([]string, []string, error) {
+	numKeys, err := nextIntegerArgument(cmd, "numkeys", args)
+	if err != nil {
+		return nil, nil, err
+	}
+	if numKeys < 0 {
+		return nil, nil, ErrNegativeNumKeys
+	}
+	strs := nextStringArguments(args)
+	if len(strs) < numKeys {
+		return nil, nil, ErrTooManyNumKeys
+	}
+	return strs[:numKeys], strs[numKeys:], nil
+}
+
 // String argument functions
 
 func nextSetArguments(cmd string, args Arguments) (string, string, error) {
@@ -119,12 +148,12 @@ func nextSetExArguments(cmd string, args Arguments) (string, int, string, error)
 	if err != nil {
 		return "", 0, "", newMissingArgumentError(cmd, "key", err)
 	}
-	seconds, err := args.NextInteger()
+	seconds, err := nextIntegerArgument(cmd, "seconds", args)
 	if err != nil {
-		return "", 0, "", newMissingArgumentError(cmd, "seconds", err)
+		return "", 0, "", err
 	}
 	if seconds < 1 {
-		return "", 0, "", newInvalidArgumentError(cmd, "seconds", fmt.Errorf(errorShouldBeGreaterThanInt, "argument", 0))
+		return "", 0, "", newInvalidExpireTimeError(cmd)
 	}
 	val, err := args.NextString()
 	if err != nil {
@@ -156,28 +185,28 @@ func nextSetOptionArguments(cmd string, args Arguments) (SetOption, error) {
 		switch argStr {
 		case "NX":
 			if opt.NX || opt.XX {
-				return opt, newInvalidArgumentError(cmd, argStr, fmt.Errorf(errorUseOnlyOnce, "NX|XX"))
+				return opt, ErrSyntax
 			}
 			opt.NX = true
 		case "XX":
 			if opt.NX || opt.XX {
-				return opt, newInvalidArgumentError(cmd, argStr, fmt.Errorf(errorUseOnlyOnce, "NX|XX"))
+				return opt, ErrSyntax
 			}
 			opt.XX = true
 		case "EX", "PX", "EXAT", "PXAT":
 			if opt.EX > 0 || opt.PX > 0 || !opt.EXAT.IsZero() || !opt.PXAT.IsZero() {
-				return opt, newInvalidArgumentError(cmd, argStr, fmt.Errorf(errorUseOnlyOnce, "EX|PX|EXAT|PXAT"))
+				return opt, ErrSyntax
 			}
 			argInt, err := args.NextInteger()
 			if err != nil {
 				if errors.Is(err, proto.ErrEOM) {
-					return opt, newMissingArgumentError(cmd, argStr, err)
+					return opt, ErrSyntax
 				} else {
-					return opt, err
+					return opt, newNotIntegerArgumentError(err)
 				}
 			}
 			if argInt < 1 {
-				return opt, newInvalidArgumentError(cmd, argStr, fmt.Errorf(errorShouldBeGreaterThanInt, "expire", 0))
+				return opt, newInvalidExpireTimeError(cmd)
 			}
 			switch argStr {
 			case "EX":
@@ -191,16 +220,16 @@ func nextSetOptionArguments(cmd string, args Arguments) (SetOption, error) {
 			}
 		case "KEEPTTL":
 			if opt.KEEPTTL {
-				return opt, newInvalidArgumentError(cmd, argStr, fmt.Errorf(errorUseOnlyOnce, ""))
+				return opt, ErrSyntax
 			}
 			opt.KEEPTTL = true
 		case "GET":
 			if opt.GET {
-				return opt, newInvalidArgumentError(cmd, argStr, fmt.Errorf(errorUseOnlyOnce, ""))
+				return opt, ErrSyntax
 			}
 			opt.GET = true
 		default:
-			return opt, newUnkownArgumentError(cmd, argStr)
+			return opt, ErrSyntax
 		}
 	}
 	return opt, nil
@@ -261,7 +290,7 @@ func nextRangeScoreIndexArgument(cmd string, name string, args Arguments) (float
 	}
 	rng, err := strconv.ParseFloat(str[offset:], 64)
 	if err != nil {
-		return 0, false, newInvalidArgumentError(cmd, name, err)
+		return 0, false, newErrorWith(ErrNotFloat, errorMinOrMaxNotFloat, err)
 	}
 	return rng, exclusive, nil
 }
@@ -308,6 +337,40 @@ func nextRangeOptionArguments(cmd string, args Arguments) (ZRangeOption, error)
 	return opt, nil
 }
 
+// Shutdown argument fuctions
+
+func nextShutdownOptionArguments(cmd string, args Arguments) (ShutdownOption, error) {
+	opt := ShutdownOption{
+		NOSAVE: false,
+		SAVE:   false,
+		NOW:    false,
+		FORCE:  false,
+	}
+	arg, err := args.NextString()
+	for err == nil {
+		switch strings.ToUpper(arg) {
+		case "NOSAVE":
+			opt.NOSAVE = true
+		case "SAVE":
+			opt.SAVE = true
+		case "NOW":
+			opt.NOW = true
+		case "FORCE":
+			opt.FORCE = true
+		default:
+			return opt, ErrSyntax
+		}
+		arg, err = args.NextString()
+	}
+	if !errors.Is(err, proto.ErrEOM) {
+		return opt, err
+	}
+	if opt.NOSAVE && opt.SAVE {
+		return opt, ErrSyntax
+	}
+	return opt, nil
+}
+
 // Expire argument fuctions
 
 func nextExpireArgument(cmd string, ttl time.Time, args Arguments) (ExpireOption, error) {
@@ -331,7 +394,7 @@ func nextExpireArgument(cmd string, ttl time.Time, args Arguments) (ExpireOption
 		case "LT":
 			opt.LT = true
 		default:
-			return opt, newUnkownArgumentError(cmd, arg)
+			return opt, ErrSyntax
 		}
 	}
 	if !errors.Is(err, proto.ErrEOM) {
@@ -360,7 +423,7 @@ func nextScanArgument(cmd string, args Arguments) (ScanOption, error) {
 			}
 			opt.MatchPattern, err = regexp.Compile(pattern)
 			if err != nil {
-				return opt, newInvalidArgumentError(cmd, "pattern", err)
+				return opt, newErrorWith(ErrSyntax, ErrSyntax.Message, err)
 			}
 		case "COUNT":
 			opt.Count, err = nextIntegerArgument(cmd, "count", args)
@@ -375,7 +438,7 @@ func nextScanArgument(cmd string, args Arguments) (ScanOption, error) {
 			}
 			opt.Type, err = newScanTypeFromString(scanType)
 			if err != nil {
-				return opt, newInvalidArgumentError(cmd, "type", err)
+				return opt, newErrorWith(ErrSyntax, ErrSyntax.Message, err)
 			}
 		}
 		param, err = args.NextString()
@@ -0,0 +1,124 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"math/bits"
+)
+
+const (
+	latencyHistogramSubBits    = 4
+	latencyHistogramSubBuckets = 1 << latencyHistogramSubBits
+	latencyHistogramMaxBits    = 40
+	latencyHistogramBuckets    = latencyHistogramSubBuckets + (latencyHistogramMaxBits-latencyHistogramSubBits)*latencyHistogramSubBuckets
+)
+
+// LatencyHistogram represents a log-linear latency histogram in microseconds.
+// Each power of two range is divided into 16 linear sub buckets, so that the relative error of the percentiles is less than about 6%.
+type LatencyHistogram struct {
+	counts []int64
+	total  int64
+}
+
+// NewLatencyHistogram returns a new latency histogram.
+func NewLatencyHistogram() *LatencyHistogram {
+	return &LatencyHistogram{
+		counts: make([]int64, latencyHistogramBuckets),
+		total:  0,
+	}
+}
+
+// latencyHistogramIndex returns the bucket index of the specified value.
+func latencyHistogramIndex(usec int64) int {
+	if usec < latencyHistogramSubBuckets {
+		if usec < 0 {
+			return 0
+		}
+		return int(usec)
+	}
+	exp := bits.Len64(uint64(usec)) - 1
+	if latencyHistogramMaxBits <= exp {
+		return latencyHistogramBuckets - 1
+	}
+	sub := int(usec>>(exp-latencyHistogramSubBits)) & (latencyHistogramSubBuckets - 1)
+	return latencyHistogramSubBuckets + (exp-latencyHistogramSubBits)*latencyHistogramSubBuckets + sub
+}
+
+// latencyHistogramUpperBound returns the highest value of the specified bucket.
+func latencyHistogramUpperBound(idx int) int64 {
+	if idx < latencyHistogramSubBuckets {
+		return int64(idx)
+	}
+	idx -= latencyHistogramSubBuckets
+	exp := idx/latencyHistogramSubBuckets + latencyHistogramSubBits
+	sub := int64(idx%latencyHistogramSubBuckets) + latencyHistogramSubBuckets
+	return ((sub + 1) << (exp - latencyHistogramSubBits)) - 1
+}
+
+// Record records the specified latency in microseconds.
+func (h *LatencyHistogram) Record(usec int64) {
+	h.counts[latencyHistogramIndex(usec)]++
+	h.total++
+}
+
+// Count returns the number of the recorded latencies.
+func (h *LatencyHistogram) Count() int64 {
+	return h.total
+}
+
+// CountLessThanOrEqual returns the number of the recorded latencies which are less than or equal to the specified microseconds.
+// The result is exact only when the specified value is a bucket boundary such as a power of two minus one.
+func (h *LatencyHistogram) CountLessThanOrEqual(usec int64) int64 {
+	cnt := int64(0)
+	for idx, bucketCnt := range h.counts {
+		if usec < latencyHistogramUpperBound(idx) {
+			break
+		}
+		cnt += bucketCnt
+	}
+	return cnt
+}
+
+// Percentile returns the latency of the specified percentile in microseconds.
+func (h *LatencyHistogram) Percentile(p float64) float64 {
+	if h.total == 0 {
+		return 0
+	}
+	rank := int64(float64(h.total)*p/100.0 + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	if h.total < rank {
+		rank = h.total
+	}
+	cnt := int64(0)
+	for idx, bucketCnt := range h.counts {
+		cnt += bucketCnt
+		if rank <= cnt {
+			return float64(latencyHistogramUpperBound(idx))
+		}
+	}
+	return float64(latencyHistogramUpperBound(len(h.counts) - 1))
+}
+
+// Copy returns a copy of the histogram.
+func (h *LatencyHistogram) Copy() *LatencyHistogram {
+	counts := make([]int64, len(h.counts))
+	copy(counts, h.counts)
+	return &LatencyHistogram{
+		counts: counts,
+		total:  h.total,
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestLatencyHistogram(t *testing.T) {
+	h := NewLatencyHistogram()
+	for n := int64(1); n <= 1000; n++ {
+		h.Record(n)
+	}
+
+	if h.Count() != 1000 {
+		t.Errorf("%d != %d", h.Count(), 1000)
+	}
+
+	percentiles := []struct {
+		p        float64
+		expected float64
+	}{
+		{50, 500},
+		{99, 990},
+		{99.9, 999},
+		{100, 1000},
+	}
+	for _, r := range percentiles {
+		t.Run(fmt.Sprintf("p%v", r.p), func(t *testing.T) {
+			v := h.Percentile(r.p)
+			if v < r.expected || r.expected*1.07 < v {
+				t.Errorf("%f is out of range (%f)", v, r.expected)
+			}
+		})
+	}
+
+	boundaries := []struct {
+		usec     int64
+		expected int64
+	}{
+		{0, 0},
+		{15, 15},
+		{63, 63},
+		{1023, 1000},
+	}
+	for _, r := range boundaries {
+		t.Run(fmt.Sprintf("le%d", r.usec), func(t *testing.T) {
+			cnt := h.CountLessThanOrEqual(r.usec)
+			if cnt != r.expected {
+				t.Errorf("%d != %d", cnt, r.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// memorySizeUnits is the memory size units in the Redis configuration.
+var memorySizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"kb", 1024},
+	{"mb", 1024 * 1024},
+	{"gb", 1024 * 1024 * 1024},
+	{"k", 1000},
+	{"m", 1000 * 1000},
+	{"g", 1000 * 1000 * 1000},
+	{"b", 1},
+}
+
+// parseMemorySize parses the specified memory size string such as 1gb and 64mb into bytes.
+func parseMemorySize(str string) (int64, error) {
+	lstr := strings.ToLower(str)
+	unit := int64(1)
+	for _, u := range memorySizeUnits {
+		if strings.HasSuffix(lstr, u.suffix) {
+			lstr = strings.TrimSuffix(lstr, u.suffix)
+			unit = u.bytes
+			break
+		}
+	}
+	val, err := strconv.ParseInt(lstr, 10, 64)
+	if err != nil || val < 0 {
+		return 0, fmt.Errorf("%w memory size (%s)", ErrInvalid, str)
+	}
+	return val * unit, nil
+}
+
+// formatMemorySize returns the memory size string of the specified bytes using the largest binary unit.
+func formatMemorySize(size int64) string {
+	units := []struct {
+		suffix string
+		bytes  int64
+	}{
+		{"gb", 1024 * 1024 * 1024},
+		{"mb", 1024 * 1024},
+		{"kb", 1024},
+	}
+	for _, u := range units {
+		if 0 < size && size%u.bytes == 0 {
+			return strconv.FormatInt(size/u.bytes, 10) + u.suffix
+		}
+	}
+	return strconv.FormatInt(size, 10)
+}
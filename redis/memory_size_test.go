@@ -0,0 +1,53 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"testing"
+)
+
+func TestMemorySize(t *testing.T) {
+	sizes := []struct {
+		str      string
+		expected int64
+	}{
+		{"0", 0},
+		{"100", 100},
+		{"100b", 100},
+		{"1k", 1000},
+		{"1kb", 1024},
+		{"64mb", 64 * 1024 * 1024},
+		{"1GB", 1024 * 1024 * 1024},
+		{"2g", 2 * 1000 * 1000 * 1000},
+	}
+	for _, size := range sizes {
+		t.Run(size.str, func(t *testing.T) {
+			val, err := parseMemorySize(size.str)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if val != size.expected {
+				t.Errorf("%d != %d", val, size.expected)
+			}
+		})
+	}
+
+	for _, str := range []string{"", "mb", "-1", "1tb"} {
+		if _, err := parseMemorySize(str); err == nil {
+			t.Errorf("%s is parsed", str)
+		}
+	}
+}
@@ -35,7 +35,7 @@ func NewBulkMessage(msg string) *Message {
 
 // NewErrorMessage creates an error message.
 func NewErrorMessage(err error) *Message {
-	return proto.NewMessageWithType(proto.ErrorMessage).SetBytes([]byte(err.Error()))
+	return proto.NewMessageWithType(proto.ErrorMessage).SetBytes([]byte(newErrorString(err)))
 }
 
 // NewOKMessage creates a OK string message.
@@ -58,6 +58,11 @@ func NewFloatMessage(val float64) *Message {
 	return proto.NewMessageWithType(proto.BulkMessage).SetBytes([]byte(strconv.FormatFloat(val, 'g', -1, 64)))
 }
 
+// NewNilArrayMessage creates a nil array message.
+func NewNilArrayMessage() *Message {
+	return proto.NewMessageWithType(proto.ArrayMessage).SetArray(nil)
+}
+
 // NewArrayMessage creates an empty array message.
 func NewArrayMessage() *Message {
 	return proto.NewMessageWithType(proto.ArrayMessage).SetArray(proto.NewArray())
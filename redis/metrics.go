@@ -0,0 +1,171 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/cybergarage/go-logger/log"
+)
+
+const (
+	// MetricsPath is the HTTP path of the Prometheus metrics.
+	MetricsPath = "/metrics"
+
+	metricsContentType       = "text/plain; version=0.0.4; charset=utf-8"
+	metricsReadHeaderTimeout = 10 * time.Second
+)
+
+// metricsLatencyBuckets is the upper bounds of the command latency histogram buckets in seconds.
+var metricsLatencyBuckets = []float64{
+	0.00001, 0.000025, 0.00005, 0.0001, 0.00025, 0.0005,
+	0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10,
+}
+
+// metricsWriter represents a writer of the Prometheus text exposition format.
+type metricsWriter struct {
+	bytes.Buffer
+}
+
+func (w *metricsWriter) writeHeader(name string, metricType string, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, metricType)
+}
+
+func (w *metricsWriter) writeValue(name string, labels string, val float64) {
+	if 0 < len(labels) {
+		name += "{" + labels + "}"
+	}
+	fmt.Fprintf(w, "%s %s\n", name, strconv.FormatFloat(val, 'g', -1, 64))
+}
+
+func (w *metricsWriter) writeMetric(name string, metricType string, help string, val float64) {
+	w.writeHeader(name, metricType, help)
+	w.writeValue(name, "", val)
+}
+
+// MetricsBytes returns the current metrics in the Prometheus text exposition format.
+func (server *Server) MetricsBytes() []byte {
+	var w metricsWriter
+
+	w.writeMetric("redis_uptime_seconds", "gauge", "Number of seconds since the server started.", time.Since(server.startTime).Seconds())
+	w.writeMetric("redis_connected_clients", "gauge", "Number of client connections.", float64(server.clientConns.Len()))
+	w.writeMetric("redis_connections_received_total", "counter", "Total number of connections accepted by the server.", float64(server.serverStats.TotalConnectionsReceived()))
+	w.writeMetric("redis_net_input_bytes_total", "counter", "Total number of bytes read from the network.", float64(server.serverStats.NetInputBytes()))
+	w.writeMetric("redis_net_output_bytes_total", "counter", "Total number of bytes written to the network.", float64(server.serverStats.NetOutputBytes()))
+	w.writeMetric("redis_auth_failures_total", "counter", "Total number of failed authentications.", float64(server.serverStats.AuthFailures()))
+	w.writeMetric("redis_rejected_connections_total", "counter", "Total number of connections rejected by the client limits.", float64(server.serverStats.RejectedConnections()))
+	w.writeMetric("redis_client_output_buffer_limit_disconnections_total", "counter", "Total number of connections closed by the output buffer limits.", float64(server.serverStats.OutputBufferLimitDisconnections()))
+
+	cmdStats := server.commandStats.CommandStats()
+
+	w.writeHeader("redis_commands_total", "counter", "Total number of processed commands by result.")
+	for _, stat := range cmdStats {
+		w.writeValue("redis_commands_total", fmt.Sprintf("cmd=%q,result=\"ok\"", stat.Name), float64(stat.Calls-stat.FailedCalls))
+		w.writeValue("redis_commands_total", fmt.Sprintf("cmd=%q,result=\"failed\"", stat.Name), float64(stat.FailedCalls))
+		w.writeValue("redis_commands_total", fmt.Sprintf("cmd=%q,result=\"rejected\"", stat.Name), float64(stat.RejectedCalls))
+	}
+
+	// The bucket counts are approximated within the precision of LatencyHistogram.
+	w.writeHeader("redis_command_duration_seconds", "histogram", "Command execution latency.")
+	for _, stat := range cmdStats {
+		if stat.Calls == 0 {
+			continue
+		}
+		for _, le := range metricsLatencyBuckets {
+			cnt := stat.Latency.CountLessThanOrEqual(int64(le * 1e6))
+			w.writeValue("redis_command_duration_seconds_bucket", fmt.Sprintf("cmd=%q,le=%q", stat.Name, strconv.FormatFloat(le, 'g', -1, 64)), float64(cnt))
+		}
+		w.writeValue("redis_command_duration_seconds_bucket", fmt.Sprintf("cmd=%q,le=\"+Inf\"", stat.Name), float64(stat.Latency.Count()))
+		w.writeValue("redis_command_duration_seconds_sum", fmt.Sprintf("cmd=%q", stat.Name), float64(stat.Usec)/1e6)
+		w.writeValue("redis_command_duration_seconds_count", fmt.Sprintf("cmd=%q", stat.Name), float64(stat.Latency.Count()))
+	}
+
+	if keyspace, ok := server.keyspaceStats(); ok {
+		ids := make([]DatabaseID, 0, len(keyspace))
+		for id := range keyspace {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+		w.writeHeader("redis_db_keys", "gauge", "Number of keys in each database.")
+		for _, id := range ids {
+			w.writeValue("redis_db_keys", fmt.Sprintf("db=\"db%d\"", id), float64(keyspace[id].Keys))
+		}
+		w.writeHeader("redis_db_keys_expiring", "gauge", "Number of keys with an expiration in each database.")
+		for _, id := range ids {
+			w.writeValue("redis_db_keys_expiring", fmt.Sprintf("db=\"db%d\"", id), float64(keyspace[id].Expires))
+		}
+	}
+
+	return w.Bytes()
+}
+
+// MetricsHandler returns a HTTP handler which exposes the metrics in the Prometheus text exposition format.
+func (server *Server) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", metricsContentType)
+		if _, err := w.Write(server.MetricsBytes()); err != nil {
+			log.Error(err)
+		}
+	})
+}
+
+// openMetrics starts the metrics HTTP server if the metrics port is specified.
+func (server *Server) openMetrics() error {
+	port := server.ConfigMetricsPort()
+	if port <= 0 {
+		return nil
+	}
+
+	addr := net.JoinHostPort(server.Addr, strconv.Itoa(port))
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(MetricsPath, server.MetricsHandler())
+	server.metricsServer = &http.Server{ // nolint: exhaustruct
+		Handler:           mux,
+		ReadHeaderTimeout: metricsReadHeaderTimeout,
+	}
+
+	go func(httpServer *http.Server) {
+		if err := httpServer.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error(err)
+		}
+	}(server.metricsServer)
+
+	log.Infof("%s/%s metrics (%s%s) started", PackageName, Version, addr, MetricsPath)
+
+	return nil
+}
+
+// closeMetrics stops the metrics HTTP server.
+func (server *Server) closeMetrics() error {
+	if server.metricsServer == nil {
+		return nil
+	}
+	err := server.metricsServer.Close()
+	server.metricsServer = nil
+	return err
+}
@@ -0,0 +1,114 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cybergarage/go-logger/log"
+)
+
+const (
+	monitorRedactedArgument = "(redacted)"
+)
+
+// monitorRedactedCommands is the commands whose arguments are redacted in the MONITOR output.
+var monitorRedactedCommands = map[string]bool{
+	"AUTH": true,
+}
+
+// quoteMonitorArgument returns the quoted argument in the MONITOR output format.
+func quoteMonitorArgument(arg []byte) string {
+	var quoted strings.Builder
+	quoted.WriteByte('"')
+	for _, b := range arg {
+		switch b {
+		case '\\', '"':
+			quoted.WriteByte('\\')
+			quoted.WriteByte(b)
+		case '\n':
+			quoted.WriteString("\\n")
+		case '\r':
+			quoted.WriteString("\\r")
+		case '\t':
+			quoted.WriteString("\\t")
+		case '\a':
+			quoted.WriteString("\\a")
+		case '\b':
+			quoted.WriteString("\\b")
+		default:
+			if b < 0x20 || 0x7e < b {
+				fmt.Fprintf(&quoted, "\\x%02x", b)
+			} else {
+				quoted.WriteByte(b)
+			}
+		}
+	}
+	quoted.WriteByte('"')
+	return quoted.String()
+}
+
+// newMonitorLine returns the MONITOR output line of the specified command arguments.
+func newMonitorLine(ts time.Time, dbID DatabaseID, addr string, args [][]byte) string {
+	var line strings.Builder
+	fmt.Fprintf(&line, "+%d.%06d [%d %s]", ts.Unix(), ts.Nanosecond()/1000, dbID, addr)
+	isRedacted := false
+	for n, arg := range args {
+		line.WriteByte(' ')
+		if n == 0 {
+			isRedacted = monitorRedactedCommands[strings.ToUpper(string(arg))]
+		} else if isRedacted {
+			arg = []byte(monitorRedactedArgument)
+		}
+		line.WriteString(quoteMonitorArgument(arg))
+	}
+	line.WriteString("\r\n")
+	return line.String()
+}
+
+// feedMonitors sends the specified authorized command to all monitoring connections.
+func (server *Server) feedMonitors(conn *Conn, cmd string, argMsgs []*Message) {
+	if server.monitorConns.Len() == 0 {
+		return
+	}
+	args := [][]byte{[]byte(cmd)}
+	for _, argMsg := range argMsgs {
+		if argMsg.IsArray() {
+			continue
+		}
+		argBytes, _ := argMsg.Bytes()
+		args = append(args, argBytes)
+	}
+
+	addr := ""
+	if conn.Conn != nil {
+		addr = conn.RemoteAddr().String()
+	}
+	line := []byte(newMonitorLine(time.Now(), conn.Database(), addr, args))
+
+	for _, monitorConn := range server.monitorConns.Conns() {
+		if _, err := monitorConn.Write(line); err != nil {
+			log.Error(err)
+			server.monitorConns.Remove(monitorConn)
+		}
+	}
+}
+
+// MonitorConns returns the monitoring connections.
+func (server *Server) MonitorConns() *Conns {
+	return server.monitorConns
+}
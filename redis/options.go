@@ -20,6 +20,13 @@ import (
 	"github.com/cybergarage/go-redis/redis/glob"
 )
 
+type ShutdownOption struct {
+	NOSAVE bool
+	SAVE   bool
+	NOW    bool
+	FORCE  bool
+}
+
 type ExpireOption struct {
 	Time time.Time
 	NX   bool
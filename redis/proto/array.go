@@ -70,6 +70,16 @@ func (array *Array) Append(msg *Message) {
 	array.msgs = append(array.msgs, msg)
 }
 
+// Clone returns a copy of the array which reads the messages from the first one.
+func (array *Array) Clone() *Array {
+	msgs := make([]*Message, len(array.msgs))
+	copy(msgs, array.msgs)
+	return &Array{
+		index: 0,
+		msgs:  msgs,
+	}
+}
+
 // Size returns the array size.
 func (array *Array) Size() int {
 	return len(array.msgs)
@@ -114,6 +124,14 @@ func (array *Array) NextMessages() ([]*Message, error) {
 	return unreadMsgs, nil
 }
 
+// PeekMessages returns all unread messages without advancing the read position.
+func (array *Array) PeekMessages() []*Message {
+	if array.Size() <= array.index {
+		return []*Message{}
+	}
+	return array.msgs[array.index:]
+}
+
 // NextBytes returns the next byte message.
 func (array *Array) NextBytes() ([]byte, error) {
 	msg, err := array.NextMessage()
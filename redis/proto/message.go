@@ -195,6 +195,13 @@ func (msg *Message) RESPBytes() ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
+		if array == nil {
+			respBytes.WriteByte(arrayMessageByte)
+			respBytes.WriteString("-1")
+			respBytes.WriteRune(cr)
+			respBytes.WriteRune(lf)
+			break
+		}
 		bytes, err := array.RESPBytes()
 		if err != nil {
 			return nil, err
@@ -0,0 +1,194 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package proxy implements a RESP proxy which forwards the commands of the clients to upstream Redis servers.
+// The proxy shards the keyspace by the hash slots of the keys, splits the reads to the replicas and routes
+// the specified commands to the specified shards. The server in front of the upstream servers still handles
+// the connection and server commands such as AUTH, so the authentication and the middlewares are applied
+// to the forwarded commands. The commands which keep the state on the upstream connections such as MULTI,
+// SUBSCRIBE and the blocking commands are not supported because the upstream connections are pooled.
+package proxy
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/cybergarage/go-redis/redis"
+)
+
+// ErrNoShard is returned when the proxy has no shard.
+var ErrNoShard = errors.New("no shard")
+
+// statefulCommands are the commands which keep the state on the upstream connections.
+// The proxy rejects them because the upstream connections are pooled and shared by the clients.
+var statefulCommands = map[string]bool{
+	"MULTI":        true,
+	"EXEC":         true,
+	"DISCARD":      true,
+	"WATCH":        true,
+	"UNWATCH":      true,
+	"SUBSCRIBE":    true,
+	"UNSUBSCRIBE":  true,
+	"PSUBSCRIBE":   true,
+	"PUNSUBSCRIBE": true,
+	"SSUBSCRIBE":   true,
+	"SUNSUBSCRIBE": true,
+	"CLIENT":       true,
+	"RESET":        true,
+	"READONLY":     true,
+	"READWRITE":    true,
+	"MONITOR":      true,
+	"BLPOP":        true,
+	"BRPOP":        true,
+	"BRPOPLPUSH":   true,
+	"BLMOVE":       true,
+	"BLMPOP":       true,
+	"BZPOPMIN":     true,
+	"BZPOPMAX":     true,
+	"BZMPOP":       true,
+	"XREAD":        true,
+	"XREADGROUP":   true,
+	"WAIT":         true,
+	"WAITAOF":      true,
+}
+
+// Proxy represents a RESP proxy to the sharded upstream servers.
+type Proxy struct {
+	sync.RWMutex
+	shards           []*Shard
+	routes           map[string]*Shard
+	readFromReplicas bool
+	commandTable     redis.CommandTable
+}
+
+// NewProxy returns a new proxy to the specified shards. The keyspace is divided evenly by the hash slots in the specified order.
+func NewProxy(shards ...*Shard) *Proxy {
+	return &Proxy{
+		RWMutex:          sync.RWMutex{},
+		shards:           shards,
+		routes:           map[string]*Shard{},
+		readFromReplicas: false,
+		commandTable:     redis.NewCommandTable(),
+	}
+}
+
+// Shards returns the shards of the proxy.
+func (proxy *Proxy) Shards() []*Shard {
+	return proxy.shards
+}
+
+// SetRoute routes the specified command to the specified shard regardless of the keys.
+func (proxy *Proxy) SetRoute(cmd string, shard *Shard) {
+	proxy.Lock()
+	defer proxy.Unlock()
+	proxy.routes[strings.ToUpper(cmd)] = shard
+}
+
+// SetReadFromReplicas sets whether the read-only commands are forwarded to the replicas of the shards.
+func (proxy *Proxy) SetReadFromReplicas(enabled bool) {
+	proxy.Lock()
+	defer proxy.Unlock()
+	proxy.readFromReplicas = enabled
+}
+
+// Register sets the proxy as the fallback executor of the specified server which has no user command handler,
+// so the server forwards the data commands and the commands unknown to the server.
+func (proxy *Proxy) Register(server *redis.Server) {
+	proxy.commandTable = server.CommandTable()
+	server.SetFallbackExecutor(proxy.Execute)
+}
+
+// ShardOf returns the shard of the specified key.
+func (proxy *Proxy) ShardOf(key string) *Shard {
+	if len(proxy.shards) == 0 {
+		return nil
+	}
+	return proxy.shards[redis.KeySlot(key)*len(proxy.shards)/redis.ClusterSlots]
+}
+
+// Execute forwards the specified command to the upstream server selected by the routes, the keys and the command flags.
+func (proxy *Proxy) Execute(conn *redis.Conn, cmd string, args redis.Arguments) (*redis.Message, error) {
+	info, hasInfo := proxy.commandTable.LookupCommandInfo(cmd)
+	if statefulCommands[strings.ToUpper(cmd)] || (hasInfo && info.HasFlag(redis.BlockingFlag)) {
+		return nil, redis.NewErrNotSupported(cmd)
+	}
+
+	msgs, err := args.NextMessages()
+	if err != nil {
+		return nil, err
+	}
+	cmdArgs := make([]string, len(msgs)+1)
+	cmdArgs[0] = cmd
+	for n, msg := range msgs {
+		b, err := msg.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		cmdArgs[n+1] = string(b)
+	}
+
+	shard, err := proxy.shard(info, hasInfo, cmdArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	proxy.RLock()
+	readFromReplicas := proxy.readFromReplicas
+	proxy.RUnlock()
+	if readFromReplicas && hasInfo && !info.IsWrite() {
+		if replica := shard.replica(); replica != nil {
+			if msg, err := replica.Do(conn.Database(), cmdArgs...); err == nil {
+				return msg, nil
+			}
+		}
+	}
+	return shard.Primary().Do(conn.Database(), cmdArgs...)
+}
+
+// shard returns the shard of the specified command. The commands without keys are forwarded to the first shard.
+func (proxy *Proxy) shard(info *redis.CommandInfo, hasInfo bool, args []string) (*Shard, error) {
+	proxy.RLock()
+	route, ok := proxy.routes[strings.ToUpper(args[0])]
+	proxy.RUnlock()
+	if ok {
+		return route, nil
+	}
+	if len(proxy.shards) == 0 {
+		return nil, ErrNoShard
+	}
+	if !hasInfo {
+		return proxy.shards[0], nil
+	}
+	keys := info.Keys(args)
+	if len(keys) == 0 {
+		return proxy.shards[0], nil
+	}
+	shard := proxy.ShardOf(keys[0])
+	for _, key := range keys[1:] {
+		if proxy.ShardOf(key) != shard {
+			return nil, redis.ErrCrossSlot
+		}
+	}
+	return shard, nil
+}
+
+// Close closes the idle connections to the upstream servers.
+func (proxy *Proxy) Close() error {
+	var err error
+	for _, shard := range proxy.shards {
+		err = errors.Join(err, shard.Close())
+	}
+	return err
+}
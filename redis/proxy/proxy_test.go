@@ -0,0 +1,97 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cybergarage/go-redis/redis"
+	"github.com/cybergarage/go-redis/redis/proto"
+)
+
+func TestProxyShard(t *testing.T) {
+	shards := []*Shard{
+		NewShard(NewUpstream("127.0.0.1:7000")),
+		NewShard(NewUpstream("127.0.0.1:7001")),
+	}
+	proxy := NewProxy(shards...)
+	proxy.Register(redis.NewServer())
+
+	lookup := func(args ...string) (*Shard, error) {
+		info, ok := proxy.commandTable.LookupCommandInfo(args[0])
+		return proxy.shard(info, ok, args)
+	}
+
+	shard, err := lookup("GET", "{user1}:a")
+	if err != nil || shard != proxy.ShardOf("user1") {
+		t.Errorf("GET is not forwarded to the shard of the key (%v)", err)
+	}
+	shard, err = lookup("MGET", "{user1}:a", "{user1}:b")
+	if err != nil || shard != proxy.ShardOf("user1") {
+		t.Errorf("MGET is not forwarded to the shard of the keys (%v)", err)
+	}
+
+	keys := map[*Shard]string{}
+	for _, key := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		keys[proxy.ShardOf(key)] = key
+	}
+	if len(keys) != len(shards) {
+		t.Fatalf("keys are not distributed to the shards")
+	}
+	if _, err := lookup("MGET", keys[shards[0]], keys[shards[1]]); !errors.Is(err, redis.ErrCrossSlot) {
+		t.Errorf("MGET across the shards is accepted (%v)", err)
+	}
+
+	shard, err = lookup("NOCOMMAND", keys[shards[1]])
+	if err != nil || shard != shards[0] {
+		t.Errorf("unknown command is not forwarded to the first shard (%v)", err)
+	}
+
+	proxy.SetRoute("keys", shards[1])
+	shard, err = lookup("KEYS", "*")
+	if err != nil || shard != shards[1] {
+		t.Errorf("KEYS is not forwarded to the routed shard (%v)", err)
+	}
+}
+
+func TestProxyStatefulCommands(t *testing.T) {
+	// The upstream is not running, so the commands must be rejected before forwarding.
+	proxy := NewProxy(NewShard(NewUpstream("127.0.0.1:0")))
+	proxy.Register(redis.NewServer())
+	for _, args := range [][]string{{"MULTI"}, {"watch", "key"}, {"SUBSCRIBE", "ch"}, {"BLPOP", "key", "0"}} {
+		array := proto.NewArray()
+		for _, arg := range args[1:] {
+			array.Append(redis.NewBulkMessage(arg))
+		}
+		if _, err := proxy.Execute(nil, args[0], array); !errors.Is(err, redis.ErrNotSupported) {
+			t.Errorf("%s: %v != %v", args[0], err, redis.ErrNotSupported)
+		}
+	}
+}
+
+func TestShardReplica(t *testing.T) {
+	primary := NewUpstream("127.0.0.1:7000")
+	replicas := []*Upstream{NewUpstream("127.0.0.1:7001"), NewUpstream("127.0.0.1:7002")}
+	if NewShard(primary).replica() != nil {
+		t.Errorf("shard without replicas returns a replica")
+	}
+	shard := NewShard(primary, replicas...)
+	for n := 0; n < 4; n++ {
+		if replica := shard.replica(); replica != replicas[n%len(replicas)] {
+			t.Errorf("replica %d = %s", n, replica.Addr())
+		}
+	}
+}
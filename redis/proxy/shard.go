@@ -0,0 +1,64 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// Shard represents a primary upstream server and the replicas serving a part of the keyspace.
+type Shard struct {
+	primary  *Upstream
+	replicas []*Upstream
+	next     atomic.Uint64
+}
+
+// NewShard returns a new shard of the specified primary and replica upstream servers.
+func NewShard(primary *Upstream, replicas ...*Upstream) *Shard {
+	return &Shard{
+		primary:  primary,
+		replicas: replicas,
+		next:     atomic.Uint64{},
+	}
+}
+
+// Primary returns the primary upstream server of the shard.
+func (shard *Shard) Primary() *Upstream {
+	return shard.primary
+}
+
+// Replicas returns the replica upstream servers of the shard.
+func (shard *Shard) Replicas() []*Upstream {
+	return shard.replicas
+}
+
+// replica returns the next replica in round-robin order, or nil if the shard has no replica.
+func (shard *Shard) replica() *Upstream {
+	if len(shard.replicas) == 0 {
+		return nil
+	}
+	n := shard.next.Add(1) - 1
+	return shard.replicas[n%uint64(len(shard.replicas))]
+}
+
+// Close closes the idle connections to the upstream servers of the shard.
+func (shard *Shard) Close() error {
+	err := shard.primary.Close()
+	for _, replica := range shard.replicas {
+		err = errors.Join(err, replica.Close())
+	}
+	return err
+}
@@ -0,0 +1,149 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/cybergarage/go-redis/redis"
+	"github.com/cybergarage/go-redis/redis/client"
+)
+
+const (
+	// DefaultPoolSize is the default number of the idle connections kept for an upstream server.
+	DefaultPoolSize = 16
+	// DefaultTimeout is the default timeout of the commands forwarded to upstream servers.
+	DefaultTimeout = 5 * time.Second
+)
+
+// upstreamConn represents a pooled connection to an upstream server with the selected database.
+type upstreamConn struct {
+	*client.Client
+	db redis.DatabaseID
+}
+
+// Upstream represents an upstream server with a pool of the connections.
+type Upstream struct {
+	addr     string
+	password string
+	timeout  time.Duration
+	pool     chan *upstreamConn
+}
+
+// NewUpstream returns a new upstream server of the specified address.
+func NewUpstream(addr string) *Upstream {
+	return &Upstream{
+		addr:     addr,
+		password: "",
+		timeout:  DefaultTimeout,
+		pool:     make(chan *upstreamConn, DefaultPoolSize),
+	}
+}
+
+// Addr returns the address of the upstream server.
+func (upstream *Upstream) Addr() string {
+	return upstream.addr
+}
+
+// SetPassword sets the password to authenticate with the upstream server.
+func (upstream *Upstream) SetPassword(password string) *Upstream {
+	upstream.password = password
+	return upstream
+}
+
+// SetTimeout sets the timeout of the commands forwarded to the upstream server.
+func (upstream *Upstream) SetTimeout(timeout time.Duration) *Upstream {
+	upstream.timeout = timeout
+	return upstream
+}
+
+// SetPoolSize sets the number of the idle connections kept for the upstream server. SetPoolSize should be called before use.
+func (upstream *Upstream) SetPoolSize(size int) *Upstream {
+	upstream.pool = make(chan *upstreamConn, size)
+	return upstream
+}
+
+// Do forwards the specified command arguments to the specified database of the upstream server.
+// The error replies of the upstream server are returned as the reply messages without errors.
+func (upstream *Upstream) Do(db redis.DatabaseID, args ...string) (*redis.Message, error) {
+	conn, err := upstream.conn()
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(upstream.timeout))
+	if conn.db != db {
+		msg, err := conn.Do("SELECT", strconv.Itoa(db))
+		if err != nil {
+			return upstream.release(conn, msg, err)
+		}
+		conn.db = db
+	}
+	msg, err := conn.Do(args...)
+	return upstream.release(conn, msg, err)
+}
+
+// conn returns an idle connection in the pool or a new connection.
+func (upstream *Upstream) conn() (*upstreamConn, error) {
+	select {
+	case conn := <-upstream.pool:
+		return conn, nil
+	default:
+	}
+	cli := client.NewClient()
+	if err := cli.OpenWithTimeout(upstream.addr, upstream.timeout); err != nil {
+		return nil, err
+	}
+	conn := &upstreamConn{
+		Client: cli,
+		db:     0,
+	}
+	if 0 < len(upstream.password) {
+		cli.SetDeadline(time.Now().Add(upstream.timeout))
+		if _, err := cli.Do("AUTH", upstream.password); err != nil {
+			cli.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// release returns the specified connection to the pool unless the connection is broken.
+func (upstream *Upstream) release(conn *upstreamConn, msg *redis.Message, err error) (*redis.Message, error) {
+	if err != nil && !errors.Is(err, client.ErrReply) {
+		conn.Close()
+		return nil, err
+	}
+	select {
+	case upstream.pool <- conn:
+	default:
+		conn.Close()
+	}
+	return msg, nil
+}
+
+// Close closes the idle connections to the upstream server.
+func (upstream *Upstream) Close() error {
+	var errs error
+	for {
+		select {
+		case conn := <-upstream.pool:
+			errs = errors.Join(errs, conn.Close())
+		default:
+			return errs
+		}
+	}
+}
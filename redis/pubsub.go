@@ -0,0 +1,350 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/cybergarage/go-logger/log"
+	"github.com/cybergarage/go-redis/redis/glob"
+)
+
+// pubSubContextCommands is the commands which are allowed while the connection subscribes to channels or patterns.
+var pubSubContextCommands = map[string]bool{
+	"SUBSCRIBE":    true,
+	"UNSUBSCRIBE":  true,
+	"PSUBSCRIBE":   true,
+	"PUNSUBSCRIBE": true,
+	"PING":         true,
+	"QUIT":         true,
+}
+
+// pubSubPattern represents a subscribed pattern.
+type pubSubPattern struct {
+	glob  *glob.Glob
+	conns map[*Conn]bool
+}
+
+// pubSub represents the channel and pattern subscriptions of the connections.
+type pubSub struct {
+	sync.Mutex
+	channels     map[string]map[*Conn]bool
+	patterns     map[string]*pubSubPattern
+	connChannels map[*Conn][]string
+	connPatterns map[*Conn][]string
+}
+
+// newPubSub returns a new subscription registry.
+func newPubSub() *pubSub {
+	return &pubSub{
+		Mutex:        sync.Mutex{},
+		channels:     map[string]map[*Conn]bool{},
+		patterns:     map[string]*pubSubPattern{},
+		connChannels: map[*Conn][]string{},
+		connPatterns: map[*Conn][]string{},
+	}
+}
+
+// subscriptionsLocked returns the number of the channels and patterns subscribed by the specified connection.
+func (ps *pubSub) subscriptionsLocked(conn *Conn) int {
+	return len(ps.connChannels[conn]) + len(ps.connPatterns[conn])
+}
+
+// subscribeLocked subscribes the specified connection to the specified channel.
+func (ps *pubSub) subscribeLocked(conn *Conn, channel string) {
+	conns, ok := ps.channels[channel]
+	if !ok {
+		conns = map[*Conn]bool{}
+		ps.channels[channel] = conns
+	}
+	if conns[conn] {
+		return
+	}
+	conns[conn] = true
+	ps.connChannels[conn] = append(ps.connChannels[conn], channel)
+}
+
+// unsubscribeLocked unsubscribes the specified connection from the specified channel.
+func (ps *pubSub) unsubscribeLocked(conn *Conn, channel string) {
+	conns, ok := ps.channels[channel]
+	if !ok || !conns[conn] {
+		return
+	}
+	delete(conns, conn)
+	if len(conns) == 0 {
+		delete(ps.channels, channel)
+	}
+	ps.connChannels[conn] = removeString(ps.connChannels[conn], channel)
+	if len(ps.connChannels[conn]) == 0 {
+		delete(ps.connChannels, conn)
+	}
+}
+
+// psubscribeLocked subscribes the specified connection to the specified pattern.
+func (ps *pubSub) psubscribeLocked(conn *Conn, pattern string) error {
+	p, ok := ps.patterns[pattern]
+	if !ok {
+		g, err := glob.Compile(pattern)
+		if err != nil {
+			return err
+		}
+		p = &pubSubPattern{glob: g, conns: map[*Conn]bool{}}
+		ps.patterns[pattern] = p
+	}
+	if p.conns[conn] {
+		return nil
+	}
+	p.conns[conn] = true
+	ps.connPatterns[conn] = append(ps.connPatterns[conn], pattern)
+	return nil
+}
+
+// punsubscribeLocked unsubscribes the specified connection from the specified pattern.
+func (ps *pubSub) punsubscribeLocked(conn *Conn, pattern string) {
+	p, ok := ps.patterns[pattern]
+	if !ok || !p.conns[conn] {
+		return
+	}
+	delete(p.conns, conn)
+	if len(p.conns) == 0 {
+		delete(ps.patterns, pattern)
+	}
+	ps.connPatterns[conn] = removeString(ps.connPatterns[conn], pattern)
+	if len(ps.connPatterns[conn]) == 0 {
+		delete(ps.connPatterns, conn)
+	}
+}
+
+// removeString returns the specified strings without the specified string.
+func removeString(strs []string, str string) []string {
+	for n, s := range strs {
+		if s == str {
+			return append(strs[:n:n], strs[n+1:]...)
+		}
+	}
+	return strs
+}
+
+// newPubSubReply returns a reply message of the subscription commands.
+func newPubSubReply(kind string, name string, count int) []byte {
+	msg := NewArrayMessage()
+	msg.Append(NewBulkMessage(kind))
+	if 0 < len(name) {
+		msg.Append(NewBulkMessage(name))
+	} else {
+		msg.Append(NewNilMessage())
+	}
+	msg.Append(NewIntegerMessage(count))
+	b, _ := msg.RESPBytes()
+	return b
+}
+
+// updatePubSubClientLocked updates the client type of the specified connection by the subscriptions.
+func (server *Server) updatePubSubClientLocked(conn *Conn) {
+	switch {
+	case 0 < server.pubSub.subscriptionsLocked(conn):
+		conn.SetClientType(PubSubClient)
+	case conn.ClientType() == PubSubClient:
+		conn.SetClientType(NormalClient)
+	}
+}
+
+// isPubSubContext returns true if the specified connection subscribes to any channels or patterns.
+func (server *Server) isPubSubContext(conn *Conn) bool {
+	return conn.ClientType() == PubSubClient
+}
+
+// Subscribe subscribes the connection to the specified channels. The replies are written directly to the connection.
+func (server *Server) Subscribe(conn *Conn, channels []string) (*Message, error) {
+	ps := server.pubSub
+	ps.Lock()
+	defer ps.Unlock()
+	for _, channel := range channels {
+		ps.subscribeLocked(conn, channel)
+		if _, err := conn.Write(newPubSubReply("subscribe", channel, ps.subscriptionsLocked(conn))); err != nil {
+			return nil, err
+		}
+	}
+	server.updatePubSubClientLocked(conn)
+	return nil, ErrNoReply
+}
+
+// Unsubscribe unsubscribes the connection from the specified channels, or all channels if no channels are specified.
+func (server *Server) Unsubscribe(conn *Conn, channels []string) (*Message, error) {
+	ps := server.pubSub
+	ps.Lock()
+	defer ps.Unlock()
+	if len(channels) == 0 {
+		channels = append([]string{}, ps.connChannels[conn]...)
+	}
+	if len(channels) == 0 {
+		if _, err := conn.Write(newPubSubReply("unsubscribe", "", ps.subscriptionsLocked(conn))); err != nil {
+			return nil, err
+		}
+	}
+	for _, channel := range channels {
+		ps.unsubscribeLocked(conn, channel)
+		if _, err := conn.Write(newPubSubReply("unsubscribe", channel, ps.subscriptionsLocked(conn))); err != nil {
+			return nil, err
+		}
+	}
+	server.updatePubSubClientLocked(conn)
+	return nil, ErrNoReply
+}
+
+// PSubscribe subscribes the connection to the specified patterns. The replies are written directly to the connection.
+func (server *Server) PSubscribe(conn *Conn, patterns []string) (*Message, error) {
+	ps := server.pubSub
+	ps.Lock()
+	defer ps.Unlock()
+	for _, pattern := range patterns {
+		if err := ps.psubscribeLocked(conn, pattern); err != nil {
+			return nil, err
+		}
+		if _, err := conn.Write(newPubSubReply("psubscribe", pattern, ps.subscriptionsLocked(conn))); err != nil {
+			return nil, err
+		}
+	}
+	server.updatePubSubClientLocked(conn)
+	return nil, ErrNoReply
+}
+
+// PUnsubscribe unsubscribes the connection from the specified patterns, or all patterns if no patterns are specified.
+func (server *Server) PUnsubscribe(conn *Conn, patterns []string) (*Message, error) {
+	ps := server.pubSub
+	ps.Lock()
+	defer ps.Unlock()
+	if len(patterns) == 0 {
+		patterns = append([]string{}, ps.connPatterns[conn]...)
+	}
+	if len(patterns) == 0 {
+		if _, err := conn.Write(newPubSubReply("punsubscribe", "", ps.subscriptionsLocked(conn))); err != nil {
+			return nil, err
+		}
+	}
+	for _, pattern := range patterns {
+		ps.punsubscribeLocked(conn, pattern)
+		if _, err := conn.Write(newPubSubReply("punsubscribe", pattern, ps.subscriptionsLocked(conn))); err != nil {
+			return nil, err
+		}
+	}
+	server.updatePubSubClientLocked(conn)
+	return nil, ErrNoReply
+}
+
+// removeSubscriptions removes all subscriptions of the specified connection.
+func (server *Server) removeSubscriptions(conn *Conn) {
+	ps := server.pubSub
+	ps.Lock()
+	defer ps.Unlock()
+	for _, channel := range append([]string{}, ps.connChannels[conn]...) {
+		ps.unsubscribeLocked(conn, channel)
+	}
+	for _, pattern := range append([]string{}, ps.connPatterns[conn]...) {
+		ps.punsubscribeLocked(conn, pattern)
+	}
+}
+
+// Publish posts the specified message to the specified channel and returns the number of the receivers.
+func (server *Server) Publish(channel string, message string) int {
+	ps := server.pubSub
+	ps.Lock()
+	defer ps.Unlock()
+
+	receivers := 0
+	write := func(conn *Conn, msg *Message) {
+		b, err := msg.RESPBytes()
+		if err != nil {
+			log.Error(err)
+			return
+		}
+		if _, err := conn.Write(b); err != nil {
+			log.Error(err)
+			return
+		}
+		receivers++
+	}
+
+	conns := ps.channels[channel]
+	if 0 < len(conns) {
+		msg := NewArrayMessage()
+		msg.Append(NewBulkMessage("message"))
+		msg.Append(NewBulkMessage(channel))
+		msg.Append(NewBulkMessage(message))
+		for conn := range conns {
+			write(conn, msg)
+		}
+	}
+	for pattern, p := range ps.patterns {
+		if !p.glob.MatchString(channel) {
+			continue
+		}
+		msg := NewArrayMessage()
+		msg.Append(NewBulkMessage("pmessage"))
+		msg.Append(NewBulkMessage(pattern))
+		msg.Append(NewBulkMessage(channel))
+		msg.Append(NewBulkMessage(message))
+		for conn := range p.conns {
+			write(conn, msg)
+		}
+	}
+	return receivers
+}
+
+// PublishMessage handles PUBLISH command.
+func (server *Server) PublishMessage(conn *Conn, channel string, message string) (*Message, error) {
+	return NewIntegerMessage(server.Publish(channel, message)), nil
+}
+
+// PubSubChannels returns the active channels which match the specified pattern.
+func (server *Server) PubSubChannels(conn *Conn, pattern string) (*Message, error) {
+	g, err := glob.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	ps := server.pubSub
+	ps.Lock()
+	defer ps.Unlock()
+	channels := []string{}
+	for channel := range ps.channels {
+		if g.MatchString(channel) {
+			channels = append(channels, channel)
+		}
+	}
+	sort.Strings(channels)
+	return NewStringArrayMessage(channels), nil
+}
+
+// PubSubNumSub returns the number of the subscribers of the specified channels.
+func (server *Server) PubSubNumSub(conn *Conn, channels []string) (*Message, error) {
+	ps := server.pubSub
+	ps.Lock()
+	defer ps.Unlock()
+	msg := NewArrayMessage()
+	for _, channel := range channels {
+		msg.Append(NewBulkMessage(channel))
+		msg.Append(NewIntegerMessage(len(ps.channels[channel])))
+	}
+	return msg, nil
+}
+
+// PubSubNumPat returns the number of the subscribed patterns.
+func (server *Server) PubSubNumPat(conn *Conn) (*Message, error) {
+	ps := server.pubSub
+	ps.Lock()
+	defer ps.Unlock()
+	return NewIntegerMessage(len(ps.patterns)), nil
+}
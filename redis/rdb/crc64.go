@@ -0,0 +1,30 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rdb
+
+import (
+	"hash/crc64"
+)
+
+// crc64Jones is the reflected polynomial of the Jones CRC-64 used by Redis.
+const crc64Jones = 0x95AC9329AC4BC9B5
+
+var crc64Table = crc64.MakeTable(crc64Jones)
+
+// updateChecksum returns the Redis CRC-64 checksum updated with the specified bytes.
+// Redis uses no initial value and no final XOR unlike hash/crc64, so both are inverted here.
+func updateChecksum(crc uint64, p []byte) uint64 {
+	return ^crc64.Update(^crc, crc64Table, p)
+}
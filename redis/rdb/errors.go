@@ -0,0 +1,28 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rdb
+
+import (
+	"errors"
+)
+
+var (
+	// ErrInvalid is returned when the RDB bytes are broken.
+	ErrInvalid = errors.New("invalid RDB format")
+	// ErrNotSupported is returned when the RDB bytes have an unsupported version or value type.
+	ErrNotSupported = errors.New("not supported")
+	// ErrChecksum is returned when the checksum of the RDB bytes does not match.
+	ErrChecksum = errors.New("wrong RDB checksum")
+)
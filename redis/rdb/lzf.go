@@ -0,0 +1,60 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rdb
+
+import (
+	"fmt"
+)
+
+// decompressLZF decompresses the specified LZF compressed bytes into the specified length bytes.
+func decompressLZF(in []byte, outLen int) ([]byte, error) {
+	out := make([]byte, 0, outLen)
+	for n := 0; n < len(in); {
+		ctrl := int(in[n])
+		n++
+		if ctrl < (1 << 5) {
+			run := ctrl + 1
+			if len(in) < n+run {
+				return nil, fmt.Errorf("%w LZF literal", ErrInvalid)
+			}
+			out = append(out, in[n:n+run]...)
+			n += run
+			continue
+		}
+		run := ctrl >> 5
+		if run == 7 {
+			if len(in) <= n {
+				return nil, fmt.Errorf("%w LZF length", ErrInvalid)
+			}
+			run += int(in[n])
+			n++
+		}
+		if len(in) <= n {
+			return nil, fmt.Errorf("%w LZF reference", ErrInvalid)
+		}
+		ref := len(out) - ((ctrl & 0x1F) << 8) - int(in[n]) - 1
+		n++
+		if ref < 0 {
+			return nil, fmt.Errorf("%w LZF reference", ErrInvalid)
+		}
+		for i := 0; i < run+2; i++ {
+			out = append(out, out[ref+i])
+		}
+	}
+	if len(out) != outLen {
+		return nil, fmt.Errorf("%w LZF length (%d != %d)", ErrInvalid, len(out), outLen)
+	}
+	return out, nil
+}
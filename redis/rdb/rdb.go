@@ -0,0 +1,105 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rdb implements reading and writing of the Redis database (RDB) snapshot format.
+package rdb
+
+import (
+	"time"
+)
+
+const (
+	// Magic is the magic string at the beginning of RDB files.
+	Magic = "REDIS"
+	// Version is the RDB version written by Writer.
+	Version = 9
+	// MaxVersion is the latest RDB version which Reader accepts.
+	MaxVersion = 12
+)
+
+// Opcodes of RDB files.
+const (
+	opcodeFunction2     = 0xF5
+	opcodeModuleAux     = 0xF7
+	opcodeIdle          = 0xF8
+	opcodeFreq          = 0xF9
+	opcodeAux           = 0xFA
+	opcodeResizeDB      = 0xFB
+	opcodeExpireTimeMs  = 0xFC
+	opcodeExpireTime    = 0xFD
+	opcodeSelectDB      = 0xFE
+	opcodeEOF           = 0xFF
+	lengthEncoded       = 3
+	lengthEncodedInt8   = 0
+	lengthEncodedInt16  = 1
+	lengthEncodedInt32  = 2
+	lengthEncodedLZF    = 3
+	length6Bit          = 0
+	length14Bit         = 1
+	length32Bit         = 0x80
+	length64Bit         = 0x81
+	doubleNaN           = 253
+	doublePositiveInf   = 254
+	doubleNegativeInf   = 255
+	expireTimeMsBytes   = 8
+	expireTimeBytes     = 4
+	checksumBytes       = 8
+	versionDigits       = 4
+	magicAndVersionSize = len(Magic) + versionDigits
+)
+
+// Type represents a value type of RDB files.
+type Type byte
+
+const (
+	// StringType is the type of string values.
+	StringType Type = 0
+	// ListType is the type of list values.
+	ListType Type = 1
+	// SetType is the type of set values.
+	SetType Type = 2
+	// ZSetType is the type of sorted set values which scores are encoded as strings.
+	ZSetType Type = 3
+	// HashType is the type of hash values.
+	HashType Type = 4
+	// ZSet2Type is the type of sorted set values which scores are encoded as binary doubles.
+	ZSet2Type Type = 5
+)
+
+// ZSetMember represents a member of a sorted set value.
+type ZSetMember struct {
+	Score  float64
+	Member string
+}
+
+// Entry represents a key entry of RDB files.
+// The value is a string for StringType, a []string for ListType and SetType,
+// a []*ZSetMember for ZSetType and ZSet2Type, and a map[string]string for HashType.
+type Entry struct {
+	// DatabaseID is the database number of the key.
+	DatabaseID int
+	// Key is the key name.
+	Key string
+	// Type is the value type.
+	Type Type
+	// Value is the value of the key.
+	Value any
+	// ExpireAt is the expiration time of the key, or zero if the key has no expiration time.
+	ExpireAt time.Time
+}
+
+// HasExpireAt returns true if the entry has an expiration time.
+func (entry *Entry) HasExpireAt() bool {
+	return !entry.ExpireAt.IsZero()
+}
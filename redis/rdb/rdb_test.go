@@ -0,0 +1,115 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rdb
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestChecksum(t *testing.T) {
+	crc := updateChecksum(0, []byte("123456789"))
+	if crc != 0xE9C6D914C4B8D9CA {
+		t.Errorf("%016X != %016X", crc, uint64(0xE9C6D914C4B8D9CA))
+	}
+}
+
+func TestDecompressLZF(t *testing.T) {
+	// "abcabcabcabc" compressed by LZF: a literal run of "abc" and a back reference of 9 bytes.
+	in := []byte{0x02, 'a', 'b', 'c', 0xE0, 0x00, 0x02}
+	out, err := decompressLZF(in, 12)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(out) != "abcabcabcabc" {
+		t.Errorf("%s != %s", string(out), "abcabcabcabc")
+	}
+}
+
+func TestReadWrite(t *testing.T) {
+	expireAt := time.UnixMilli(time.Now().Add(time.Hour).UnixMilli())
+	entries := []*Entry{
+		{DatabaseID: 0, Key: "str", Type: StringType, Value: "hello", ExpireAt: time.Time{}},
+		{DatabaseID: 0, Key: "int8", Type: StringType, Value: "-12", ExpireAt: time.Time{}},
+		{DatabaseID: 0, Key: "int32", Type: StringType, Value: "1234567", ExpireAt: expireAt},
+		{DatabaseID: 0, Key: "notint", Type: StringType, Value: "0123", ExpireAt: time.Time{}},
+		{DatabaseID: 1, Key: "list", Type: ListType, Value: []string{"a", "b", "c"}, ExpireAt: time.Time{}},
+		{DatabaseID: 1, Key: "set", Type: SetType, Value: []string{"x", "y"}, ExpireAt: time.Time{}},
+		{DatabaseID: 2, Key: "hash", Type: HashType, Value: map[string]string{"f1": "v1", "f2": "v2"}, ExpireAt: time.Time{}},
+		{DatabaseID: 2, Key: "zset", Type: ZSet2Type, Value: []*ZSetMember{{Score: 1.5, Member: "m1"}, {Score: -2, Member: "m2"}}, ExpireAt: time.Time{}},
+		{DatabaseID: 0, Key: "long", Type: StringType, Value: string(bytes.Repeat([]byte("x"), 20000)), ExpireAt: time.Time{}},
+	}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	if err := w.WriteAux("redis-ver", "7.2.0"); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := w.WriteFunction("#!lua name=lib\n"); err != nil {
+		t.Error(err)
+		return
+	}
+	for _, entry := range entries {
+		if err := w.WriteEntry(entry); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Error(err)
+		return
+	}
+
+	r := NewReader(bytes.NewReader(buf.Bytes()))
+	for _, expected := range entries {
+		entry, err := r.Next()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if !reflect.DeepEqual(entry, expected) {
+			t.Errorf("%v != %v", entry, expected)
+		}
+	}
+	if _, err := r.Next(); !errors.Is(err, io.EOF) {
+		t.Error(err)
+	}
+	if r.Version() != Version {
+		t.Errorf("%d != %d", r.Version(), Version)
+	}
+	if r.Aux()["redis-ver"] != "7.2.0" {
+		t.Errorf("%s != %s", r.Aux()["redis-ver"], "7.2.0")
+	}
+	if len(r.Functions()) != 1 {
+		t.Errorf("%d != %d", len(r.Functions()), 1)
+	}
+
+	broken := buf.Bytes()
+	broken[len(broken)-checksumBytes-2] ^= 0xFF
+	r = NewReader(bytes.NewReader(broken))
+	var err error
+	for err == nil {
+		_, err = r.Next()
+	}
+	if errors.Is(err, io.EOF) {
+		t.Errorf("broken bytes are read")
+	}
+}
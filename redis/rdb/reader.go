@@ -0,0 +1,415 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rdb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Reader represents a reader of RDB files.
+type Reader struct {
+	r         *bufio.Reader
+	crc       uint64
+	version   int
+	db        int
+	aux       map[string]string
+	functions []string
+	done      bool
+}
+
+// NewReader returns a new RDB reader for the specified reader.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{
+		r:         bufio.NewReader(r),
+		crc:       0,
+		version:   0,
+		db:        0,
+		aux:       map[string]string{},
+		functions: []string{},
+		done:      false,
+	}
+}
+
+// Version returns the RDB version of the read file.
+func (r *Reader) Version() int {
+	return r.version
+}
+
+// Aux returns the auxiliary fields which have been read.
+func (r *Reader) Aux() map[string]string {
+	return r.aux
+}
+
+// Functions returns the function library codes which have been read.
+func (r *Reader) Functions() []string {
+	return r.functions
+}
+
+// read reads the specified number of bytes updating the checksum.
+func (r *Reader) read(n int) ([]byte, error) {
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r.r, buf); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	r.crc = updateChecksum(r.crc, buf)
+	return buf, nil
+}
+
+// readByte reads a byte updating the checksum.
+func (r *Reader) readByte() (byte, error) {
+	buf, err := r.read(1)
+	if err != nil {
+		return 0, err
+	}
+	return buf[0], nil
+}
+
+// readHeader reads the magic string and the version.
+func (r *Reader) readHeader() error {
+	buf, err := r.read(magicAndVersionSize)
+	if err != nil {
+		return err
+	}
+	if string(buf[:len(Magic)]) != Magic {
+		return fmt.Errorf("%w magic (%s)", ErrInvalid, string(buf[:len(Magic)]))
+	}
+	version, err := strconv.Atoi(string(buf[len(Magic):]))
+	if err != nil {
+		return fmt.Errorf("%w version (%s)", ErrInvalid, string(buf[len(Magic):]))
+	}
+	if version < 1 || MaxVersion < version {
+		return fmt.Errorf("%w version (%d)", ErrNotSupported, version)
+	}
+	r.version = version
+	return nil
+}
+
+// readLength reads a length encoded value. It returns true if the value is a special encoding.
+func (r *Reader) readLength() (uint64, bool, error) {
+	b, err := r.readByte()
+	if err != nil {
+		return 0, false, err
+	}
+	switch b >> 6 {
+	case length6Bit:
+		return uint64(b & 0x3F), false, nil
+	case length14Bit:
+		next, err := r.readByte()
+		if err != nil {
+			return 0, false, err
+		}
+		return uint64(b&0x3F)<<8 | uint64(next), false, nil
+	case lengthEncoded:
+		return uint64(b & 0x3F), true, nil
+	}
+	switch b {
+	case length32Bit:
+		buf, err := r.read(4)
+		if err != nil {
+			return 0, false, err
+		}
+		return uint64(binary.BigEndian.Uint32(buf)), false, nil
+	case length64Bit:
+		buf, err := r.read(8)
+		if err != nil {
+			return 0, false, err
+		}
+		return binary.BigEndian.Uint64(buf), false, nil
+	}
+	return 0, false, fmt.Errorf("%w length (%02X)", ErrInvalid, b)
+}
+
+// readCount reads a length which is not a special encoding.
+func (r *Reader) readCount() (int, error) {
+	n, encoded, err := r.readLength()
+	if err != nil {
+		return 0, err
+	}
+	if encoded || math.MaxInt32 < n {
+		return 0, fmt.Errorf("%w length (%d)", ErrInvalid, n)
+	}
+	return int(n), nil
+}
+
+// readString reads a string which may be encoded as an integer or compressed.
+func (r *Reader) readString() (string, error) {
+	n, encoded, err := r.readLength()
+	if err != nil {
+		return "", err
+	}
+	if !encoded {
+		if math.MaxInt32 < n {
+			return "", fmt.Errorf("%w string length (%d)", ErrInvalid, n)
+		}
+		buf, err := r.read(int(n))
+		if err != nil {
+			return "", err
+		}
+		return string(buf), nil
+	}
+	switch n {
+	case lengthEncodedInt8:
+		buf, err := r.read(1)
+		if err != nil {
+			return "", err
+		}
+		return strconv.Itoa(int(int8(buf[0]))), nil
+	case lengthEncodedInt16:
+		buf, err := r.read(2)
+		if err != nil {
+			return "", err
+		}
+		return strconv.Itoa(int(int16(binary.LittleEndian.Uint16(buf)))), nil
+	case lengthEncodedInt32:
+		buf, err := r.read(4)
+		if err != nil {
+			return "", err
+		}
+		return strconv.Itoa(int(int32(binary.LittleEndian.Uint32(buf)))), nil
+	case lengthEncodedLZF:
+		clen, err := r.readCount()
+		if err != nil {
+			return "", err
+		}
+		ulen, err := r.readCount()
+		if err != nil {
+			return "", err
+		}
+		buf, err := r.read(clen)
+		if err != nil {
+			return "", err
+		}
+		out, err := decompressLZF(buf, ulen)
+		if err != nil {
+			return "", err
+		}
+		return string(out), nil
+	}
+	return "", fmt.Errorf("%w string encoding (%d)", ErrInvalid, n)
+}
+
+// readStrings reads strings with the length.
+func (r *Reader) readStrings() ([]string, error) {
+	n, err := r.readCount()
+	if err != nil {
+		return nil, err
+	}
+	strs := make([]string, n)
+	for i := 0; i < n; i++ {
+		strs[i], err = r.readString()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return strs, nil
+}
+
+// readDouble reads a double encoded as a string for the legacy sorted sets.
+func (r *Reader) readDouble() (float64, error) {
+	n, err := r.readByte()
+	if err != nil {
+		return 0, err
+	}
+	switch n {
+	case doubleNaN:
+		return math.NaN(), nil
+	case doublePositiveInf:
+		return math.Inf(1), nil
+	case doubleNegativeInf:
+		return math.Inf(-1), nil
+	}
+	buf, err := r.read(int(n))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(string(buf), 64)
+}
+
+// readValue reads a value of the specified type.
+func (r *Reader) readValue(t Type) (any, error) {
+	switch t {
+	case StringType:
+		return r.readString()
+	case ListType, SetType:
+		return r.readStrings()
+	case ZSetType, ZSet2Type:
+		n, err := r.readCount()
+		if err != nil {
+			return nil, err
+		}
+		mems := make([]*ZSetMember, n)
+		for i := 0; i < n; i++ {
+			mem, err := r.readString()
+			if err != nil {
+				return nil, err
+			}
+			var score float64
+			if t == ZSetType {
+				score, err = r.readDouble()
+			} else {
+				var buf []byte
+				buf, err = r.read(8)
+				if err == nil {
+					score = math.Float64frombits(binary.LittleEndian.Uint64(buf))
+				}
+			}
+			if err != nil {
+				return nil, err
+			}
+			mems[i] = &ZSetMember{Score: score, Member: mem}
+		}
+		return mems, nil
+	case HashType:
+		n, err := r.readCount()
+		if err != nil {
+			return nil, err
+		}
+		fields := make(map[string]string, n)
+		for i := 0; i < n; i++ {
+			field, err := r.readString()
+			if err != nil {
+				return nil, err
+			}
+			val, err := r.readString()
+			if err != nil {
+				return nil, err
+			}
+			fields[field] = val
+		}
+		return fields, nil
+	}
+	return nil, fmt.Errorf("%w type (%d)", ErrNotSupported, t)
+}
+
+// readChecksum reads and verifies the checksum at the end of file. The zero checksum means that the checksum is disabled.
+func (r *Reader) readChecksum() error {
+	expected := r.crc
+	buf := make([]byte, checksumBytes)
+	if _, err := io.ReadFull(r.r, buf); err != nil {
+		return err
+	}
+	crc := binary.LittleEndian.Uint64(buf)
+	if crc != 0 && crc != expected {
+		return fmt.Errorf("%w (%016X != %016X)", ErrChecksum, crc, expected)
+	}
+	return nil
+}
+
+// Next returns the next key entry. It returns io.EOF when all entries are read and the checksum is verified.
+// nolint: gocyclo
+func (r *Reader) Next() (*Entry, error) {
+	if r.done {
+		return nil, io.EOF
+	}
+	if r.version == 0 {
+		if err := r.readHeader(); err != nil {
+			return nil, err
+		}
+	}
+	var expireAt time.Time
+	for {
+		opcode, err := r.readByte()
+		if err != nil {
+			return nil, err
+		}
+		switch opcode {
+		case opcodeEOF:
+			r.done = true
+			if 5 <= r.version {
+				if err := r.readChecksum(); err != nil {
+					return nil, err
+				}
+			}
+			return nil, io.EOF
+		case opcodeSelectDB:
+			r.db, err = r.readCount()
+			if err != nil {
+				return nil, err
+			}
+		case opcodeResizeDB:
+			if _, err := r.readCount(); err != nil {
+				return nil, err
+			}
+			if _, err := r.readCount(); err != nil {
+				return nil, err
+			}
+		case opcodeAux:
+			key, err := r.readString()
+			if err != nil {
+				return nil, err
+			}
+			val, err := r.readString()
+			if err != nil {
+				return nil, err
+			}
+			r.aux[key] = val
+		case opcodeFunction2:
+			code, err := r.readString()
+			if err != nil {
+				return nil, err
+			}
+			r.functions = append(r.functions, code)
+		case opcodeExpireTimeMs:
+			buf, err := r.read(expireTimeMsBytes)
+			if err != nil {
+				return nil, err
+			}
+			expireAt = time.UnixMilli(int64(binary.LittleEndian.Uint64(buf)))
+		case opcodeExpireTime:
+			buf, err := r.read(expireTimeBytes)
+			if err != nil {
+				return nil, err
+			}
+			expireAt = time.Unix(int64(binary.LittleEndian.Uint32(buf)), 0)
+		case opcodeFreq:
+			if _, err := r.readByte(); err != nil {
+				return nil, err
+			}
+		case opcodeIdle:
+			if _, err := r.readCount(); err != nil {
+				return nil, err
+			}
+		case opcodeModuleAux:
+			return nil, fmt.Errorf("%w module auxiliary data", ErrNotSupported)
+		default:
+			t := Type(opcode)
+			key, err := r.readString()
+			if err != nil {
+				return nil, err
+			}
+			val, err := r.readValue(t)
+			if err != nil {
+				return nil, err
+			}
+			return &Entry{
+				DatabaseID: r.db,
+				Key:        key,
+				Type:       t,
+				Value:      val,
+				ExpireAt:   expireAt,
+			}, nil
+		}
+	}
+}
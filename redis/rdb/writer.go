@@ -0,0 +1,245 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// Writer represents a writer of RDB files.
+type Writer struct {
+	w             io.Writer
+	crc           uint64
+	db            int
+	headerWritten bool
+}
+
+// NewWriter returns a new RDB writer for the specified writer.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		w:             w,
+		crc:           0,
+		db:            -1,
+		headerWritten: false,
+	}
+}
+
+// write writes the specified bytes updating the checksum.
+func (w *Writer) write(p []byte) error {
+	w.crc = updateChecksum(w.crc, p)
+	_, err := w.w.Write(p)
+	return err
+}
+
+// writeByte writes the specified byte updating the checksum.
+func (w *Writer) writeByte(b byte) error {
+	return w.write([]byte{b})
+}
+
+// writeHeader writes the magic string and the version if they are not written yet.
+func (w *Writer) writeHeader() error {
+	if w.headerWritten {
+		return nil
+	}
+	w.headerWritten = true
+	return w.write([]byte(fmt.Sprintf("%s%04d", Magic, Version)))
+}
+
+// writeLength writes the specified length with the length encoding.
+func (w *Writer) writeLength(n uint64) error {
+	switch {
+	case n < (1 << 6):
+		return w.writeByte(byte(length6Bit<<6) | byte(n))
+	case n < (1 << 14):
+		return w.write([]byte{byte(length14Bit<<6) | byte(n>>8), byte(n)})
+	case n <= math.MaxUint32:
+		buf := make([]byte, 5)
+		buf[0] = length32Bit
+		binary.BigEndian.PutUint32(buf[1:], uint32(n))
+		return w.write(buf)
+	}
+	buf := make([]byte, 9)
+	buf[0] = length64Bit
+	binary.BigEndian.PutUint64(buf[1:], n)
+	return w.write(buf)
+}
+
+// writeString writes the specified string. The strings of the small integers are written as integers.
+func (w *Writer) writeString(str string) error {
+	if v, err := strconv.ParseInt(str, 10, 32); err == nil && strconv.FormatInt(v, 10) == str {
+		switch {
+		case math.MinInt8 <= v && v <= math.MaxInt8:
+			return w.write([]byte{byte(lengthEncoded<<6) | lengthEncodedInt8, byte(int8(v))})
+		case math.MinInt16 <= v && v <= math.MaxInt16:
+			buf := []byte{byte(lengthEncoded<<6) | lengthEncodedInt16, 0, 0}
+			binary.LittleEndian.PutUint16(buf[1:], uint16(int16(v)))
+			return w.write(buf)
+		default:
+			buf := []byte{byte(lengthEncoded<<6) | lengthEncodedInt32, 0, 0, 0, 0}
+			binary.LittleEndian.PutUint32(buf[1:], uint32(int32(v)))
+			return w.write(buf)
+		}
+	}
+	if err := w.writeLength(uint64(len(str))); err != nil {
+		return err
+	}
+	return w.write([]byte(str))
+}
+
+// writeStrings writes the specified strings with the length.
+func (w *Writer) writeStrings(strs []string) error {
+	if err := w.writeLength(uint64(len(strs))); err != nil {
+		return err
+	}
+	for _, str := range strs {
+		if err := w.writeString(str); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteAux writes the specified auxiliary field.
+func (w *Writer) WriteAux(key string, val string) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	if err := w.writeByte(opcodeAux); err != nil {
+		return err
+	}
+	if err := w.writeString(key); err != nil {
+		return err
+	}
+	return w.writeString(val)
+}
+
+// WriteFunction writes the specified function library code.
+func (w *Writer) WriteFunction(code string) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	if err := w.writeByte(opcodeFunction2); err != nil {
+		return err
+	}
+	return w.writeString(code)
+}
+
+// selectDB writes the database selector if the specified database is not selected.
+func (w *Writer) selectDB(id int) error {
+	if w.db == id {
+		return nil
+	}
+	w.db = id
+	if err := w.writeByte(opcodeSelectDB); err != nil {
+		return err
+	}
+	return w.writeLength(uint64(id))
+}
+
+// WriteEntry writes the specified key entry.
+func (w *Writer) WriteEntry(entry *Entry) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	if err := w.selectDB(entry.DatabaseID); err != nil {
+		return err
+	}
+	if entry.HasExpireAt() {
+		buf := make([]byte, 1+expireTimeMsBytes)
+		buf[0] = opcodeExpireTimeMs
+		binary.LittleEndian.PutUint64(buf[1:], uint64(entry.ExpireAt.UnixMilli()))
+		if err := w.write(buf); err != nil {
+			return err
+		}
+	}
+	t := entry.Type
+	if t == ZSetType {
+		t = ZSet2Type
+	}
+	if err := w.writeByte(byte(t)); err != nil {
+		return err
+	}
+	if err := w.writeString(entry.Key); err != nil {
+		return err
+	}
+	return w.writeValue(entry)
+}
+
+// writeValue writes the value of the specified key entry.
+func (w *Writer) writeValue(entry *Entry) error {
+	switch entry.Type {
+	case StringType:
+		if val, ok := entry.Value.(string); ok {
+			return w.writeString(val)
+		}
+	case ListType, SetType:
+		if vals, ok := entry.Value.([]string); ok {
+			return w.writeStrings(vals)
+		}
+	case ZSetType, ZSet2Type:
+		if mems, ok := entry.Value.([]*ZSetMember); ok {
+			if err := w.writeLength(uint64(len(mems))); err != nil {
+				return err
+			}
+			for _, mem := range mems {
+				if err := w.writeString(mem.Member); err != nil {
+					return err
+				}
+				buf := make([]byte, 8)
+				binary.LittleEndian.PutUint64(buf, math.Float64bits(mem.Score))
+				if err := w.write(buf); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case HashType:
+		if fields, ok := entry.Value.(map[string]string); ok {
+			if err := w.writeLength(uint64(len(fields))); err != nil {
+				return err
+			}
+			for field, val := range fields {
+				if err := w.writeString(field); err != nil {
+					return err
+				}
+				if err := w.writeString(val); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	default:
+		return fmt.Errorf("%w type (%d)", ErrNotSupported, entry.Type)
+	}
+	return fmt.Errorf("%w value (%s:%T)", ErrInvalid, entry.Key, entry.Value)
+}
+
+// Close writes the end of file marker and the checksum. Close does not close the underlying writer.
+func (w *Writer) Close() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	if err := w.writeByte(opcodeEOF); err != nil {
+		return err
+	}
+	buf := make([]byte, checksumBytes)
+	binary.LittleEndian.PutUint64(buf, w.crc)
+	_, err := w.w.Write(buf)
+	return err
+}
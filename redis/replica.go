@@ -0,0 +1,430 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/cybergarage/go-logger/log"
+	"github.com/cybergarage/go-redis/redis/client"
+	"github.com/cybergarage/go-redis/redis/proto"
+)
+
+const (
+	replicaRetryInterval = time.Second
+	replicaAckInterval   = time.Second
+)
+
+// replicaLinkState represents a state of the link from a replica to the master.
+type replicaLinkState int32
+
+const (
+	replicaLinkConnect replicaLinkState = iota
+	replicaLinkConnecting
+	replicaLinkHandshake
+	replicaLinkSync
+	replicaLinkConnected
+)
+
+// String returns the state name reported by ROLE command.
+func (state replicaLinkState) String() string {
+	switch state {
+	case replicaLinkConnect:
+		return "connect"
+	case replicaLinkConnecting:
+		return "connecting"
+	case replicaLinkHandshake:
+		return "handshake"
+	case replicaLinkSync:
+		return "sync"
+	case replicaLinkConnected:
+		return "connected"
+	}
+	return ""
+}
+
+// replicaLink represents a link from a replica to the master.
+type replicaLink struct {
+	host   string
+	port   int
+	state  atomic.Int32
+	lastIO atomic.Int64
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// State returns the current state of the link.
+func (link *replicaLink) State() replicaLinkState {
+	return replicaLinkState(link.state.Load())
+}
+
+// setState sets the current state of the link.
+func (link *replicaLink) setState(state replicaLinkState) {
+	link.state.Store(int32(state))
+}
+
+// touch records the last interaction with the master.
+func (link *replicaLink) touch() {
+	link.lastIO.Store(time.Now().UnixNano())
+}
+
+// LastIOSecondsAgo returns the seconds since the last interaction with the master.
+func (link *replicaLink) LastIOSecondsAgo() int64 {
+	lastIO := link.lastIO.Load()
+	if lastIO == 0 {
+		return -1
+	}
+	return int64(time.Since(time.Unix(0, lastIO)).Seconds())
+}
+
+// stop stops the link and waits for the termination.
+func (link *replicaLink) stop() {
+	link.cancel()
+	<-link.done
+}
+
+// startReplicaLink starts a link to the specified master which replicates the dataset in the background.
+func (server *Server) startReplicaLink(host string, port int) {
+	ctx, cancel := context.WithCancel(server.ctx)
+	link := &replicaLink{
+		host:   host,
+		port:   port,
+		state:  atomic.Int32{},
+		lastIO: atomic.Int64{},
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	repl := server.replication
+	repl.Lock()
+	repl.link = link
+	repl.Unlock()
+	go server.runReplicaLink(ctx, link)
+}
+
+// restoreReplicaLink starts the link to the master of the configuration when the server starts.
+func (server *Server) restoreReplicaLink() {
+	server.replicaOfMutex.Lock()
+	defer server.replicaOfMutex.Unlock()
+
+	host, port, ok := server.ConfigReplicaOf()
+	if !ok {
+		return
+	}
+	server.startReplicaLink(host, port)
+}
+
+// stopReplicaLink stops the link to the master keeping the replication state to continue after restarting.
+func (server *Server) stopReplicaLink() {
+	server.replicaOfMutex.Lock()
+	defer server.replicaOfMutex.Unlock()
+
+	repl := server.replication
+	repl.Lock()
+	link := repl.link
+	repl.Unlock()
+	if link != nil {
+		link.stop()
+	}
+}
+
+// isMasterLinkUp returns true if the server is a replica which is connected to the master.
+func (server *Server) isMasterLinkUp() bool {
+	repl := server.replication
+	repl.Lock()
+	defer repl.Unlock()
+	return repl.link != nil && repl.link.State() == replicaLinkConnected
+}
+
+// listeningPort returns the TCP port which the server is listening on.
+func (server *Server) listeningPort() int {
+	if port := server.ConfigPort(); 0 < port {
+		return port
+	}
+	for _, addr := range server.ListenAddrs() {
+		if _, portStr, err := net.SplitHostPort(addr); err == nil {
+			if port, err := strconv.Atoi(portStr); err == nil {
+				return port
+			}
+		}
+	}
+	return 0
+}
+
+// runReplicaLink synchronizes with the master until the link is stopped, reconnecting on failures.
+func (server *Server) runReplicaLink(ctx context.Context, link *replicaLink) {
+	defer close(link.done)
+	for {
+		err := server.syncWithMaster(ctx, link)
+		link.setState(replicaLinkConnect)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Warnf("%s/%s replication with %s failed (%s)", PackageName, Version, masterAddr(link.host, link.port), err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(replicaRetryInterval):
+		}
+	}
+}
+
+// masterAddr returns the address of the specified master.
+func masterAddr(host string, port int) string {
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// syncWithMaster connects to the master, synchronizes the dataset and applies the replication stream.
+func (server *Server) syncWithMaster(ctx context.Context, link *replicaLink) error {
+	link.setState(replicaLinkConnecting)
+	cli := client.NewClient()
+	if err := cli.Open(masterAddr(link.host, link.port)); err != nil {
+		return err
+	}
+	defer cli.Close()
+	stopClose := context.AfterFunc(ctx, func() { cli.Close() })
+	defer stopClose()
+
+	timeout := time.Duration(server.ConfigReplTimeout()) * time.Second
+	if err := cli.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+
+	link.setState(replicaLinkHandshake)
+	link.touch()
+	if passwd, ok := server.ConfigMasterAuth(); ok {
+		if _, err := cli.Do("AUTH", passwd); err != nil {
+			return err
+		}
+	}
+	handshakes := [][]string{
+		{"PING"},
+		{"REPLCONF", "listening-port", strconv.Itoa(server.listeningPort())},
+		{"REPLCONF", "capa", "psync2"},
+	}
+	for _, args := range handshakes {
+		if _, err := cli.Do(args...); err != nil {
+			return err
+		}
+	}
+
+	repl := server.replication
+	repl.Lock()
+	replID, offset := repl.replID, repl.offset+1
+	repl.Unlock()
+	msg, err := cli.Do("PSYNC", replID, strconv.FormatInt(offset, 10))
+	if err != nil {
+		return err
+	}
+	reply, err := msg.String()
+	if err != nil {
+		return err
+	}
+	fields := strings.Fields(reply)
+	switch {
+	case len(fields) == 3 && strings.ToUpper(fields[0]) == "FULLRESYNC":
+		masterOffset, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return err
+		}
+		link.setState(replicaLinkSync)
+		if err := server.fullSyncWithMaster(cli, fields[1], masterOffset); err != nil {
+			return err
+		}
+	case 1 <= len(fields) && strings.ToUpper(fields[0]) == "CONTINUE":
+		newReplID := ""
+		if 2 <= len(fields) {
+			newReplID = fields[1]
+		}
+		server.continueWithMaster(newReplID)
+	default:
+		return fmt.Errorf("%w PSYNC reply (%s)", ErrInvalid, reply)
+	}
+
+	return server.streamFromMaster(ctx, link, cli, timeout)
+}
+
+// fullSyncWithMaster loads the RDB snapshot from the master and starts a new replication history of the master.
+func (server *Server) fullSyncWithMaster(cli *client.Client, replID string, offset int64) error {
+	line := ""
+	var err error
+	for len(line) == 0 {
+		// Skips the newlines which the masters send to keep the connection while preparing the snapshot.
+		line, err = cli.ReadLine()
+		if err != nil {
+			return err
+		}
+	}
+	if line[0] != '$' {
+		return fmt.Errorf("%w snapshot header (%s)", ErrInvalid, line)
+	}
+	size, err := strconv.ParseInt(line[1:], 10, 64)
+	if err != nil {
+		return err
+	}
+
+	snapshot := io.LimitReader(cli, size)
+	server.writeMutex.Lock()
+	err = server.loadSnapshot(snapshot)
+	server.writeMutex.Unlock()
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(io.Discard, snapshot); err != nil {
+		return err
+	}
+
+	repl := server.replication
+	repl.Lock()
+	defer repl.Unlock()
+	repl.replID = replID
+	repl.replID2 = strings.Repeat("0", ReplicationIDLength)
+	repl.offset = offset
+	repl.secondOffset = -1
+	repl.backlog = newReplicationBacklog(server.ConfigReplBacklogSize(), offset)
+	repl.masterDB = 0
+	repl.disconnectReplicasLocked()
+	log.Infof("%s/%s full resynchronization completed (%d bytes)", PackageName, Version, size)
+	return nil
+}
+
+// continueWithMaster continues the replication history of the master with the specified new replication ID if any.
+func (server *Server) continueWithMaster(replID string) {
+	repl := server.replication
+	repl.Lock()
+	defer repl.Unlock()
+	if 0 < len(replID) && replID != repl.replID {
+		repl.shiftReplicationIDLocked()
+		repl.replID = replID
+		repl.disconnectReplicasLocked()
+	}
+	if repl.backlog == nil {
+		repl.backlog = newReplicationBacklog(server.ConfigReplBacklogSize(), repl.offset)
+	}
+	log.Infof("%s/%s partial resynchronization completed (%d)", PackageName, Version, repl.offset)
+}
+
+// sendReplicaAck sends the current replication offset to the master.
+func (server *Server) sendReplicaAck(cli *client.Client) error {
+	return cli.Send("REPLCONF", "ACK", strconv.FormatInt(server.ReplicationOffset(), 10))
+}
+
+// streamFromMaster applies the replication stream from the master until the link is broken.
+func (server *Server) streamFromMaster(ctx context.Context, link *replicaLink, cli *client.Client, timeout time.Duration) error {
+	conn := newConnWith(cli.NetConn())
+	conn.SetClientType(MasterClient)
+	conn.SetAuthrized(true)
+	conn.setContext(ctx)
+	repl := server.replication
+	repl.Lock()
+	conn.SetDatabase(repl.masterDB)
+	repl.Unlock()
+
+	link.setState(replicaLinkConnected)
+	link.touch()
+	log.Infof("%s/%s connected to master %s", PackageName, Version, masterAddr(link.host, link.port))
+
+	ackCtx, ackCancel := context.WithCancel(ctx)
+	defer ackCancel()
+	go func() {
+		ticker := time.NewTicker(replicaAckInterval)
+		defer ticker.Stop()
+		for {
+			if err := server.sendReplicaAck(cli); err != nil {
+				return
+			}
+			select {
+			case <-ackCtx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	for {
+		if err := cli.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+			return err
+		}
+		msg, err := cli.Receive()
+		if err != nil {
+			return err
+		}
+		link.touch()
+		stream, err := msg.RESPBytes()
+		if err != nil {
+			return err
+		}
+		array, err := msg.Array()
+		if err != nil || array.Size() == 0 {
+			server.feedReplicationStream(conn, stream)
+			continue
+		}
+		if isReplConfGetAck(array) {
+			if err := server.sendReplicaAck(cli); err != nil {
+				return err
+			}
+			server.feedReplicationStream(conn, stream)
+			continue
+		}
+		server.applyMasterCommand(conn, array, stream)
+	}
+}
+
+// isReplConfGetAck returns true if the specified command is REPLCONF GETACK.
+func isReplConfGetAck(array *proto.Array) bool {
+	msgs := array.PeekMessages()
+	if len(msgs) < 2 {
+		return false
+	}
+	cmd, _ := msgs[0].String()
+	subcmd, _ := msgs[1].String()
+	return strings.EqualFold(cmd, "REPLCONF") && strings.EqualFold(subcmd, "GETACK")
+}
+
+// applyMasterCommand executes the specified command of the replication stream and forwards it to the sub-replicas.
+// The command is executed without the middlewares which must not veto the writes of the master.
+func (server *Server) applyMasterCommand(conn *Conn, array *proto.Array, stream []byte) {
+	server.writeMutex.Lock()
+	defer server.writeMutex.Unlock()
+
+	cmd, err := array.NextString()
+	if err == nil {
+		span := server.Tracer.StartSpan(PackageName)
+		conn.SetSpanContext(span)
+		msg, err := server.executeCommand(conn, cmd, array)
+		span.Span().Finish()
+		if isFailedCommandResult(msg, err) {
+			log.Warnf("%s/%s replicated command %s failed", PackageName, Version, cmd)
+		}
+	}
+	server.feedReplicationStream(conn, stream)
+}
+
+// feedReplicationStream forwards the specified bytes of the replication stream of the master.
+func (server *Server) feedReplicationStream(conn *Conn, stream []byte) {
+	repl := server.replication
+	repl.Lock()
+	defer repl.Unlock()
+	repl.feedLocked(stream)
+	repl.masterDB = conn.Database()
+}
@@ -0,0 +1,609 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cybergarage/go-logger/log"
+	"github.com/cybergarage/go-redis/redis/rdb"
+)
+
+const (
+	// ReplicationIDLength is the length of the replication IDs.
+	ReplicationIDLength = 40
+	// MasterRole is the role of the masters.
+	MasterRole = "master"
+	// ReplicaRole is the role of the replicas which is reported as "slave" for compatibility.
+	ReplicaRole = "slave"
+)
+
+// replicaInfo represents a replica connected to the master.
+type replicaInfo struct {
+	conn      *Conn
+	ip        string
+	port      int
+	online    bool
+	ackOffset int64
+	ackTime   time.Time
+}
+
+// replication represents the replication state of the server.
+type replication struct {
+	sync.Mutex
+	replID       string
+	replID2      string
+	offset       int64
+	secondOffset int64
+	backlog      *replicationBacklog
+	selectedDB   DatabaseID
+	masterDB     DatabaseID
+	replicas     []*replicaInfo
+	acked        chan struct{}
+	link         *replicaLink
+	lastPing     time.Time
+}
+
+// newReplication returns a new replication state of a master.
+func newReplication() *replication {
+	return &replication{
+		Mutex:        sync.Mutex{},
+		replID:       newRunID(),
+		replID2:      strings.Repeat("0", ReplicationIDLength),
+		offset:       0,
+		secondOffset: -1,
+		backlog:      nil,
+		selectedDB:   -1,
+		masterDB:     0,
+		replicas:     []*replicaInfo{},
+		acked:        make(chan struct{}),
+		link:         nil,
+		lastPing:     time.Time{},
+	}
+}
+
+// shiftReplicationIDLocked starts a new replication history keeping the current replication ID as the secondary ID,
+// so that the replicas of the previous master can continue with a partial resynchronization.
+func (repl *replication) shiftReplicationIDLocked() {
+	repl.replID2 = repl.replID
+	repl.secondOffset = repl.offset + 1
+	repl.replID = newRunID()
+}
+
+// feedLocked appends the specified bytes to the replication stream and sends them to the online replicas.
+func (repl *replication) feedLocked(p []byte) {
+	if repl.backlog == nil {
+		return
+	}
+	repl.backlog.Write(p)
+	repl.offset += int64(len(p))
+	for _, replica := range repl.replicas {
+		if !replica.online {
+			continue
+		}
+		if _, err := replica.conn.Write(p); err != nil {
+			log.Warnf("%s/%s (%s) replica write failed (%s)", PackageName, Version, replica.conn.RemoteAddr().String(), err.Error())
+		}
+	}
+}
+
+// lookupReplicaLocked returns the replica of the specified connection.
+func (repl *replication) lookupReplicaLocked(conn *Conn) (*replicaInfo, bool) {
+	for _, replica := range repl.replicas {
+		if replica.conn == conn {
+			return replica, true
+		}
+	}
+	return nil, false
+}
+
+// replicaLocked returns the replica of the specified connection, adding it if it is not added yet.
+func (repl *replication) replicaLocked(conn *Conn) *replicaInfo {
+	if replica, ok := repl.lookupReplicaLocked(conn); ok {
+		return replica
+	}
+	replica := &replicaInfo{
+		conn:      conn,
+		ip:        "",
+		port:      0,
+		online:    false,
+		ackOffset: 0,
+		ackTime:   time.Time{},
+	}
+	if host, _, err := net.SplitHostPort(conn.RemoteAddr().String()); err == nil {
+		replica.ip = host
+	}
+	repl.replicas = append(repl.replicas, replica)
+	return replica
+}
+
+// onlineReplicasLocked returns the replicas which have completed the synchronization.
+func (repl *replication) onlineReplicasLocked() []*replicaInfo {
+	replicas := []*replicaInfo{}
+	for _, replica := range repl.replicas {
+		if replica.online {
+			replicas = append(replicas, replica)
+		}
+	}
+	return replicas
+}
+
+// ackedReplicasLocked returns the number of the replicas which have acknowledged the specified offset.
+func (repl *replication) ackedReplicasLocked(offset int64) int {
+	cnt := 0
+	for _, replica := range repl.onlineReplicasLocked() {
+		if offset <= replica.ackOffset {
+			cnt++
+		}
+	}
+	return cnt
+}
+
+// newCommandBytes returns the RESP bytes of the specified command and arguments.
+func newCommandBytes(cmd string, argMsgs []*Message) []byte {
+	msg := NewArrayMessage()
+	msg.Append(NewBulkMessage(cmd))
+	for _, argMsg := range argMsgs {
+		msg.Append(argMsg)
+	}
+	b, err := msg.RESPBytes()
+	if err != nil {
+		log.Error(err)
+	}
+	return b
+}
+
+// newStringCommandBytes returns the RESP bytes of the specified command strings.
+func newStringCommandBytes(cmd string, args ...string) []byte {
+	argMsgs := make([]*Message, len(args))
+	for n, arg := range args {
+		argMsgs[n] = NewBulkMessage(arg)
+	}
+	return newCommandBytes(cmd, argMsgs)
+}
+
+// IsReplica returns true if the server is a replica of a master.
+func (server *Server) IsReplica() bool {
+	server.replication.Lock()
+	defer server.replication.Unlock()
+	return server.replication.link != nil
+}
+
+// ReplicationOffset returns the current replication offset.
+func (server *Server) ReplicationOffset() int64 {
+	server.replication.Lock()
+	defer server.replication.Unlock()
+	return server.replication.offset
+}
+
+// isReadOnlyReplica returns true if the write commands from the specified connection should be rejected.
+func (server *Server) isReadOnlyReplica(conn *Conn) bool {
+	if conn.ClientType() == MasterClient || !server.IsReplica() {
+		return false
+	}
+	return server.ConfigReplicaReadOnly()
+}
+
+// propagate appends the specified write command executed by the specified connection to the replication stream.
+// The commands are not propagated by the replicas which forward the replication stream of the master as it is.
+func (server *Server) propagate(conn *Conn, cmd string, argMsgs []*Message) {
+	repl := server.replication
+	repl.Lock()
+	defer repl.Unlock()
+	if repl.backlog == nil || repl.link != nil {
+		return
+	}
+	var buf bytes.Buffer
+	if db := conn.Database(); db != repl.selectedDB {
+		buf.Write(newStringCommandBytes("SELECT", strconv.Itoa(db)))
+		repl.selectedDB = db
+	}
+	buf.Write(newCommandBytes(cmd, argMsgs))
+	repl.feedLocked(buf.Bytes())
+}
+
+// writeSnapshot writes the function libraries and the dataset of the user command handler as an RDB snapshot.
+func (server *Server) writeSnapshot(w io.Writer) error {
+	handler, ok := server.userCommandHandler.(SnapshotHandler)
+	if !ok {
+		return fmt.Errorf("snapshot is %w", ErrNotSupported)
+	}
+	rw := rdb.NewWriter(w)
+	if err := rw.WriteAux("go-redis-ver", Version); err != nil {
+		return err
+	}
+	for _, code := range server.libraries.codes() {
+		if err := rw.WriteFunction(code); err != nil {
+			return err
+		}
+	}
+	if err := handler.WriteSnapshot(rw); err != nil {
+		return err
+	}
+	return rw.Close()
+}
+
+// loadSnapshot replaces the dataset of the user command handler and the function libraries with the specified RDB snapshot.
+func (server *Server) loadSnapshot(r io.Reader) error {
+	handler, ok := server.userCommandHandler.(SnapshotHandler)
+	if !ok {
+		return fmt.Errorf("snapshot is %w", ErrNotSupported)
+	}
+	rr := rdb.NewReader(r)
+	if err := handler.LoadSnapshot(rr); err != nil {
+		return err
+	}
+	libs, err := newLuaLibraries(rr.Functions())
+	if err != nil {
+		return err
+	}
+	return server.libraries.restore(libs, FunctionRestoreFlush)
+}
+
+// replConf handles REPLCONF command from the replicas. REPLCONF ACK has no reply.
+func (server *Server) replConf(conn *Conn, params []string) (*Message, error) {
+	if len(params)%2 != 0 {
+		return nil, ErrSyntax
+	}
+	repl := server.replication
+	repl.Lock()
+	defer repl.Unlock()
+	replica := repl.replicaLocked(conn)
+	for n := 0; n < len(params); n += 2 {
+		key, val := strings.ToLower(params[n]), params[n+1]
+		switch key {
+		case "listening-port":
+			port, err := strconv.Atoi(val)
+			if err != nil {
+				return nil, ErrNotInteger
+			}
+			replica.port = port
+		case "ip-address":
+			replica.ip = val
+		case "capa":
+		case "ack":
+			offset, err := strconv.ParseInt(val, 10, 64)
+			if err != nil {
+				return nil, ErrNoReply
+			}
+			if replica.ackOffset < offset {
+				replica.ackOffset = offset
+			}
+			replica.ackTime = time.Now()
+			close(repl.acked)
+			repl.acked = make(chan struct{})
+			return nil, ErrNoReply
+		case "getack":
+			return nil, ErrNoReply
+		default:
+			return nil, NewError(ErrorPrefix, fmt.Sprintf("Unrecognized REPLCONF option: %s", params[n]))
+		}
+	}
+	return NewOKMessage(), nil
+}
+
+// psync handles PSYNC command from the replicas. It continues the replication stream from the backlog if possible,
+// otherwise it transfers an RDB snapshot of the dataset. The replies are written directly to the connection.
+func (server *Server) psync(conn *Conn, replID string, offset int64) (*Message, error) {
+	if server.IsReplica() && !server.isMasterLinkUp() {
+		return nil, NewError(ErrorPrefix, "Can't SYNC while not connected with my master")
+	}
+
+	server.writeMutex.Lock()
+	defer server.writeMutex.Unlock()
+
+	repl := server.replication
+	repl.Lock()
+	defer repl.Unlock()
+
+	replica := repl.replicaLocked(conn)
+
+	isContinuable := repl.backlog != nil &&
+		(replID == repl.replID || (replID == repl.replID2 && offset <= repl.secondOffset))
+	if isContinuable {
+		if stream, ok := repl.backlog.ReadFrom(offset); ok {
+			conn.SetClientType(ReplicaClient)
+			if _, err := conn.Write([]byte("+CONTINUE " + repl.replID + "\r\n")); err != nil {
+				return nil, err
+			}
+			if _, err := conn.writeBulk(stream); err != nil {
+				return nil, err
+			}
+			replica.online = true
+			replica.ackOffset = offset - 1
+			server.serverStats.syncPartialOK.Add(1)
+			log.Infof("%s/%s (%s) partial resynchronization accepted (%d)", PackageName, Version, conn.RemoteAddr().String(), offset)
+			return nil, ErrNoReply
+		}
+	}
+
+	if replID != "?" {
+		server.serverStats.syncPartialErr.Add(1)
+	}
+
+	var snapshot bytes.Buffer
+	if err := server.writeSnapshot(&snapshot); err != nil {
+		return nil, err
+	}
+	if repl.backlog == nil {
+		repl.backlog = newReplicationBacklog(server.ConfigReplBacklogSize(), repl.offset)
+	}
+	repl.selectedDB = -1
+
+	header := fmt.Sprintf("+FULLRESYNC %s %d\r\n$%d\r\n", repl.replID, repl.offset, snapshot.Len())
+	if _, err := conn.Write([]byte(header)); err != nil {
+		return nil, err
+	}
+	// The snapshot is not counted against the output buffer limit of the replicas not to disconnect them
+	// by the untransmitted snapshot which is larger than the limit.
+	if _, err := conn.writeBulk(snapshot.Bytes()); err != nil {
+		return nil, err
+	}
+	conn.SetClientType(ReplicaClient)
+	replica.online = true
+	replica.ackOffset = repl.offset
+	server.serverStats.syncFull.Add(1)
+	log.Infof("%s/%s (%s) full resynchronization started (%d bytes)", PackageName, Version, conn.RemoteAddr().String(), snapshot.Len())
+	return nil, ErrNoReply
+}
+
+// removeReplica removes the specified connection from the replicas if it is a replica.
+func (server *Server) removeReplica(conn *Conn) {
+	repl := server.replication
+	repl.Lock()
+	defer repl.Unlock()
+	for n, replica := range repl.replicas {
+		if replica.conn == conn {
+			repl.replicas = append(repl.replicas[:n], repl.replicas[n+1:]...)
+			if replica.online {
+				log.Infof("%s/%s (%s) replica disconnected", PackageName, Version, conn.RemoteAddr().String())
+			}
+			return
+		}
+	}
+}
+
+// disconnectReplicasLocked closes the connections of all replicas to make them resynchronize.
+func (repl *replication) disconnectReplicasLocked() {
+	for _, replica := range repl.replicas {
+		replica.conn.Close()
+	}
+	repl.replicas = []*replicaInfo{}
+}
+
+// ReplicaOf makes the server a replica of the specified master.
+func (server *Server) ReplicaOf(conn *Conn, host string, port int) (*Message, error) {
+	server.replicaOfMutex.Lock()
+	defer server.replicaOfMutex.Unlock()
+
+	repl := server.replication
+	repl.Lock()
+	link := repl.link
+	repl.Unlock()
+
+	if link != nil {
+		if link.host == host && link.port == port {
+			return NewStringMessage("OK Already connected to specified master"), nil
+		}
+		link.stop()
+	}
+
+	server.SetReplicaOf(host, port)
+	server.startReplicaLink(host, port)
+	log.Infof("%s/%s replica of %s", PackageName, Version, net.JoinHostPort(host, strconv.Itoa(port)))
+	return NewOKMessage(), nil
+}
+
+// ReplicaOfNoOne makes the server a master.
+func (server *Server) ReplicaOfNoOne(conn *Conn) (*Message, error) {
+	server.replicaOfMutex.Lock()
+	defer server.replicaOfMutex.Unlock()
+
+	repl := server.replication
+	repl.Lock()
+	link := repl.link
+	repl.Unlock()
+
+	if link == nil {
+		return NewOKMessage(), nil
+	}
+	link.stop()
+
+	repl.Lock()
+	repl.link = nil
+	repl.shiftReplicationIDLocked()
+	repl.selectedDB = -1
+	repl.Unlock()
+
+	server.SetReplicaOf("", 0)
+	log.Infof("%s/%s master mode enabled", PackageName, Version)
+	return NewOKMessage(), nil
+}
+
+// Role returns the role of the server with the replication state.
+func (server *Server) Role(conn *Conn) (*Message, error) {
+	if server.IsSentinelEnabled() {
+		return server.sentinelRole()
+	}
+
+	repl := server.replication
+	repl.Lock()
+	defer repl.Unlock()
+
+	msg := NewArrayMessage()
+	if link := repl.link; link != nil {
+		msg.Append(NewBulkMessage(ReplicaRole))
+		msg.Append(NewBulkMessage(link.host))
+		msg.Append(NewIntegerMessage(link.port))
+		msg.Append(NewBulkMessage(link.State().String()))
+		msg.Append(NewIntegerMessage(int(repl.offset)))
+		return msg, nil
+	}
+
+	msg.Append(NewBulkMessage(MasterRole))
+	msg.Append(NewIntegerMessage(int(repl.offset)))
+	replicasMsg := NewArrayMessage()
+	for _, replica := range repl.onlineReplicasLocked() {
+		replicaMsg := NewArrayMessage()
+		replicaMsg.Append(NewBulkMessage(replica.ip))
+		replicaMsg.Append(NewBulkMessage(strconv.Itoa(replica.port)))
+		replicaMsg.Append(NewBulkMessage(strconv.FormatInt(replica.ackOffset, 10)))
+		replicasMsg.Append(replicaMsg)
+	}
+	msg.Append(replicasMsg)
+	return msg, nil
+}
+
+// Wait blocks until the specified number of the replicas acknowledge the write commands before the call,
+// or the specified timeout is reached. Zero timeout blocks forever. It returns the number of the acknowledged replicas.
+func (server *Server) Wait(conn *Conn, numReplicas int, timeout time.Duration) (*Message, error) {
+	repl := server.replication
+	repl.Lock()
+	if repl.link != nil {
+		repl.Unlock()
+		return nil, ErrWaitReplica
+	}
+	offset := repl.offset
+	acked := repl.ackedReplicasLocked(offset)
+	if numReplicas <= acked || repl.backlog == nil {
+		repl.Unlock()
+		return NewIntegerMessage(acked), nil
+	}
+	repl.feedLocked(newStringCommandBytes("REPLCONF", "GETACK", "*"))
+	repl.Unlock()
+
+	var timeoutCh <-chan time.Time
+	if 0 < timeout {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutCh = timer.C
+	}
+	for {
+		repl.Lock()
+		acked = repl.ackedReplicasLocked(offset)
+		ackedCh := repl.acked
+		repl.Unlock()
+		if numReplicas <= acked {
+			return NewIntegerMessage(acked), nil
+		}
+		select {
+		case <-ackedCh:
+		case <-timeoutCh:
+			return NewIntegerMessage(acked), nil
+		case <-conn.CommandContext().Done():
+			return nil, conn.CommandContext().Err()
+		}
+	}
+}
+
+// runReplicationCron pings the replicas periodically to let them detect the broken links.
+func (server *Server) runReplicationCron(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		period := time.Duration(server.ConfigReplPingReplicaPeriod()) * time.Second
+		repl := server.replication
+		repl.Lock()
+		if repl.link == nil && 0 < len(repl.onlineReplicasLocked()) && period <= time.Since(repl.lastPing) {
+			repl.feedLocked(newStringCommandBytes("PING"))
+			repl.lastPing = time.Now()
+		}
+		if repl.backlog != nil {
+			repl.backlog.Resize(server.ConfigReplBacklogSize())
+		}
+		repl.Unlock()
+	}
+}
+
+// replicationInfo returns the lines of INFO replication section.
+func (server *Server) replicationInfo() []string {
+	repl := server.replication
+	repl.Lock()
+	defer repl.Unlock()
+
+	lines := []string{}
+	if link := repl.link; link != nil {
+		linkStatus := "down"
+		if link.State() == replicaLinkConnected {
+			linkStatus = "up"
+		}
+		lines = append(lines,
+			"role:"+ReplicaRole,
+			"master_host:"+link.host,
+			"master_port:"+strconv.Itoa(link.port),
+			"master_link_status:"+linkStatus,
+			"master_last_io_seconds_ago:"+strconv.FormatInt(link.LastIOSecondsAgo(), 10),
+			"master_sync_in_progress:"+formatBit(link.State() == replicaLinkSync),
+			"slave_read_repl_offset:"+strconv.FormatInt(repl.offset, 10),
+			"slave_repl_offset:"+strconv.FormatInt(repl.offset, 10),
+			"slave_read_only:"+formatBit(server.ConfigReplicaReadOnly()),
+		)
+	} else {
+		lines = append(lines, "role:"+MasterRole)
+	}
+
+	replicas := repl.onlineReplicasLocked()
+	lines = append(lines, "connected_slaves:"+strconv.Itoa(len(replicas)))
+	for n, replica := range replicas {
+		lag := int64(0)
+		if !replica.ackTime.IsZero() {
+			lag = int64(time.Since(replica.ackTime).Seconds())
+		}
+		lines = append(lines, fmt.Sprintf("slave%d:ip=%s,port=%d,state=online,offset=%d,lag=%d",
+			n, replica.ip, replica.port, replica.ackOffset, lag))
+	}
+
+	backlogActive := repl.backlog != nil
+	backlogSize := server.ConfigReplBacklogSize()
+	backlogFirstByteOffset := int64(0)
+	backlogHistLen := int64(0)
+	if backlogActive {
+		backlogSize = repl.backlog.Size()
+		backlogFirstByteOffset = repl.backlog.FirstByteOffset()
+		backlogHistLen = repl.backlog.HistLen()
+	}
+	lines = append(lines,
+		"master_replid:"+repl.replID,
+		"master_replid2:"+repl.replID2,
+		"master_repl_offset:"+strconv.FormatInt(repl.offset, 10),
+		"second_repl_offset:"+strconv.FormatInt(repl.secondOffset, 10),
+		"repl_backlog_active:"+formatBit(backlogActive),
+		"repl_backlog_size:"+strconv.FormatInt(backlogSize, 10),
+		"repl_backlog_first_byte_offset:"+strconv.FormatInt(backlogFirstByteOffset, 10),
+		"repl_backlog_histlen:"+strconv.FormatInt(backlogHistLen, 10),
+	)
+	return lines
+}
+
+// formatBit returns 1 if the specified value is true, otherwise 0.
+func formatBit(val bool) string {
+	if val {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,99 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+// replicationBacklog represents a ring buffer of the latest replication stream for the partial resynchronizations.
+type replicationBacklog struct {
+	buf     []byte
+	head    int
+	histLen int
+	offset  int64
+}
+
+// newReplicationBacklog returns a new replication backlog of the specified size which starts after the specified replication offset.
+func newReplicationBacklog(size int64, offset int64) *replicationBacklog {
+	if size < 1 {
+		size = 1
+	}
+	return &replicationBacklog{
+		buf:     make([]byte, size),
+		head:    0,
+		histLen: 0,
+		offset:  offset,
+	}
+}
+
+// Size returns the size of the backlog.
+func (backlog *replicationBacklog) Size() int64 {
+	return int64(len(backlog.buf))
+}
+
+// HistLen returns the number of the bytes in the backlog.
+func (backlog *replicationBacklog) HistLen() int64 {
+	return int64(backlog.histLen)
+}
+
+// FirstByteOffset returns the replication offset of the first byte in the backlog.
+func (backlog *replicationBacklog) FirstByteOffset() int64 {
+	return backlog.offset - int64(backlog.histLen) + 1
+}
+
+// Write appends the specified bytes of the replication stream.
+func (backlog *replicationBacklog) Write(p []byte) {
+	backlog.offset += int64(len(p))
+	size := len(backlog.buf)
+	if size <= len(p) {
+		p = p[len(p)-size:]
+	}
+	for 0 < len(p) {
+		n := copy(backlog.buf[backlog.head:], p)
+		backlog.head = (backlog.head + n) % size
+		p = p[n:]
+		backlog.histLen += n
+	}
+	if size < backlog.histLen {
+		backlog.histLen = size
+	}
+}
+
+// ReadFrom returns the bytes from the specified replication offset to the latest byte.
+// It returns false if the offset is not in the backlog.
+func (backlog *replicationBacklog) ReadFrom(offset int64) ([]byte, bool) {
+	first := backlog.FirstByteOffset()
+	if offset < first || backlog.offset+1 < offset {
+		return nil, false
+	}
+	n := int(backlog.offset + 1 - offset)
+	p := make([]byte, n)
+	size := len(backlog.buf)
+	start := (backlog.head - n + size) % size
+	for copied := 0; copied < n; {
+		m := copy(p[copied:], backlog.buf[start:])
+		copied += m
+		start = (start + m) % size
+	}
+	return p, true
+}
+
+// Resize changes the size of the backlog keeping the latest bytes.
+func (backlog *replicationBacklog) Resize(size int64) {
+	if size < 1 || size == backlog.Size() {
+		return
+	}
+	latest, _ := backlog.ReadFrom(backlog.FirstByteOffset())
+	resized := newReplicationBacklog(size, backlog.offset-int64(len(latest)))
+	resized.Write(latest)
+	*backlog = *resized
+}
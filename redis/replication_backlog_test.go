@@ -0,0 +1,54 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReplicationBacklog(t *testing.T) {
+	backlog := newReplicationBacklog(8, 10)
+
+	backlog.Write([]byte("abcde"))
+	if backlog.FirstByteOffset() != 11 || backlog.HistLen() != 5 {
+		t.Errorf("%d %d", backlog.FirstByteOffset(), backlog.HistLen())
+	}
+	if p, ok := backlog.ReadFrom(13); !ok || !bytes.Equal(p, []byte("cde")) {
+		t.Errorf("%s != cde", p)
+	}
+
+	backlog.Write([]byte("fghij"))
+	if backlog.FirstByteOffset() != 13 || backlog.HistLen() != 8 {
+		t.Errorf("%d %d", backlog.FirstByteOffset(), backlog.HistLen())
+	}
+	if p, ok := backlog.ReadFrom(13); !ok || !bytes.Equal(p, []byte("cdefghij")) {
+		t.Errorf("%s != cdefghij", p)
+	}
+	if p, ok := backlog.ReadFrom(21); !ok || len(p) != 0 {
+		t.Errorf("%s is not empty", p)
+	}
+	if _, ok := backlog.ReadFrom(12); ok {
+		t.Errorf("offset 12 is out of the backlog")
+	}
+	if _, ok := backlog.ReadFrom(22); ok {
+		t.Errorf("offset 22 is out of the backlog")
+	}
+
+	backlog.Resize(4)
+	if p, ok := backlog.ReadFrom(backlog.FirstByteOffset()); !ok || !bytes.Equal(p, []byte("ghij")) {
+		t.Errorf("%s != ghij", p)
+	}
+}
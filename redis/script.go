@@ -0,0 +1,590 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"context"
+	"crypto/sha1" // nolint: gosec
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cybergarage/go-logger/log"
+	"github.com/cybergarage/go-redis/redis/proto"
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+const (
+	scriptChunkName   = "@user_script"
+	scriptShebang     = "#!"
+	scriptLuaShebang  = "#!lua"
+	scriptFlagsPrefix = "flags="
+	scriptNoWrites    = "no-writes"
+)
+
+// scriptFlags is the script flags which are accepted in the shebang of the scripts.
+var scriptFlags = map[string]bool{
+	scriptNoWrites:          true,
+	"allow-oom":             true,
+	"allow-stale":           true,
+	"no-cluster":            true,
+	"allow-cross-slot-keys": true,
+}
+
+// luaScript represents a compiled Lua script.
+type luaScript struct {
+	sha      string
+	body     string
+	proto    *lua.FunctionProto
+	noWrites bool
+}
+
+// scriptSHA returns the SHA1 digest of the specified script body in lower case hex.
+func scriptSHA(body string) string {
+	sum := sha1.Sum([]byte(body)) // nolint: gosec
+	return hex.EncodeToString(sum[:])
+}
+
+// parseScriptFlags parses the shebang of the specified script body, and returns the body without the shebang and the flags.
+func parseScriptFlags(body string) (string, []string, error) {
+	if !strings.HasPrefix(body, scriptShebang) {
+		return body, []string{}, nil
+	}
+	line, _, _ := strings.Cut(body, "\n")
+	fields := strings.Fields(line)
+	if fields[0] != scriptLuaShebang {
+		return "", nil, fmt.Errorf("Unexpected engine in script shebang: %s", strings.TrimPrefix(fields[0], scriptShebang))
+	}
+	flags := []string{}
+	for _, field := range fields[1:] {
+		if !strings.HasPrefix(field, scriptFlagsPrefix) {
+			return "", nil, fmt.Errorf("Unknown lua shebang option: %s", field)
+		}
+		for _, flag := range strings.Split(strings.TrimPrefix(field, scriptFlagsPrefix), ",") {
+			if len(flag) == 0 {
+				continue
+			}
+			if !scriptFlags[flag] {
+				return "", nil, fmt.Errorf("Unexpected flag in script shebang: %s", flag)
+			}
+			flags = append(flags, flag)
+		}
+	}
+	// Keeps the line numbers of the error messages.
+	return body[len(line):], flags, nil
+}
+
+// compileLua compiles the specified Lua source.
+func compileLua(source string, name string) (*lua.FunctionProto, error) {
+	chunk, err := parse.Parse(strings.NewReader(source), name)
+	if err != nil {
+		return nil, err
+	}
+	return lua.Compile(chunk, name)
+}
+
+// newLuaScript returns a new compiled script of the specified body.
+func newLuaScript(body string) (*luaScript, error) {
+	source, flags, err := parseScriptFlags(body)
+	if err != nil {
+		return nil, NewError(ErrorPrefix, err.Error())
+	}
+	proto, err := compileLua(source, scriptChunkName)
+	if err != nil {
+		return nil, NewError(ErrorPrefix, "Error compiling script (new function): "+err.Error())
+	}
+	script := &luaScript{
+		sha:      scriptSHA(body),
+		body:     body,
+		proto:    proto,
+		noWrites: false,
+	}
+	for _, flag := range flags {
+		if flag == scriptNoWrites {
+			script.noWrites = true
+		}
+	}
+	return script, nil
+}
+
+// scripting represents the script cache and the running script of the server.
+type scripting struct {
+	sync.Mutex
+	scripts map[string]*luaScript
+	running *scriptCall
+}
+
+// newScripting returns a new empty script cache.
+func newScripting() *scripting {
+	return &scripting{
+		Mutex:   sync.Mutex{},
+		scripts: map[string]*luaScript{},
+		running: nil,
+	}
+}
+
+// load compiles the specified script body and caches it.
+func (s *scripting) load(body string) (*luaScript, error) {
+	sha := scriptSHA(body)
+	s.Lock()
+	script, ok := s.scripts[sha]
+	s.Unlock()
+	if ok {
+		return script, nil
+	}
+	script, err := newLuaScript(body)
+	if err != nil {
+		return nil, err
+	}
+	s.Lock()
+	s.scripts[sha] = script
+	s.Unlock()
+	return script, nil
+}
+
+// lookup returns the cached script of the specified SHA1 digest.
+func (s *scripting) lookup(sha string) (*luaScript, bool) {
+	s.Lock()
+	defer s.Unlock()
+	script, ok := s.scripts[strings.ToLower(sha)]
+	return script, ok
+}
+
+// flush removes all cached scripts.
+func (s *scripting) flush() {
+	s.Lock()
+	defer s.Unlock()
+	s.scripts = map[string]*luaScript{}
+}
+
+// kill stops the running script unless the script has executed write commands.
+func (s *scripting) kill() error {
+	s.Lock()
+	defer s.Unlock()
+	if s.running == nil {
+		return ErrNotBusy
+	}
+	if s.running.wrote {
+		return ErrUnkillable
+	}
+	s.running.killed = true
+	s.running.cancel()
+	return nil
+}
+
+// scriptCall represents an execution of a script on a connection.
+type scriptCall struct {
+	server    *Server
+	conn      *Conn
+	name      string
+	command   []string
+	noWrites  bool
+	wrote     bool
+	killed    bool
+	startTime time.Time
+	ctx       context.Context
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// newScriptCommand returns the command line of a script call which is reported by FUNCTION STATS.
+func newScriptCommand(cmd string, name string, keys []string, args []string) []string {
+	command := append([]string{cmd, name, strconv.Itoa(len(keys))}, keys...)
+	return append(command, args...)
+}
+
+// runScript runs the specified function atomically as a script of the specified name on the specified connection.
+// Scripts which may write take the write lock to keep the order of the replication stream.
+func (server *Server) runScript(conn *Conn, name string, command []string, noWrites bool, run func(call *scriptCall) (*Message, error)) (*Message, error) {
+	if !noWrites {
+		server.writeMutex.Lock()
+		defer server.writeMutex.Unlock()
+	}
+	server.scriptMutex.Lock()
+	defer server.scriptMutex.Unlock()
+
+	ctx, cancel := context.WithCancel(conn.CommandContext())
+	defer cancel()
+	call := &scriptCall{
+		server:    server,
+		conn:      conn,
+		name:      name,
+		command:   command,
+		noWrites:  noWrites,
+		wrote:     false,
+		killed:    false,
+		startTime: time.Now(),
+		ctx:       ctx,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+
+	s := server.scripting
+	s.Lock()
+	s.running = call
+	s.Unlock()
+	conn.setScripting(true)
+	defer func() {
+		conn.setScripting(false)
+		s.Lock()
+		s.running = nil
+		s.Unlock()
+		close(call.done)
+	}()
+
+	return run(call)
+}
+
+// allowBusySubcommands is the subcommands which run without waiting for the running script such as SCRIPT KILL.
+var allowBusySubcommands = map[string]map[string]bool{
+	"FUNCTION": {"KILL": true, "STATS": true},
+	"SCRIPT":   {"KILL": true},
+}
+
+// isAllowBusyCommand returns true if the specified command has the allow-busy flag or is an allow-busy subcommand.
+func isAllowBusyCommand(info *CommandInfo, argMsgs []*Message) bool {
+	if info.HasFlag(AllowBusyFlag) {
+		return true
+	}
+	subcmds, ok := allowBusySubcommands[info.Name]
+	if !ok || len(argMsgs) == 0 {
+		return false
+	}
+	subcmd, err := argMsgs[0].String()
+	return err == nil && subcmds[strings.ToUpper(subcmd)]
+}
+
+// waitScript waits for the script running on another connection to finish. It returns ErrBusy if the script runs
+// longer than the busy reply threshold. The allow-busy commands and subcommands run without waiting.
+func (server *Server) waitScript(conn *Conn, info *CommandInfo, argMsgs []*Message) error {
+	if isAllowBusyCommand(info, argMsgs) || conn.IsScripting() || conn.ClientType() == MasterClient {
+		return nil
+	}
+	threshold := time.Duration(server.ConfigBusyReplyThreshold()) * time.Millisecond
+	for {
+		s := server.scripting
+		s.Lock()
+		running := s.running
+		s.Unlock()
+		if running == nil {
+			return nil
+		}
+		elapsed := time.Since(running.startTime)
+		if threshold <= elapsed {
+			return ErrBusy
+		}
+		timer := time.NewTimer(threshold - elapsed)
+		select {
+		case <-running.done:
+		case <-timer.C:
+		case <-conn.CommandContext().Done():
+			timer.Stop()
+			return conn.CommandContext().Err()
+		}
+		timer.Stop()
+	}
+}
+
+// evalScript runs the specified script with the specified keys and arguments.
+func (server *Server) evalScript(conn *Conn, script *luaScript, keys []string, args []string, readOnly bool) (*Message, error) {
+	cmd := "EVALSHA"
+	if readOnly {
+		cmd = "EVALSHA_RO"
+	}
+	command := newScriptCommand(cmd, script.sha, keys, args)
+	return server.runScript(conn, "f_"+script.sha, command, readOnly || script.noWrites, func(call *scriptCall) (*Message, error) {
+		L := call.newState()
+		defer L.Close()
+		L.SetGlobal("KEYS", newLuaStringTable(L, keys))
+		L.SetGlobal("ARGV", newLuaStringTable(L, args))
+		return call.pcall(L, L.NewFunctionFromProto(script.proto))
+	})
+}
+
+// newState returns a new Lua state with the sandboxed standard libraries and the redis library.
+func (call *scriptCall) newState() *lua.LState {
+	L := lua.NewState(lua.Options{ // nolint: exhaustruct
+		SkipOpenLibs: true,
+	})
+	for name, open := range map[string]lua.LGFunction{
+		lua.BaseLibName:   lua.OpenBase,
+		lua.TabLibName:    lua.OpenTable,
+		lua.StringLibName: lua.OpenString,
+		lua.MathLibName:   lua.OpenMath,
+	} {
+		L.Push(L.NewFunction(open))
+		L.Push(lua.LString(name))
+		L.Call(1, 0)
+	}
+	for _, name := range []string{"dofile", "loadfile", "print"} {
+		L.SetGlobal(name, lua.LNil)
+	}
+	L.SetGlobal("redis", call.newRedisLib(L))
+	L.SetContext(call.ctx)
+	return L
+}
+
+// newRedisLib returns the redis library table of the scripts.
+func (call *scriptCall) newRedisLib(L *lua.LState) *lua.LTable {
+	lib := L.NewTable()
+	L.SetFuncs(lib, map[string]lua.LGFunction{
+		"call": func(L *lua.LState) int {
+			return call.luaCall(L, true)
+		},
+		"pcall": func(L *lua.LState) int {
+			return call.luaCall(L, false)
+		},
+		"error_reply": func(L *lua.LState) int {
+			L.Push(newLuaReplyTable(L, "err", L.CheckString(1)))
+			return 1
+		},
+		"status_reply": func(L *lua.LState) int {
+			L.Push(newLuaReplyTable(L, "ok", L.CheckString(1)))
+			return 1
+		},
+		"sha1hex": func(L *lua.LState) int {
+			L.Push(lua.LString(scriptSHA(L.CheckString(1))))
+			return 1
+		},
+		"log": func(L *lua.LState) int {
+			level := L.CheckInt(1)
+			msgs := []string{}
+			for n := 2; n <= L.GetTop(); n++ {
+				msgs = append(msgs, L.ToStringMeta(L.Get(n)).String())
+			}
+			msg := strings.Join(msgs, " ")
+			switch level {
+			case scriptLogDebug, scriptLogVerbose:
+				log.Debugf("%s", msg)
+			case scriptLogNotice:
+				log.Infof("%s", msg)
+			default:
+				log.Warnf("%s", msg)
+			}
+			return 0
+		},
+	})
+	for name, level := range map[string]int{
+		"LOG_DEBUG":   scriptLogDebug,
+		"LOG_VERBOSE": scriptLogVerbose,
+		"LOG_NOTICE":  scriptLogNotice,
+		"LOG_WARNING": scriptLogWarning,
+	} {
+		lib.RawSetString(name, lua.LNumber(level))
+	}
+	return lib
+}
+
+const (
+	scriptLogDebug = iota
+	scriptLogVerbose
+	scriptLogNotice
+	scriptLogWarning
+)
+
+// luaCall executes the command of the arguments of redis.call or redis.pcall. redis.call raises the error replies
+// as Lua errors, and redis.pcall returns them as the error tables.
+func (call *scriptCall) luaCall(L *lua.LState, raise bool) int {
+	args := make([]string, L.GetTop())
+	for n := range args {
+		switch v := L.Get(n + 1).(type) {
+		case lua.LString, lua.LNumber:
+			args[n] = lua.LVAsString(v)
+		default:
+			L.Error(newLuaReplyTable(L, "err", ErrScriptArguments.Error()), 1)
+			return 0
+		}
+	}
+	if len(args) == 0 {
+		L.Error(newLuaReplyTable(L, "err", NewError(ErrorPrefix, "Please specify at least one argument for this redis lib call").Error()), 1)
+		return 0
+	}
+
+	msg, err := call.execute(args)
+	if err != nil {
+		msg = NewErrorMessage(err)
+	}
+	ret := messageToLua(L, msg)
+	if raise && msg.IsError() {
+		L.Error(ret, 1)
+		return 0
+	}
+	L.Push(ret)
+	return 1
+}
+
+// execute executes the specified command through the command executors on the connection of the script.
+func (call *scriptCall) execute(args []string) (*Message, error) {
+	server := call.server
+	name, ok := server.commandRenames.resolve(args[0])
+	if !ok {
+		return nil, newUnknownCommandError(args[0], args[1:])
+	}
+	info, hasInfo := server.LookupCommandInfo(name)
+	isWrite := hasInfo && info.IsWrite()
+	if hasInfo {
+		if info.HasFlag(NoScriptFlag) {
+			return nil, ErrScriptCommand
+		}
+		if isWrite && call.noWrites {
+			return nil, ErrScriptWrite
+		}
+		if isWrite && server.isReadOnlyReplica(call.conn) {
+			return nil, ErrReadOnly
+		}
+	}
+
+	array := proto.NewArray()
+	for _, arg := range args[1:] {
+		array.Append(NewBulkMessage(arg))
+	}
+	argMsgs := array.PeekMessages()
+	msg, err := server.executeCommand(call.conn, name, array)
+	if isWrite && !isFailedCommandResult(msg, err) {
+		server.scripting.Lock()
+		call.wrote = true
+		server.scripting.Unlock()
+		server.propagate(call.conn, name, argMsgs)
+	}
+	return msg, err
+}
+
+// pcall calls the specified Lua function with the specified arguments and converts the result to a RESP message.
+func (call *scriptCall) pcall(L *lua.LState, fn *lua.LFunction, args ...lua.LValue) (*Message, error) {
+	L.Push(fn)
+	for _, arg := range args {
+		L.Push(arg)
+	}
+	if err := L.PCall(len(args), 1, nil); err != nil {
+		return call.errorMessage(err)
+	}
+	ret := L.Get(-1)
+	L.Pop(1)
+	return luaToMessage(ret), nil
+}
+
+// errorMessage returns the error reply of the specified Lua error.
+func (call *scriptCall) errorMessage(err error) (*Message, error) {
+	call.server.scripting.Lock()
+	killed := call.killed
+	call.server.scripting.Unlock()
+	if killed {
+		return nil, ErrScriptKilled
+	}
+	var apiErr *lua.ApiError
+	if !errors.As(err, &apiErr) || apiErr.Object == nil {
+		return nil, NewError(ErrorPrefix, fmt.Sprintf("Error running script (call to %s): %s", call.name, err.Error()))
+	}
+	if tbl, ok := apiErr.Object.(*lua.LTable); ok {
+		if errStr, ok := tbl.RawGetString("err").(lua.LString); ok {
+			return newErrorStringMessage(string(errStr)), nil
+		}
+	}
+	return nil, NewError(ErrorPrefix, fmt.Sprintf("Error running script (call to %s): %s", call.name, apiErr.Object.String()))
+}
+
+// newErrorStringMessage returns an error message of the specified error string as it is.
+func newErrorStringMessage(errStr string) *Message {
+	return proto.NewMessageWithType(proto.ErrorMessage).SetBytes([]byte(errorReplyReplacer.Replace(errStr)))
+}
+
+// newLuaReplyTable returns a Lua table which has the specified field such as err and ok.
+func newLuaReplyTable(L *lua.LState, field string, val string) *lua.LTable {
+	tbl := L.NewTable()
+	tbl.RawSetString(field, lua.LString(val))
+	return tbl
+}
+
+// newLuaStringTable returns a Lua array table of the specified strings.
+func newLuaStringTable(L *lua.LState, strs []string) *lua.LTable {
+	tbl := L.CreateTable(len(strs), 0)
+	for _, str := range strs {
+		tbl.Append(lua.LString(str))
+	}
+	return tbl
+}
+
+// messageToLua converts the specified RESP message to a Lua value.
+func messageToLua(L *lua.LState, msg *Message) lua.LValue {
+	switch msg.Type {
+	case proto.IntegerMessage:
+		n, err := msg.Integer()
+		if err != nil {
+			return lua.LFalse
+		}
+		return lua.LNumber(n)
+	case proto.BulkMessage:
+		if msg.IsNil() {
+			return lua.LFalse
+		}
+		b, _ := msg.Bytes()
+		return lua.LString(b)
+	case proto.StringMessage:
+		b, _ := msg.Bytes()
+		return newLuaReplyTable(L, "ok", string(b))
+	case proto.ErrorMessage:
+		b, _ := msg.Bytes()
+		return newLuaReplyTable(L, "err", string(b))
+	case proto.ArrayMessage:
+		array, err := msg.Array()
+		if err != nil || array == nil {
+			return lua.LFalse
+		}
+		msgs := array.PeekMessages()
+		tbl := L.CreateTable(len(msgs), 0)
+		for _, elem := range msgs {
+			tbl.Append(messageToLua(L, elem))
+		}
+		return tbl
+	}
+	return lua.LFalse
+}
+
+// luaToMessage converts the specified Lua value to a RESP message.
+func luaToMessage(lv lua.LValue) *Message {
+	switch v := lv.(type) {
+	case lua.LString:
+		return NewBulkMessage(string(v))
+	case lua.LNumber:
+		return NewIntegerMessage(int(v))
+	case lua.LBool:
+		if v {
+			return NewIntegerMessage(1)
+		}
+		return NewNilMessage()
+	case *lua.LTable:
+		if errStr, ok := v.RawGetString("err").(lua.LString); ok {
+			return newErrorStringMessage(string(errStr))
+		}
+		if okStr, ok := v.RawGetString("ok").(lua.LString); ok {
+			return NewStringMessage(string(okStr))
+		}
+		msg := NewArrayMessage()
+		for n := 1; ; n++ {
+			elem := v.RawGetInt(n)
+			if elem == lua.LNil {
+				break
+			}
+			msg.Append(luaToMessage(elem))
+		}
+		return msg
+	}
+	return NewNilMessage()
+}
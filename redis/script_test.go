@@ -0,0 +1,238 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cybergarage/go-redis/redis/proto"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func TestScriptFlags(t *testing.T) {
+	tests := []struct {
+		body     string
+		flags    []string
+		hasError bool
+	}{
+		{"return 1", []string{}, false},
+		{"#!lua\nreturn 1", []string{}, false},
+		{"#!lua flags=no-writes\nreturn 1", []string{"no-writes"}, false},
+		{"#!lua flags=no-writes,allow-stale\nreturn 1", []string{"no-writes", "allow-stale"}, false},
+		{"#!js\nreturn 1", nil, true},
+		{"#!lua flags=unknown\nreturn 1", nil, true},
+		{"#!lua name=lib\nreturn 1", nil, true},
+	}
+
+	for _, test := range tests {
+		_, flags, err := parseScriptFlags(test.body)
+		if test.hasError {
+			if err == nil {
+				t.Errorf("%q is accepted", test.body)
+			}
+			continue
+		}
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if len(flags) != len(test.flags) {
+			t.Errorf("%v != %v", flags, test.flags)
+			continue
+		}
+		for n, flag := range flags {
+			if flag != test.flags[n] {
+				t.Errorf("%s != %s", flag, test.flags[n])
+			}
+		}
+	}
+}
+
+func TestScriptConversion(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+
+	msgs := []*Message{
+		NewIntegerMessage(10),
+		NewBulkMessage("value"),
+		NewStringMessage("OK"),
+		newErrorStringMessage("ERR error"),
+		NewNilMessage(),
+	}
+	array := NewArrayMessage()
+	for _, msg := range msgs {
+		array.Append(msg)
+	}
+
+	msg := luaToMessage(messageToLua(L, array))
+	if msg.Type != proto.ArrayMessage {
+		t.Fatalf("%v != %v", msg.Type, proto.ArrayMessage)
+	}
+	a, err := msg.Array()
+	if err != nil {
+		t.Fatal(err)
+	}
+	elems := a.PeekMessages()
+	// The nil reply is converted to false, and false is converted back to the nil reply.
+	if len(elems) != len(msgs) {
+		t.Fatalf("%d != %d", len(elems), len(msgs))
+	}
+	for n, elem := range elems {
+		if elem.Type != msgs[n].Type {
+			t.Errorf("%v != %v", elem.Type, msgs[n].Type)
+			continue
+		}
+		b, _ := elem.Bytes()
+		expected, _ := msgs[n].Bytes()
+		if string(b) != string(expected) {
+			t.Errorf("%s != %s", string(b), string(expected))
+		}
+	}
+
+	if msg := luaToMessage(lua.LNumber(3.99)); msg.Type != proto.IntegerMessage {
+		t.Errorf("%v != %v", msg.Type, proto.IntegerMessage)
+	} else if n, _ := msg.Integer(); n != 3 {
+		t.Errorf("%d != %d", n, 3)
+	}
+	if msg := luaToMessage(lua.LTrue); msg.Type != proto.IntegerMessage {
+		t.Errorf("%v != %v", msg.Type, proto.IntegerMessage)
+	}
+}
+
+func TestServerEval(t *testing.T) {
+	server := NewServer()
+	server.SetFallbackExecutor(func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		key, err := args.NextString()
+		if err != nil {
+			return nil, err
+		}
+		return NewBulkMessage(cmd + ":" + key), nil
+	})
+
+	conn := newConnWith(nil)
+	conn.SetSpanContext(server.Tracer.StartSpan(PackageName))
+	conn.SetAuthrized(true)
+
+	body := "return {redis.call('GET', KEYS[1]), ARGV[1]}"
+	msg, err := server.Eval(conn, body, []string{"key"}, []string{"arg"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	array, err := msg.Array()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"GET:key", "arg"} {
+		str, err := array.NextString()
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if str != expected {
+			t.Errorf("%s != %s", str, expected)
+		}
+	}
+
+	sha := scriptSHA(body)
+	if _, err := server.EvalSha(conn, sha, []string{"key"}, []string{}, false); err != nil {
+		t.Error(err)
+	}
+	if _, err := server.ScriptFlush(conn); err != nil {
+		t.Error(err)
+	}
+	if _, err := server.EvalSha(conn, sha, []string{"key"}, []string{}, false); !errors.Is(err, ErrNoScript) {
+		t.Errorf("%v != %v", err, ErrNoScript)
+	}
+	// The error replies of redis.call are returned as they are.
+	msg, err = server.Eval(conn, "return redis.call('GET')", []string{}, []string{}, false)
+	if err != nil {
+		t.Error(err)
+	} else if !msg.IsError() {
+		t.Errorf("%v is not an error", msg)
+	}
+	if _, err := server.ScriptKill(conn); !errors.Is(err, ErrNotBusy) {
+		t.Errorf("%v != %v", err, ErrNotBusy)
+	}
+}
+
+func TestServerBusyScript(t *testing.T) {
+	server := NewServer()
+	server.SetBusyReplyThreshold(100)
+	server.SetFallbackExecutor(func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		return NewOKMessage(), nil
+	})
+
+	newConn := func() *Conn {
+		conn := newConnWith(nil)
+		conn.SetSpanContext(server.Tracer.StartSpan(PackageName))
+		conn.SetAuthrized(true)
+		return conn
+	}
+	execCommand := func(conn *Conn, args ...string) (*Message, error) {
+		array := proto.NewArray()
+		for _, arg := range args {
+			array.Append(NewBulkMessage(arg))
+		}
+		return server.handleArrayMessage(conn, array)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := execCommand(newConn(), "EVAL", "while true do end", "0")
+		done <- err
+	}()
+	for running := false; !running; time.Sleep(10 * time.Millisecond) {
+		server.scripting.Lock()
+		running = server.scripting.running != nil
+		server.scripting.Unlock()
+	}
+
+	// The other clients receive BUSY after the threshold.
+	conn := newConn()
+	busyCommands := [][]string{
+		{"GET", "key"},
+		{"SET", "key", "val"},
+		{"EVAL", "return 1", "0"},
+		{"SCRIPT", "LOAD", "return 1"},
+		{"FUNCTION", "FLUSH"},
+		{"FUNCTION", "LOAD", "#!lua name=lib\nredis.register_function('f', function() return 1 end)"},
+	}
+	for _, args := range busyCommands {
+		if _, err := execCommand(conn, args...); !errors.Is(err, ErrBusy) {
+			t.Errorf("%s: %v != %v", strings.Join(args[:2], " "), err, ErrBusy)
+		}
+	}
+	// The subcommands to inspect and kill the script run while the script is running.
+	if _, err := execCommand(conn, "FUNCTION", "STATS"); err != nil {
+		t.Error(err)
+	}
+	if _, err := execCommand(conn, "SCRIPT", "KILL"); err != nil {
+		t.Error(err)
+	}
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrScriptKilled) {
+			t.Errorf("%v != %v", err, ErrScriptKilled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("killed script is running")
+	}
+	if _, err := execCommand(conn, "GET", "key"); err != nil {
+		t.Error(err)
+	}
+}
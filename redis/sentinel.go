@@ -0,0 +1,372 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cybergarage/go-logger/log"
+	"github.com/cybergarage/go-redis/redis/client"
+)
+
+const (
+	// SentinelRole is the role of the sentinels reported by ROLE command.
+	SentinelRole = "sentinel"
+	// SentinelHelloChannel is the channel of the masters which the sentinels use to discover each other.
+	SentinelHelloChannel = "__sentinel__:hello"
+	// DefaultSentinelDownAfter is the default time after which an unreachable instance is considered down.
+	DefaultSentinelDownAfter = 30 * time.Second
+	// DefaultSentinelFailoverTimeout is the default timeout of the failovers.
+	DefaultSentinelFailoverTimeout = 3 * time.Minute
+)
+
+const (
+	sentinelPingPeriod     = time.Second
+	sentinelInfoPeriod     = 10 * time.Second
+	sentinelFastInfoPeriod = time.Second
+	sentinelHelloPeriod    = 2 * time.Second
+	sentinelAskPeriod      = time.Second
+	sentinelPeerTimeout    = time.Second
+	sentinelRetryInterval  = time.Second
+)
+
+// sentinelCommands is the commands which are served in the sentinel mode.
+var sentinelCommands = map[string]bool{
+	"AUTH":         true,
+	"CLIENT":       true,
+	"COMMAND":      true,
+	"INFO":         true,
+	"PING":         true,
+	"PSUBSCRIBE":   true,
+	"PUBLISH":      true,
+	"PUBSUB":       true,
+	"PUNSUBSCRIBE": true,
+	"QUIT":         true,
+	"ROLE":         true,
+	"SENTINEL":     true,
+	"SHUTDOWN":     true,
+	"SUBSCRIBE":    true,
+	"UNSUBSCRIBE":  true,
+}
+
+// sentinelInstance represents a master or a replica monitored by the sentinel.
+type sentinelInstance struct {
+	host         string
+	port         int
+	runID        string
+	client       *client.Client
+	lastOKPing   time.Time
+	lastInfo     time.Time
+	sdown        bool
+	role         string
+	masterHost   string
+	masterPort   int
+	masterLinkUp bool
+	offset       int64
+}
+
+// newSentinelInstance returns a new instance of the specified address.
+func newSentinelInstance(host string, port int) *sentinelInstance {
+	return &sentinelInstance{
+		host:         host,
+		port:         port,
+		runID:        "",
+		client:       nil,
+		lastOKPing:   time.Now(),
+		lastInfo:     time.Time{},
+		sdown:        false,
+		role:         "",
+		masterHost:   "",
+		masterPort:   0,
+		masterLinkUp: false,
+		offset:       0,
+	}
+}
+
+// Addr returns the address of the instance.
+func (instance *sentinelInstance) Addr() string {
+	return net.JoinHostPort(instance.host, strconv.Itoa(instance.port))
+}
+
+// closeClient closes the connection to the instance.
+func (instance *sentinelInstance) closeClient() {
+	if instance.client != nil {
+		instance.client.Close()
+		instance.client = nil
+	}
+}
+
+// sentinelPeer represents another sentinel monitoring the same master.
+type sentinelPeer struct {
+	host        string
+	port        int
+	runID       string
+	lastHello   time.Time
+	masterDown  bool
+	leader      string
+	leaderEpoch int64
+}
+
+// Addr returns the address of the sentinel.
+func (peer *sentinelPeer) Addr() string {
+	return net.JoinHostPort(peer.host, strconv.Itoa(peer.port))
+}
+
+// sentinelMaster represents a master monitored by the sentinel with the replicas and the other sentinels.
+type sentinelMaster struct {
+	*sentinelInstance
+	name              string
+	quorum            int
+	downAfter         time.Duration
+	failoverTimeout   time.Duration
+	authPass          string
+	replicas          map[string]*sentinelInstance
+	sentinels         map[string]*sentinelPeer
+	odown             bool
+	configEpoch       int64
+	leader            string
+	leaderEpoch       int64
+	failoverRequested bool
+	failoverRunning   bool
+	lastFailoverTry   time.Time
+	lastHello         time.Time
+	lastAsk           time.Time
+	helloClient       *client.Client
+	cancel            context.CancelFunc
+	done              chan struct{}
+}
+
+// newSentinelMaster returns a new master of the specified name and address.
+func newSentinelMaster(name string, host string, port int, quorum int) *sentinelMaster {
+	return &sentinelMaster{
+		sentinelInstance:  newSentinelInstance(host, port),
+		name:              name,
+		quorum:            quorum,
+		downAfter:         DefaultSentinelDownAfter,
+		failoverTimeout:   DefaultSentinelFailoverTimeout,
+		authPass:          "",
+		replicas:          map[string]*sentinelInstance{},
+		sentinels:         map[string]*sentinelPeer{},
+		odown:             false,
+		configEpoch:       0,
+		leader:            "",
+		leaderEpoch:       0,
+		failoverRequested: false,
+		failoverRunning:   false,
+		lastFailoverTry:   time.Time{},
+		lastHello:         time.Time{},
+		lastAsk:           time.Time{},
+		helloClient:       nil,
+		cancel:            nil,
+		done:              nil,
+	}
+}
+
+// pingPeriod returns the period of the pings to the instances of the master.
+func (master *sentinelMaster) pingPeriod() time.Duration {
+	if master.downAfter < sentinelPingPeriod {
+		return master.downAfter
+	}
+	return sentinelPingPeriod
+}
+
+// Sentinel represents the masters monitored by the server in the sentinel mode.
+type Sentinel struct {
+	sync.Mutex
+	server       *Server
+	currentEpoch int64
+	masters      map[string]*sentinelMaster
+	ctx          context.Context
+	waitGroup    sync.WaitGroup
+}
+
+// newSentinel returns a new sentinel of the specified server.
+func newSentinel(server *Server) *Sentinel {
+	return &Sentinel{
+		Mutex:        sync.Mutex{},
+		server:       server,
+		currentEpoch: 0,
+		masters:      map[string]*sentinelMaster{},
+		ctx:          nil,
+		waitGroup:    sync.WaitGroup{},
+	}
+}
+
+// MyID returns the ID of the sentinel which is the run ID of the server.
+func (sentinel *Sentinel) MyID() string {
+	return sentinel.server.runID
+}
+
+// Monitor starts monitoring the specified master. The master is considered objectively down
+// when the specified number of the sentinels agree that the master is not reachable.
+func (sentinel *Sentinel) Monitor(name string, host string, port int, quorum int) error {
+	if quorum <= 0 {
+		return NewError(ErrorPrefix, "Quorum must be 1 or greater.")
+	}
+	sentinel.Lock()
+	defer sentinel.Unlock()
+	if _, ok := sentinel.masters[name]; ok {
+		return NewError(ErrorPrefix, "Duplicated master name.")
+	}
+	master := newSentinelMaster(name, host, port, quorum)
+	sentinel.masters[name] = master
+	sentinel.eventLocked(master, "+monitor", master.sentinelInstance, fmt.Sprintf("quorum %d", quorum))
+	if sentinel.ctx != nil && sentinel.ctx.Err() == nil {
+		sentinel.startMasterLocked(sentinel.ctx, master)
+	}
+	return nil
+}
+
+// Remove stops monitoring the specified master.
+func (sentinel *Sentinel) Remove(name string) error {
+	sentinel.Lock()
+	master, ok := sentinel.masters[name]
+	if ok {
+		delete(sentinel.masters, name)
+		sentinel.eventLocked(master, "-monitor", master.sentinelInstance, "")
+	}
+	sentinel.Unlock()
+	if !ok {
+		return ErrNoSuchMaster
+	}
+	if master.cancel != nil {
+		master.cancel()
+		<-master.done
+	}
+	return nil
+}
+
+// SetDownAfter sets the time after which the unreachable instances of the specified master are considered down.
+func (sentinel *Sentinel) SetDownAfter(name string, d time.Duration) error {
+	return sentinel.updateMaster(name, func(master *sentinelMaster) {
+		master.downAfter = d
+	})
+}
+
+// SetFailoverTimeout sets the timeout of the failovers of the specified master.
+func (sentinel *Sentinel) SetFailoverTimeout(name string, d time.Duration) error {
+	return sentinel.updateMaster(name, func(master *sentinelMaster) {
+		master.failoverTimeout = d
+	})
+}
+
+// SetAuthPass sets the password to authenticate with the instances of the specified master.
+func (sentinel *Sentinel) SetAuthPass(name string, password string) error {
+	return sentinel.updateMaster(name, func(master *sentinelMaster) {
+		master.authPass = password
+	})
+}
+
+// updateMaster updates the specified master with the specified function.
+func (sentinel *Sentinel) updateMaster(name string, update func(master *sentinelMaster)) error {
+	sentinel.Lock()
+	defer sentinel.Unlock()
+	master, ok := sentinel.masters[name]
+	if !ok {
+		return ErrNoSuchMaster
+	}
+	update(master)
+	return nil
+}
+
+// MasterAddr returns the current address of the specified master.
+func (sentinel *Sentinel) MasterAddr(name string) (string, int, bool) {
+	sentinel.Lock()
+	defer sentinel.Unlock()
+	master, ok := sentinel.masters[name]
+	if !ok {
+		return "", 0, false
+	}
+	return master.host, master.port, true
+}
+
+// start starts monitoring all masters until the specified context is done.
+func (sentinel *Sentinel) start(ctx context.Context) {
+	sentinel.Lock()
+	defer sentinel.Unlock()
+	sentinel.ctx = ctx
+	for _, master := range sentinel.masters {
+		sentinel.startMasterLocked(ctx, master)
+	}
+}
+
+// stop waits for the termination of the monitoring goroutines.
+func (sentinel *Sentinel) stop() {
+	sentinel.waitGroup.Wait()
+}
+
+// startMasterLocked starts the monitoring goroutines of the specified master.
+func (sentinel *Sentinel) startMasterLocked(ctx context.Context, master *sentinelMaster) {
+	masterCtx, cancel := context.WithCancel(ctx)
+	master.cancel = cancel
+	master.done = make(chan struct{})
+	sentinel.waitGroup.Add(2)
+	go func() {
+		defer sentinel.waitGroup.Done()
+		defer close(master.done)
+		sentinel.monitorMaster(masterCtx, master)
+	}()
+	go func() {
+		defer sentinel.waitGroup.Done()
+		sentinel.receiveHellos(masterCtx, master)
+	}()
+}
+
+// instanceDescription returns the description of the specified instance used in the events.
+func instanceDescription(master *sentinelMaster, instance *sentinelInstance) string {
+	if instance == master.sentinelInstance {
+		return fmt.Sprintf("master %s %s %d", master.name, instance.host, instance.port)
+	}
+	return fmt.Sprintf("slave %s %s %d @ %s %s %d", instance.Addr(), instance.host, instance.port, master.name, master.host, master.port)
+}
+
+// eventLocked logs the specified event of the specified instance and publishes it to the event channel.
+func (sentinel *Sentinel) eventLocked(master *sentinelMaster, event string, instance *sentinelInstance, detail string) {
+	msg := instanceDescription(master, instance)
+	if 0 < len(detail) {
+		msg += " " + detail
+	}
+	sentinel.publishLocked(event, msg)
+}
+
+// publishLocked logs the specified event and publishes it to the event channel.
+func (sentinel *Sentinel) publishLocked(event string, msg string) {
+	log.Infof("%s/%s %s %s", PackageName, Version, event, msg)
+	sentinel.server.Publish(event, msg)
+}
+
+// Failover forces the failover of the specified master without the agreement of the other sentinels.
+// The failover runs asynchronously by the monitoring goroutine of the master.
+func (sentinel *Sentinel) Failover(name string) error {
+	sentinel.Lock()
+	defer sentinel.Unlock()
+	master, ok := sentinel.masters[name]
+	if !ok {
+		return ErrNoSuchMaster
+	}
+	if master.failoverRunning || master.failoverRequested {
+		return ErrFailoverInProgress
+	}
+	if master.selectReplicaLocked() == nil {
+		return ErrNoGoodReplica
+	}
+	master.failoverRequested = true
+	return nil
+}
@@ -0,0 +1,624 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cybergarage/go-logger/log"
+	"github.com/cybergarage/go-redis/redis/client"
+	"github.com/cybergarage/go-redis/redis/proto"
+)
+
+// monitorMaster pings the specified master and the replicas, and fails over the master when it is objectively down.
+func (sentinel *Sentinel) monitorMaster(ctx context.Context, master *sentinelMaster) {
+	defer sentinel.closeMasterClients(master)
+	for {
+		sentinel.Lock()
+		period := master.pingPeriod()
+		sentinel.Unlock()
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(period):
+		}
+		sentinel.checkInstances(master)
+		sentinel.sendHello(master)
+		sentinel.askSentinels(master, "*")
+		sentinel.checkObjectivelyDown(master)
+		if sentinel.shouldFailover(master) {
+			sentinel.failover(ctx, master)
+		}
+		sentinel.reconfigureReplicas(master)
+	}
+}
+
+// closeMasterClients closes the connections to the instances of the specified master.
+func (sentinel *Sentinel) closeMasterClients(master *sentinelMaster) {
+	sentinel.Lock()
+	defer sentinel.Unlock()
+	master.closeClient()
+	for _, replica := range master.replicas {
+		replica.closeClient()
+	}
+}
+
+// instancesLocked returns the master and the replicas of the specified master.
+func (master *sentinelMaster) instancesLocked() []*sentinelInstance {
+	instances := []*sentinelInstance{master.sentinelInstance}
+	for _, replica := range master.replicas {
+		instances = append(instances, replica)
+	}
+	return instances
+}
+
+// checkInstances pings the instances of the specified master, refreshes the INFO of them and updates the subjectively down states.
+func (sentinel *Sentinel) checkInstances(master *sentinelMaster) {
+	sentinel.Lock()
+	instances := master.instancesLocked()
+	authPass := master.authPass
+	timeout := master.pingPeriod()
+	infoPeriod := sentinelInfoPeriod
+	if master.sdown || master.failoverRunning {
+		infoPeriod = sentinelFastInfoPeriod
+	}
+	sentinel.Unlock()
+
+	for _, instance := range instances {
+		_, pingErr := sentinel.doInstance(instance, authPass, timeout, "PING")
+		sentinel.Lock()
+		lastInfo := instance.lastInfo
+		if pingErr == nil {
+			instance.lastOKPing = time.Now()
+		}
+		sentinel.Unlock()
+
+		if pingErr == nil && infoPeriod <= time.Since(lastInfo) {
+			msg, err := sentinel.doInstance(instance, authPass, timeout, "INFO")
+			if err == nil {
+				if info, err := msg.Bytes(); err == nil {
+					sentinel.Lock()
+					sentinel.refreshInfoLocked(master, instance, string(info))
+					sentinel.Unlock()
+				}
+			}
+		}
+
+		sentinel.Lock()
+		sentinel.checkSubjectivelyDownLocked(master, instance)
+		sentinel.Unlock()
+	}
+}
+
+// doInstance sends the specified command to the specified instance, and connects to the instance if the sentinel is not connected.
+func (sentinel *Sentinel) doInstance(instance *sentinelInstance, authPass string, timeout time.Duration, args ...string) (*proto.Message, error) {
+	if instance.client == nil {
+		cli := client.NewClient()
+		if err := cli.OpenWithTimeout(instance.Addr(), timeout); err != nil {
+			return nil, err
+		}
+		cli.SetDeadline(time.Now().Add(timeout))
+		if 0 < len(authPass) {
+			if _, err := cli.Do("AUTH", authPass); err != nil {
+				cli.Close()
+				return nil, err
+			}
+		}
+		sentinel.Lock()
+		instance.client = cli
+		sentinel.Unlock()
+	}
+	instance.client.SetDeadline(time.Now().Add(timeout))
+	msg, err := instance.client.Do(args...)
+	if err != nil && !errors.Is(err, client.ErrReply) {
+		sentinel.Lock()
+		instance.closeClient()
+		sentinel.Unlock()
+	}
+	return msg, err
+}
+
+// refreshInfoLocked updates the specified instance with the specified INFO string, and discovers the replicas.
+func (sentinel *Sentinel) refreshInfoLocked(master *sentinelMaster, instance *sentinelInstance, info string) {
+	instance.lastInfo = time.Now()
+	instance.masterLinkUp = false
+	for _, line := range strings.Split(info, infoLineSep) {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch {
+		case name == "run_id":
+			instance.runID = value
+		case name == "role":
+			instance.role = value
+		case name == "master_host":
+			instance.masterHost = value
+		case name == "master_port":
+			instance.masterPort, _ = strconv.Atoi(value)
+		case name == "master_link_status":
+			instance.masterLinkUp = value == "up"
+		case name == "slave_repl_offset":
+			instance.offset, _ = strconv.ParseInt(value, 10, 64)
+		case strings.HasPrefix(name, "slave") && instance == master.sentinelInstance:
+			host, port, ok := parseReplicaInfo(value)
+			if !ok {
+				continue
+			}
+			addr := net.JoinHostPort(host, strconv.Itoa(port))
+			if _, ok := master.replicas[addr]; ok || addr == master.Addr() {
+				continue
+			}
+			replica := newSentinelInstance(host, port)
+			master.replicas[addr] = replica
+			sentinel.eventLocked(master, "+slave", replica, "")
+		}
+	}
+}
+
+// parseReplicaInfo returns the address of the specified slaveN value of the INFO replication section.
+func parseReplicaInfo(value string) (string, int, bool) {
+	host := ""
+	port := 0
+	for _, field := range strings.Split(value, ",") {
+		name, value, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+		switch name {
+		case "ip":
+			host = value
+		case "port":
+			port, _ = strconv.Atoi(value)
+		}
+	}
+	return host, port, 0 < len(host) && 0 < port
+}
+
+// checkSubjectivelyDownLocked updates the subjectively down state of the specified instance.
+func (sentinel *Sentinel) checkSubjectivelyDownLocked(master *sentinelMaster, instance *sentinelInstance) {
+	down := master.downAfter < time.Since(instance.lastOKPing)
+	if down == instance.sdown {
+		return
+	}
+	instance.sdown = down
+	if down {
+		sentinel.eventLocked(master, "+sdown", instance, "")
+	} else {
+		sentinel.eventLocked(master, "-sdown", instance, "")
+	}
+}
+
+// sendHello publishes the hello message of the sentinel to the specified master.
+func (sentinel *Sentinel) sendHello(master *sentinelMaster) {
+	sentinel.Lock()
+	if time.Since(master.lastHello) < sentinelHelloPeriod || master.client == nil || master.sdown {
+		sentinel.Unlock()
+		return
+	}
+	master.lastHello = time.Now()
+	host, _, err := net.SplitHostPort(master.client.LocalAddr().String())
+	if err != nil {
+		sentinel.Unlock()
+		return
+	}
+	hello := strings.Join([]string{
+		host,
+		strconv.Itoa(sentinel.server.listeningPort()),
+		sentinel.MyID(),
+		strconv.FormatInt(sentinel.currentEpoch, 10),
+		master.name,
+		master.host,
+		strconv.Itoa(master.port),
+		strconv.FormatInt(master.configEpoch, 10),
+	}, ",")
+	instance := master.sentinelInstance
+	authPass := master.authPass
+	timeout := master.pingPeriod()
+	sentinel.Unlock()
+	sentinel.doInstance(instance, authPass, timeout, "PUBLISH", SentinelHelloChannel, hello)
+}
+
+// receiveHellos subscribes the hello channel of the specified master to discover the other sentinels.
+func (sentinel *Sentinel) receiveHellos(ctx context.Context, master *sentinelMaster) {
+	for ctx.Err() == nil {
+		sentinel.Lock()
+		addr := master.Addr()
+		authPass := master.authPass
+		sentinel.Unlock()
+		if err := sentinel.subscribeHellos(ctx, master, addr, authPass); err != nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(sentinelRetryInterval):
+			}
+		}
+	}
+}
+
+// subscribeHellos receives the hello messages from the specified address until the master is switched.
+func (sentinel *Sentinel) subscribeHellos(ctx context.Context, master *sentinelMaster, addr string, authPass string) error {
+	cli := client.NewClient()
+	if err := cli.OpenWithTimeout(addr, sentinelPeerTimeout); err != nil {
+		return err
+	}
+	defer cli.Close()
+	cli.SetDeadline(time.Now().Add(sentinelPeerTimeout))
+	if 0 < len(authPass) {
+		if _, err := cli.Do("AUTH", authPass); err != nil {
+			return err
+		}
+	}
+	if err := cli.Send("SUBSCRIBE", SentinelHelloChannel); err != nil {
+		return err
+	}
+	for ctx.Err() == nil {
+		sentinel.Lock()
+		switched := addr != master.Addr()
+		sentinel.Unlock()
+		if switched {
+			return nil
+		}
+		cli.SetReadDeadline(time.Now().Add(sentinelPeerTimeout))
+		msg, err := cli.Receive()
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+			return err
+		}
+		strs, err := replyStrings(msg)
+		if err != nil || len(strs) != 3 || strs[0] != "message" {
+			continue
+		}
+		sentinel.processHello(strs[2])
+	}
+	return nil
+}
+
+// processHello updates the sentinels and the master configuration with the specified hello message.
+func (sentinel *Sentinel) processHello(hello string) {
+	fields := strings.Split(hello, ",")
+	if len(fields) != 8 {
+		return
+	}
+	port, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return
+	}
+	runID := fields[2]
+	currentEpoch, err := strconv.ParseInt(fields[3], 10, 64)
+	if err != nil {
+		return
+	}
+	masterPort, err := strconv.Atoi(fields[6])
+	if err != nil {
+		return
+	}
+	configEpoch, err := strconv.ParseInt(fields[7], 10, 64)
+	if err != nil {
+		return
+	}
+	if runID == sentinel.MyID() {
+		return
+	}
+
+	sentinel.Lock()
+	defer sentinel.Unlock()
+	master, ok := sentinel.masters[fields[4]]
+	if !ok {
+		return
+	}
+	peer, ok := master.sentinels[runID]
+	if !ok {
+		peer = &sentinelPeer{
+			host:        fields[0],
+			port:        port,
+			runID:       runID,
+			lastHello:   time.Time{},
+			masterDown:  false,
+			leader:      "",
+			leaderEpoch: 0,
+		}
+		master.sentinels[runID] = peer
+		sentinel.eventLocked(master, "+sentinel", master.sentinelInstance, fmt.Sprintf("sentinel %s %s %d", runID, peer.host, peer.port))
+	}
+	peer.host = fields[0]
+	peer.port = port
+	peer.lastHello = time.Now()
+	sentinel.updateEpochLocked(currentEpoch)
+
+	if master.configEpoch < configEpoch && (fields[5] != master.host || masterPort != master.port) {
+		sentinel.switchMasterLocked(master, fields[5], masterPort, configEpoch)
+	}
+}
+
+// updateEpochLocked updates the current epoch of the sentinel if the specified epoch is newer.
+func (sentinel *Sentinel) updateEpochLocked(epoch int64) {
+	if sentinel.currentEpoch < epoch {
+		sentinel.currentEpoch = epoch
+		sentinel.publishLocked("+new-epoch", strconv.FormatInt(epoch, 10))
+	}
+}
+
+// askSentinels asks the other sentinels whether the specified master is down. The specified run ID requests
+// the votes for the leader of the failover, and "*" only asks the down state.
+func (sentinel *Sentinel) askSentinels(master *sentinelMaster, runID string) {
+	sentinel.Lock()
+	if !master.sdown || (runID == "*" && time.Since(master.lastAsk) < sentinelAskPeriod) {
+		sentinel.Unlock()
+		return
+	}
+	master.lastAsk = time.Now()
+	peers := make([]*sentinelPeer, 0, len(master.sentinels))
+	for _, peer := range master.sentinels {
+		peers = append(peers, peer)
+	}
+	host := master.host
+	port := strconv.Itoa(master.port)
+	epoch := strconv.FormatInt(sentinel.currentEpoch, 10)
+	sentinel.Unlock()
+
+	for _, peer := range peers {
+		reply, err := askSentinel(peer.Addr(), "SENTINEL", "IS-MASTER-DOWN-BY-ADDR", host, port, epoch, runID)
+		sentinel.Lock()
+		peer.masterDown = false
+		if err == nil && len(reply) == 3 {
+			peer.masterDown = reply[0] == "1"
+			if runID != "*" {
+				peer.leader = reply[1]
+				peer.leaderEpoch, _ = strconv.ParseInt(reply[2], 10, 64)
+			}
+		}
+		sentinel.Unlock()
+	}
+}
+
+// askSentinel sends the specified command to the specified sentinel and returns the reply as strings.
+func askSentinel(addr string, args ...string) ([]string, error) {
+	cli := client.NewClient()
+	if err := cli.OpenWithTimeout(addr, sentinelPeerTimeout); err != nil {
+		return nil, err
+	}
+	defer cli.Close()
+	cli.SetDeadline(time.Now().Add(sentinelPeerTimeout))
+	msg, err := cli.Do(args...)
+	if err != nil {
+		return nil, err
+	}
+	return replyStrings(msg)
+}
+
+// replyStrings returns the elements of the specified array reply as strings.
+func replyStrings(msg *proto.Message) ([]string, error) {
+	array, err := msg.Array()
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := array.NextMessages()
+	if err != nil {
+		return nil, err
+	}
+	return messageStrings(msgs), nil
+}
+
+// checkObjectivelyDown updates the objectively down state of the specified master with the replies of the other sentinels.
+func (sentinel *Sentinel) checkObjectivelyDown(master *sentinelMaster) {
+	sentinel.Lock()
+	defer sentinel.Unlock()
+	down := false
+	if master.sdown {
+		votes := 1
+		for _, peer := range master.sentinels {
+			if peer.masterDown {
+				votes++
+			}
+		}
+		down = master.quorum <= votes
+		if down && !master.odown {
+			sentinel.eventLocked(master, "+odown", master.sentinelInstance, fmt.Sprintf("#quorum %d/%d", votes, master.quorum))
+		}
+	}
+	if !down && master.odown {
+		sentinel.eventLocked(master, "-odown", master.sentinelInstance, "")
+	}
+	master.odown = down
+}
+
+// shouldFailover returns true if the sentinel should start the failover of the specified master.
+func (sentinel *Sentinel) shouldFailover(master *sentinelMaster) bool {
+	sentinel.Lock()
+	defer sentinel.Unlock()
+	if master.failoverRunning {
+		return false
+	}
+	if master.failoverRequested {
+		return true
+	}
+	return master.odown && 2*master.failoverTimeout < time.Since(master.lastFailoverTry)
+}
+
+// failover promotes the best replica of the specified master to the new master.
+func (sentinel *Sentinel) failover(ctx context.Context, master *sentinelMaster) {
+	sentinel.Lock()
+	forced := master.failoverRequested
+	master.failoverRequested = false
+	master.failoverRunning = true
+	master.lastFailoverTry = time.Now()
+	sentinel.updateEpochLocked(sentinel.currentEpoch + 1)
+	epoch := sentinel.currentEpoch
+	master.leader = sentinel.MyID()
+	master.leaderEpoch = epoch
+	sentinel.eventLocked(master, "+try-failover", master.sentinelInstance, "")
+	sentinel.Unlock()
+
+	defer func() {
+		sentinel.Lock()
+		master.failoverRunning = false
+		sentinel.Unlock()
+	}()
+
+	if !forced {
+		sentinel.askSentinels(master, sentinel.MyID())
+		sentinel.Lock()
+		votes := 1
+		for _, peer := range master.sentinels {
+			if peer.leader == sentinel.MyID() && peer.leaderEpoch == epoch {
+				votes++
+			}
+		}
+		needed := max(master.quorum, (len(master.sentinels)+1)/2+1)
+		if votes < needed {
+			sentinel.eventLocked(master, "-failover-abort-not-elected", master.sentinelInstance, "")
+			sentinel.Unlock()
+			return
+		}
+		sentinel.eventLocked(master, "+elected-leader", master.sentinelInstance, "")
+		sentinel.Unlock()
+	}
+
+	sentinel.Lock()
+	replica := master.selectReplicaLocked()
+	if replica == nil {
+		sentinel.eventLocked(master, "-failover-abort-no-good-slave", master.sentinelInstance, "")
+		sentinel.Unlock()
+		return
+	}
+	sentinel.eventLocked(master, "+selected-slave", replica, "")
+	authPass := master.authPass
+	timeout := master.failoverTimeout
+	sentinel.Unlock()
+
+	if err := sentinel.promoteReplica(ctx, replica, authPass, timeout); err != nil {
+		sentinel.Lock()
+		sentinel.eventLocked(master, "-failover-abort-slave-timeout", replica, "")
+		sentinel.Unlock()
+		log.Error(err)
+		return
+	}
+
+	sentinel.Lock()
+	defer sentinel.Unlock()
+	sentinel.eventLocked(master, "+promoted-slave", replica, "")
+	sentinel.switchMasterLocked(master, replica.host, replica.port, epoch)
+}
+
+// selectReplicaLocked returns the replica which is not down and has the highest replication offset.
+func (master *sentinelMaster) selectReplicaLocked() *sentinelInstance {
+	candidates := []*sentinelInstance{}
+	for _, replica := range master.replicas {
+		if replica.sdown || replica.lastInfo.IsZero() || replica.role != ReplicaRole {
+			continue
+		}
+		candidates = append(candidates, replica)
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].offset != candidates[j].offset {
+			return candidates[j].offset < candidates[i].offset
+		}
+		return candidates[i].runID < candidates[j].runID
+	})
+	return candidates[0]
+}
+
+// promoteReplica turns the specified replica into a master and waits until the replica reports the master role.
+func (sentinel *Sentinel) promoteReplica(ctx context.Context, replica *sentinelInstance, authPass string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	if _, err := sentinel.doInstance(replica, authPass, sentinelPeerTimeout, "REPLICAOF", "NO", "ONE"); err != nil {
+		return err
+	}
+	for time.Now().Before(deadline) {
+		msg, err := sentinel.doInstance(replica, authPass, sentinelPeerTimeout, "INFO", InfoReplicationSection)
+		if err == nil {
+			if info, err := msg.Bytes(); err == nil && strings.Contains(string(info), "role:"+MasterRole+infoLineSep) {
+				return nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(sentinelFastInfoPeriod / 10):
+		}
+	}
+	return os.ErrDeadlineExceeded
+}
+
+// switchMasterLocked switches the specified master to the specified address, and the old master becomes a replica.
+func (sentinel *Sentinel) switchMasterLocked(master *sentinelMaster, host string, port int, epoch int64) {
+	oldMaster := master.sentinelInstance
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	newMaster, ok := master.replicas[addr]
+	if ok {
+		delete(master.replicas, addr)
+	} else {
+		newMaster = newSentinelInstance(host, port)
+	}
+	master.replicas[oldMaster.Addr()] = oldMaster
+	master.sentinelInstance = newMaster
+	master.configEpoch = epoch
+	master.odown = false
+	master.leader = ""
+	for _, instance := range master.instancesLocked() {
+		instance.lastInfo = time.Time{}
+	}
+	for _, peer := range master.sentinels {
+		peer.masterDown = false
+	}
+	sentinel.publishLocked("+switch-master", fmt.Sprintf("%s %s %d %s %d", master.name, oldMaster.host, oldMaster.port, host, port))
+}
+
+// reconfigureReplicas turns the instances which do not replicate the current master into the replicas of the master.
+func (sentinel *Sentinel) reconfigureReplicas(master *sentinelMaster) {
+	sentinel.Lock()
+	if master.sdown || master.failoverRunning || master.role != MasterRole {
+		sentinel.Unlock()
+		return
+	}
+	targets := []*sentinelInstance{}
+	for _, replica := range master.replicas {
+		if replica.sdown || replica.lastInfo.IsZero() {
+			continue
+		}
+		if replica.role == ReplicaRole && replica.masterHost == master.host && replica.masterPort == master.port {
+			continue
+		}
+		if replica.role == MasterRole {
+			sentinel.eventLocked(master, "+convert-to-slave", replica, "")
+		} else {
+			sentinel.eventLocked(master, "+slave-reconf-sent", replica, "")
+		}
+		replica.lastInfo = time.Time{}
+		targets = append(targets, replica)
+	}
+	host := master.host
+	port := strconv.Itoa(master.port)
+	authPass := master.authPass
+	sentinel.Unlock()
+
+	for _, replica := range targets {
+		sentinel.doInstance(replica, authPass, sentinelPeerTimeout, "REPLICAOF", host, port)
+	}
+}
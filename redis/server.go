@@ -15,10 +15,18 @@
 package redis
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"io"
 	"net"
-	"strconv"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/cybergarage/go-logger/log"
 	"github.com/cybergarage/go-redis/redis/proto"
@@ -30,11 +38,43 @@ type Server struct {
 	*ServerConfig
 	tracer.Tracer
 	Addr                 string
-	tcpListener          net.Listener
+	tcpListeners         []*serverListener
+	listenerMutex        sync.Mutex
+	listening            bool
+	boundToNonLoopback   atomic.Bool
 	authCommandHandler   AuthCommandHandler
 	systemCommandHandler SystemCommandHandler
 	userCommandHandler   UserCommandHandler
 	commandExecutors     Executors
+	fallbackExecutor     Executor
+	middlewares          []Middleware
+	commandChain         Executor
+	commandTable         CommandTable
+	commandRenames       *commandRenames
+	commandStats         *CommandStats
+	slowLog              *SlowLog
+	monitorConns         *Conns
+	clientConns          *Conns
+	serverStats          *ServerStats
+	metricsServer        *http.Server
+	replication          *replication
+	writeMutex           sync.Mutex
+	replicaOfMutex       sync.Mutex
+	cluster              *Cluster
+	pubSub               *pubSub
+	scripting            *scripting
+	scriptMutex          sync.RWMutex
+	libraries            *libraries
+	sentinel             *Sentinel
+	sentinelEnabled      atomic.Bool
+	runID                string
+	startTime            time.Time
+	ctx                  context.Context
+	cancel               context.CancelFunc
+	waitGroup            sync.WaitGroup
+	shutdownMutex        sync.Mutex
+	closing              chan struct{}
+	done                 chan struct{}
 }
 
 // NewServer returns a new server instance.
@@ -42,13 +82,49 @@ func NewServer() *Server {
 	server := &Server{
 		Tracer:               tracer.NullTracer,
 		Addr:                 "",
-		tcpListener:          nil,
+		tcpListeners:         []*serverListener{},
+		listenerMutex:        sync.Mutex{},
+		listening:            false,
+		boundToNonLoopback:   atomic.Bool{},
 		authCommandHandler:   nil,
 		systemCommandHandler: nil,
 		userCommandHandler:   nil,
 		commandExecutors:     Executors{},
+		fallbackExecutor:     nil,
+		middlewares:          []Middleware{},
+		commandChain:         nil,
+		commandTable:         NewCommandTable(),
+		commandRenames:       newCommandRenames(),
+		commandStats:         NewCommandStats(),
+		slowLog:              NewSlowLog(),
+		monitorConns:         NewConns(),
+		clientConns:          NewConns(),
+		serverStats:          NewServerStats(),
+		metricsServer:        nil,
+		replication:          newReplication(),
+		writeMutex:           sync.Mutex{},
+		replicaOfMutex:       sync.Mutex{},
+		cluster:              NewCluster(),
+		pubSub:               newPubSub(),
+		scripting:            newScripting(),
+		scriptMutex:          sync.RWMutex{},
+		libraries:            newLibraries(),
+		sentinel:             nil,
+		sentinelEnabled:      atomic.Bool{},
+		runID:                newRunID(),
+		startTime:            time.Time{},
+		ctx:                  nil,
+		cancel:               nil,
+		waitGroup:            sync.WaitGroup{},
+		shutdownMutex:        sync.Mutex{},
+		closing:              make(chan struct{}),
+		done:                 make(chan struct{}),
 		ServerConfig:         NewDefaultServerConfig(),
 	}
+	server.commandChain = server.runCommand
+	server.sentinel = newSentinel(server)
+	server.AddConfigChangeCallback(server.onListenerConfigChange, listenerConfigs...)
+	server.ctx, server.cancel = context.WithCancel(context.Background())
 	server.SetPort(DefaultPort)
 	server.registerCoreExecutors()
 	server.registerSugarExecutors()
@@ -73,33 +149,138 @@ func (server *Server) SetCommandHandler(handler UserCommandHandler) {
 }
 
 // RegisterExexutor sets a command executor.
+// The metadata of the command is taken from the built-in command table, or no restriction is applied for unknown commands.
 func (server *Server) RegisterExexutor(cmd string, executor Executor) {
-	server.commandExecutors[cmd] = executor
+	info := newDefaultCommandInfo(cmd)
+	if coreInfo, ok := coreCommandTable.LookupCommandInfo(cmd); ok {
+		*info = *coreInfo
+	}
+	server.RegisterCommand(info, executor)
+}
+
+// RegisterCommand sets a command executor with the specified command metadata.
+func (server *Server) RegisterCommand(info *CommandInfo, executor Executor) {
+	info.Name = strings.ToUpper(info.Name)
+	server.commandExecutors[info.Name] = executor
+	server.commandTable.SetCommandInfo(info)
+}
+
+// SetFallbackExecutor sets the executor for the commands which have no registered executor.
+// The fallback executor also runs the user commands when no user command handler is set.
+func (server *Server) SetFallbackExecutor(executor Executor) {
+	server.fallbackExecutor = executor
+}
+
+// Use appends the specified middlewares to the command execution chain.
+// The middlewares are applied to the commands issued by clients which pass the arity and authentication checks,
+// in the appended order, the first appended middleware is the outermost. Each middleware and the executor get
+// a fresh copy of the arguments, so a middleware may read them before passing them to the next executor.
+// Use should be called before Start.
+func (server *Server) Use(middlewares ...Middleware) {
+	server.middlewares = append(server.middlewares, middlewares...)
+	chain := withArgumentsCopy(server.runCommand)
+	for n := len(server.middlewares) - 1; 0 <= n; n-- {
+		chain = server.middlewares[n](withArgumentsCopy(chain))
+	}
+	server.commandChain = chain
+}
+
+// LookupCommandInfo returns the metadata of the specified registered command.
+func (server *Server) LookupCommandInfo(cmd string) (*CommandInfo, bool) {
+	return server.commandTable.LookupCommandInfo(cmd)
+}
+
+// CommandTable returns the command table of the registered commands.
+func (server *Server) CommandTable() CommandTable {
+	return server.commandTable
+}
+
+// CommandStats returns the command execution statistics.
+func (server *Server) CommandStats() *CommandStats {
+	return server.commandStats
+}
+
+// SlowLog returns the slow log.
+func (server *Server) SlowLog() *SlowLog {
+	return server.slowLog
+}
+
+// ServerStats returns the server statistics.
+func (server *Server) ServerStats() *ServerStats {
+	return server.serverStats
+}
+
+// ClientConns returns the connected client connections.
+func (server *Server) ClientConns() *Conns {
+	return server.clientConns
+}
+
+// keyspaceStats returns the keyspace statistics if the user command handler implements KeyspaceHandler.
+func (server *Server) keyspaceStats() (map[DatabaseID]*KeyspaceStats, bool) {
+	handler, ok := server.userCommandHandler.(KeyspaceHandler)
+	if !ok {
+		return nil, false
+	}
+	stats, err := handler.KeyspaceStats()
+	if err != nil {
+		log.Error(err)
+		return nil, false
+	}
+	return stats, true
 }
 
 // Start starts the server.
 func (server *Server) Start() error {
+	server.startTime = time.Now()
+	server.ctx, server.cancel = context.WithCancel(context.Background())
+	server.closing = make(chan struct{})
+	server.done = make(chan struct{})
+
+	if err := server.loadCommandRenames(); err != nil {
+		return err
+	}
+
+	if err := server.loadFunctions(server.ctx); err != nil {
+		return err
+	}
+
+	if err := server.openMetrics(); err != nil {
+		return err
+	}
+
 	err := server.open()
 	if err != nil {
+		server.closeMetrics()
 		return err
 	}
 
-	go server.serve()
+	go server.runReplicationCron(server.ctx)
+	server.restoreReplicaLink()
+	if server.IsSentinelEnabled() {
+		server.sentinel.start(server.ctx)
+	}
 
-	addr := net.JoinHostPort(server.Addr, strconv.Itoa(server.ConfigPort()))
-	log.Infof("%s/%s (%s) started", PackageName, Version, addr)
+	log.Infof("%s/%s (%s) started", PackageName, Version, server.listenAddrs())
 
 	return nil
 }
 
 // Stop stops the server.
 func (server *Server) Stop() error {
+	server.cancel()
+	server.stopReplicaLink()
+	server.sentinel.stop()
+
+	if err := server.closeMetrics(); err != nil {
+		return err
+	}
+
+	addrs := server.listenAddrs()
 	if err := server.close(); err != nil {
 		return err
 	}
 
-	addr := net.JoinHostPort(server.Addr, strconv.Itoa(server.ConfigPort()))
-	log.Infof("%s/%s (%s) terminated", PackageName, Version, addr)
+	log.Infof("%s/%s (%s) terminated", PackageName, Version, addrs)
 
 	return nil
 }
@@ -112,100 +293,153 @@ func (server *Server) Restart() error {
 	return server.Start()
 }
 
-// open opens a listen socket.
+// open opens the listen sockets of the listen addresses and serves them.
 func (server *Server) open() error {
-	var err error
-	addr := net.JoinHostPort(server.Addr, strconv.Itoa(server.ConfigPort()))
-	server.tcpListener, err = net.Listen("tcp", addr)
+	server.listenerMutex.Lock()
+	defer server.listenerMutex.Unlock()
+
+	listeners, err := server.openListeners(nil)
 	if err != nil {
 		return err
 	}
+	server.setListeners(listeners)
+	server.listening = true
+
 	return nil
 }
 
-// close closes a listening socket.
+// close closes the listening sockets.
 func (server *Server) close() error {
-	if server.tcpListener != nil {
-		err := server.tcpListener.Close()
-		if err != nil {
-			return err
-		}
-	}
+	server.listenerMutex.Lock()
+	defer server.listenerMutex.Unlock()
 
-	server.tcpListener = nil
+	err := closeListeners(server.tcpListeners)
+	server.tcpListeners = []*serverListener{}
+	server.listening = false
 
-	return nil
+	return err
 }
 
-// serve handles client connections.
-func (server *Server) serve() error {
-	defer server.close()
+// serve handles client connections of the specified listener.
+func (server *Server) serve(l *serverListener) error {
+	defer server.waitGroup.Done()
+	defer l.Close()
 
-	l := server.tcpListener
 	for {
-		if l == nil {
-			break
-		}
 		conn, err := l.Accept()
 		if err != nil {
 			return err
 		}
 
+		server.setKeepAlive(conn)
+
+		server.waitGroup.Add(1)
 		go server.receive(conn)
 	}
-
-	return nil
 }
 
 // receive handles a client connection.
 func (server *Server) receive(conn net.Conn) error {
+	defer server.waitGroup.Done()
 	defer conn.Close()
 
-	isPasswdRequired, _ := server.ConfigRequirePass()
+	conn = newStatsConn(conn, server.serverStats)
+
+	// Reads the configuration of the connection admission from a consistent snapshot.
+	cfg := server.ServerConfig.Snapshot()
+	isPasswdRequired, _ := cfg.ConfigRequirePass()
 
 	handlerConn := newConnWith(conn)
 	handlerConn.SetAuthrized(!isPasswdRequired)
+	if server.isProtectedModeDenied(cfg, conn.RemoteAddr()) {
+		log.Warnf("%s/%s (%s) denied by protected mode", PackageName, Version, conn.RemoteAddr().String())
+		return server.responseMessage(handlerConn, NewErrorMessage(ErrProtectedMode))
+	}
+	if err := server.clientConns.AddWithLimits(handlerConn, cfg.ConfigMaxClients(), cfg.ConfigMaxClientsPerIP()); err != nil {
+		server.serverStats.rejectedConnections.Add(1)
+		log.Warnf("%s/%s (%s) rejected (%s)", PackageName, Version, conn.RemoteAddr().String(), err.Error())
+		return server.responseMessage(handlerConn, NewErrorMessage(err))
+	}
+	handlerConn.startWriter(server.ConfigClientOutputBufferLimit, server.recordOutputLimitDisconnection)
+	defer handlerConn.stopWriter()
+	defer server.clientConns.Remove(handlerConn)
+	server.serverStats.totalConnectionsReceived.Add(1)
+	defer server.monitorConns.Remove(handlerConn)
+	defer server.removeReplica(handlerConn)
+	defer server.removeSubscriptions(handlerConn)
 
 	log.Debugf("%s/%s (%s) accepted", PackageName, Version, conn.RemoteAddr().String())
 
-	parser := proto.NewParserWithReader(conn)
+	connCtx, connCancel := context.WithCancel(server.ctx)
+	defer connCancel()
+	handlerConn.setContext(connCtx)
 
+	// The commands run in the peer context which is cancelled as soon as the client closes the connection.
+	peerCtx, peerCancel := context.WithCancel(connCtx)
+	defer peerCancel()
+
+	reqs := server.readRequests(connCtx, peerCancel, handlerConn)
+
+	closing := server.closing
 	for {
-		span := server.Tracer.StartSpan(PackageName)
-		handlerConn.SetSpanContext(span)
+		// Closes the idle connection if the server is shutting down.
+		select {
+		case <-closing:
+			return nil
+		default:
+		}
+
+		var req *request
+		select {
+		case req = <-reqs:
+		case <-connCtx.Done():
+			return nil
+		case <-closing:
+			return nil
+		}
 
-		handlerConn.StartSpan("parse")
-		reqMsg, parserErr := parser.Next()
-		handlerConn.FinishSpan()
-		if parserErr != nil {
+		span := req.span
+		if req.err != nil {
 			span.Span().Finish()
-			log.Error(parserErr)
-			return parserErr
+			return req.err
 		}
-		if reqMsg == nil {
+		if req.msg == nil {
 			span.Span().Finish()
 			break
 		}
 
+		handlerConn.SetSpanContext(span)
+
+		cmdCtx, cmdCancel := context.WithCancel(contextWithSpanContext(peerCtx, span))
+		handlerConn.SetCommandContext(cmdCtx)
+		handlerConn.SetBlocked(true)
+
 		var resMsg *Message
 		var reqErr error
 
-		resMsg, reqErr = server.handleMessage(handlerConn, reqMsg)
+		resMsg, reqErr = server.handleMessage(handlerConn, req.msg)
 		if reqErr != nil {
-			if !errors.Is(reqErr, ErrQuit) {
+			if !errors.Is(reqErr, ErrQuit) && !errors.Is(reqErr, ErrNoReply) {
 				resMsg = NewErrorMessage(reqErr)
 			}
 		}
 
-		handlerConn.StartSpan("response")
-		resErr := server.responseMessage(conn, resMsg)
-		handlerConn.FinishSpan()
+		cmdCancel()
+		handlerConn.SetCommandContext(nil)
+
+		var resErr error
+		if !errors.Is(reqErr, ErrNoReply) {
+			handlerConn.StartSpan("response")
+			resErr = server.responseMessage(handlerConn, resMsg)
+			handlerConn.FinishSpan()
+		}
+		handlerConn.updateLastInteraction()
+		handlerConn.SetBlocked(false)
 		if resErr != nil {
 			log.Error(resErr)
 		}
 		if errors.Is(reqErr, ErrQuit) {
 			span.Span().Finish()
-			conn.Close()
 			return nil
 		}
 		span.Span().Finish()
@@ -214,6 +448,82 @@ func (server *Server) receive(conn net.Conn) error {
 	return nil
 }
 
+// recordOutputLimitDisconnection records the connection which is closed by the output buffer limit.
+func (server *Server) recordOutputLimitDisconnection(conn *Conn) {
+	server.serverStats.outputLimitDisconnects.Add(1)
+	log.Warnf("%s/%s (%s) closed for overcoming of output buffer limits (%s)", PackageName, Version, conn.RemoteAddr().String(), conn.ClientType().outputBufferClass().String())
+}
+
+// request represents a parsed request message with the tracer span context.
+type request struct {
+	span tracer.Context
+	msg  *proto.Message
+	err  error
+}
+
+// readRequests reads request messages from the specified connection in a goroutine.
+// The peer context is cancelled as soon as the end of the requests such as EOF, a read error and the idle timeout
+// is read, and the end is sent as the last request. The commands read before a half-close are still executed
+// and replied because TCP can't tell it apart from a close until a reply is written.
+func (server *Server) readRequests(ctx context.Context, cancel context.CancelFunc, conn *Conn) <-chan *request {
+	reqs := make(chan *request)
+	server.waitGroup.Add(1)
+	go func() {
+		defer server.waitGroup.Done()
+		reader := &countingReader{Reader: conn, n: 0}
+		parser := proto.NewParserWithReader(reader)
+		for {
+			if err := server.setIdleDeadline(conn); err != nil {
+				log.Error(err)
+			}
+			span := server.Tracer.StartSpan(PackageName)
+			span.StartSpan("parse")
+			reader.n = 0
+			msg, err := parser.Next()
+			span.FinishSpan()
+			// The deadline is treated as the idle timeout only if no bytes of the next request are read,
+			// because the parser can't resume the partially read request.
+			if errors.Is(err, os.ErrDeadlineExceeded) && reader.n == 0 {
+				if !server.isIdleTimeout(conn) {
+					span.Span().Finish()
+					continue
+				}
+				log.Debugf("%s/%s (%s) closed by idle timeout", PackageName, Version, conn.RemoteAddr().String())
+				msg, err = nil, nil
+			}
+			if err != nil && ctx.Err() == nil {
+				log.Error(err)
+			}
+			if err != nil || msg == nil {
+				cancel()
+			}
+			select {
+			case reqs <- &request{span: span, msg: msg, err: err}:
+			case <-ctx.Done():
+				span.Span().Finish()
+				return
+			}
+			if err != nil || msg == nil {
+				return
+			}
+		}
+	}()
+	return reqs
+}
+
+// countingReader represents a reader which counts the read bytes.
+type countingReader struct {
+	io.Reader
+	n int
+}
+
+// Read reads bytes from the reader.
+func (reader *countingReader) Read(b []byte) (int, error) {
+	n, err := reader.Reader.Read(b)
+	reader.n += n
+	return n, err
+}
+
 // handleMessage handles a client message.
 func (server *Server) handleMessage(conn *Conn, msg *proto.Message) (*Message, error) {
 	switch msg.Type {
@@ -242,7 +552,7 @@ func (server *Server) responseMessage(conn io.Writer, msg *Message) error {
 	if msg != nil {
 		bytes, err = msg.RESPBytes()
 	} else {
-		bytes, err = NewErrorMessage(ErrSystem).Bytes()
+		bytes, err = NewErrorMessage(ErrSystem).RESPBytes()
 	}
 	if err != nil {
 		return err
@@ -272,5 +582,24 @@ func (server *Server) handleArrayMessage(conn *Conn, arrayMsg *proto.Array) (*Me
 		return nil, err
 	}
 
-	return server.executeCommand(conn, cmd, arrayMsg)
+	name, ok := server.commandRenames.resolve(cmd)
+	if !ok {
+		return nil, newUnknownCommandError(cmd, messageStrings(arrayMsg.PeekMessages()))
+	}
+
+	// The ASKING flag is valid only for the next command.
+	if name != "ASKING" {
+		defer conn.setAsking(false)
+	}
+
+	return server.executeCommandWith(conn, name, arrayMsg, server.commandChain)
+}
+
+// newRunID returns a new random ID of 40 hex characters such as the replication IDs and the node IDs.
+func newRunID() string {
+	b := make([]byte, ReplicationIDLength/2)
+	if _, err := rand.Read(b); err != nil {
+		log.Error(err)
+	}
+	return hex.EncodeToString(b)
 }
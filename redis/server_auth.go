@@ -14,14 +14,10 @@
 
 package redis
 
-import (
-	"errors"
-)
-
 func (server *Server) Auth(conn *Conn, username string, password string) (*Message, error) {
 	required, configPassword := server.ConfigRequirePass()
 	if required && password != configPassword {
-		return nil, errors.New("invalid password")
+		return nil, ErrWrongPass
 	}
 	conn.SetAuthrized(true)
 	return NewOKMessage(), nil
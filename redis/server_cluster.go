@@ -0,0 +1,356 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// clusterBusPortOffset is the offset of the cluster bus port from the client port.
+const clusterBusPortOffset = 10000
+
+// Cluster returns the cluster configuration which is used if the cluster mode is enabled.
+func (server *Server) Cluster() *Cluster {
+	return server.cluster
+}
+
+// clusterRedirection returns a MOVED or ASK error if the hash slot of the keys of the specified command is not served by the server.
+func (server *Server) clusterRedirection(conn *Conn, info *CommandInfo, cmd string, argMsgs []*Message) error {
+	if !server.ConfigClusterEnabled() || conn.ClientType() == MasterClient {
+		return nil
+	}
+	keys := info.Keys(append([]string{cmd}, messageStrings(argMsgs)...))
+	if len(keys) == 0 {
+		return nil
+	}
+	slot := KeySlot(keys[0])
+	for _, key := range keys[1:] {
+		if KeySlot(key) != slot {
+			return ErrCrossSlot
+		}
+	}
+
+	cluster := server.cluster
+	cluster.RLock()
+	node := cluster.slots[slot]
+	myID := cluster.myID
+	migrating := cluster.migrating[slot]
+	importing := cluster.importing[slot]
+	cluster.RUnlock()
+
+	if node == nil {
+		return ErrClusterDown
+	}
+	if node.ID != myID {
+		if importing != nil && conn.IsAsking() {
+			return nil
+		}
+		return newMovedError(slot, node.Addr())
+	}
+	if migrating == nil {
+		return nil
+	}
+	existingKeys, err := server.countExistingKeys(conn, keys)
+	if err != nil {
+		return err
+	}
+	switch {
+	case existingKeys == 0:
+		return newAskError(slot, migrating.Addr())
+	case existingKeys < len(keys):
+		return ErrTryAgain
+	}
+	return nil
+}
+
+// countExistingKeys returns the number of the specified unique keys which exist in the database.
+func (server *Server) countExistingKeys(conn *Conn, keys []string) (int, error) {
+	uniqueKeys := []string{}
+	found := map[string]bool{}
+	for _, key := range keys {
+		if found[key] {
+			continue
+		}
+		found[key] = true
+		uniqueKeys = append(uniqueKeys, key)
+	}
+	if server.userCommandHandler == nil {
+		return 0, NewErrNotSupported("EXISTS")
+	}
+	msg, err := server.userCommandHandler.Exists(conn, uniqueKeys)
+	if err != nil {
+		return 0, err
+	}
+	n, err := msg.Integer()
+	if err != nil {
+		return 0, err
+	}
+	if n < len(uniqueKeys) {
+		return n, nil
+	}
+	return len(keys), nil
+}
+
+// keysInSlot returns the sorted keys of the specified hash slot in the database.
+func (server *Server) keysInSlot(conn *Conn, slot int) ([]string, error) {
+	if slot < 0 || ClusterSlots <= slot {
+		return nil, ErrInvalidSlot
+	}
+	if server.userCommandHandler == nil {
+		return nil, NewErrNotSupported("KEYS")
+	}
+	msg, err := server.userCommandHandler.Keys(conn, "*")
+	if err != nil {
+		return nil, err
+	}
+	array, err := msg.Array()
+	if err != nil {
+		return nil, err
+	}
+	keys := []string{}
+	for _, keyMsg := range array.PeekMessages() {
+		key, err := keyMsg.String()
+		if err != nil {
+			return nil, err
+		}
+		if KeySlot(key) == slot {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// ClusterInfo returns the state of the cluster.
+func (server *Server) ClusterInfo(conn *Conn) (*Message, error) {
+	cluster := server.cluster
+	cluster.RLock()
+	defer cluster.RUnlock()
+
+	assignedSlots := 0
+	masters := map[*ClusterNode]bool{}
+	for _, node := range cluster.slots {
+		if node != nil {
+			assignedSlots++
+			masters[node] = true
+		}
+	}
+	state := "fail"
+	if assignedSlots == ClusterSlots {
+		state = "ok"
+	}
+	lines := []string{
+		"cluster_enabled:1",
+		"cluster_state:" + state,
+		"cluster_slots_assigned:" + strconv.Itoa(assignedSlots),
+		"cluster_slots_ok:" + strconv.Itoa(assignedSlots),
+		"cluster_slots_pfail:0",
+		"cluster_slots_fail:0",
+		"cluster_known_nodes:" + strconv.Itoa(len(cluster.nodes)),
+		"cluster_size:" + strconv.Itoa(len(masters)),
+		"cluster_current_epoch:0",
+		"cluster_my_epoch:0",
+	}
+	return NewBulkMessage(strings.Join(lines, infoLineSep) + infoLineSep), nil
+}
+
+// ClusterMyID returns the node ID of the server.
+func (server *Server) ClusterMyID(conn *Conn) (*Message, error) {
+	return NewBulkMessage(server.cluster.MyID()), nil
+}
+
+// ClusterNodes returns the nodes of the cluster in the nodes.conf format.
+func (server *Server) ClusterNodes(conn *Conn) (*Message, error) {
+	cluster := server.cluster
+	cluster.RLock()
+	defer cluster.RUnlock()
+
+	var nodes strings.Builder
+	for _, node := range cluster.nodes {
+		flags := "master"
+		if node.IsReplica() {
+			flags = "slave"
+		}
+		isMyself := node.ID == cluster.myID
+		if isMyself {
+			flags = "myself," + flags
+		}
+		masterID := "-"
+		if node.IsReplica() {
+			masterID = node.MasterID
+		}
+		fields := []string{
+			node.ID,
+			fmt.Sprintf("%s@%d", node.Addr(), node.Port+clusterBusPortOffset),
+			flags,
+			masterID,
+			"0",
+			"0",
+			"0",
+			"connected",
+		}
+		for _, r := range cluster.slotRangesLocked(node) {
+			if r.Start == r.End {
+				fields = append(fields, strconv.Itoa(r.Start))
+			} else {
+				fields = append(fields, fmt.Sprintf("%d-%d", r.Start, r.End))
+			}
+		}
+		if isMyself {
+			fields = append(fields, clusterSlotStates(cluster.migrating, "->-")...)
+			fields = append(fields, clusterSlotStates(cluster.importing, "-<-")...)
+		}
+		nodes.WriteString(strings.Join(fields, " ") + "\n")
+	}
+	return NewBulkMessage(nodes.String()), nil
+}
+
+// clusterSlotStates returns the migrating or importing hash slots in the nodes.conf format.
+func clusterSlotStates(states map[int]*ClusterNode, sep string) []string {
+	slots := []int{}
+	for slot := range states {
+		slots = append(slots, slot)
+	}
+	sort.Ints(slots)
+	fields := []string{}
+	for _, slot := range slots {
+		fields = append(fields, fmt.Sprintf("[%d%s%s]", slot, sep, states[slot].ID))
+	}
+	return fields
+}
+
+// ClusterSlots returns the mapping of the hash slot ranges to the nodes.
+func (server *Server) ClusterSlots(conn *Conn) (*Message, error) {
+	cluster := server.cluster
+	cluster.RLock()
+	defer cluster.RUnlock()
+
+	type slotRangeNode struct {
+		*ClusterSlotRange
+		node *ClusterNode
+	}
+	ranges := []slotRangeNode{}
+	for _, node := range cluster.nodes {
+		for _, r := range cluster.slotRangesLocked(node) {
+			ranges = append(ranges, slotRangeNode{ClusterSlotRange: r, node: node})
+		}
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].Start < ranges[j].Start
+	})
+
+	msg := NewArrayMessage()
+	for _, r := range ranges {
+		rangeMsg := NewArrayMessage()
+		rangeMsg.Append(NewIntegerMessage(r.Start))
+		rangeMsg.Append(NewIntegerMessage(r.End))
+		for _, node := range append([]*ClusterNode{r.node}, cluster.replicasLocked(r.node)...) {
+			nodeMsg := NewArrayMessage()
+			nodeMsg.Append(NewBulkMessage(node.Host))
+			nodeMsg.Append(NewIntegerMessage(node.Port))
+			nodeMsg.Append(NewBulkMessage(node.ID))
+			rangeMsg.Append(nodeMsg)
+		}
+		msg.Append(rangeMsg)
+	}
+	return msg, nil
+}
+
+// ClusterShards returns the shards of the cluster which are the masters with the replicas.
+func (server *Server) ClusterShards(conn *Conn) (*Message, error) {
+	cluster := server.cluster
+	cluster.RLock()
+	defer cluster.RUnlock()
+
+	msg := NewArrayMessage()
+	for _, master := range cluster.nodes {
+		if master.IsReplica() {
+			continue
+		}
+		slotsMsg := NewArrayMessage()
+		for _, r := range cluster.slotRangesLocked(master) {
+			slotsMsg.Append(NewIntegerMessage(r.Start))
+			slotsMsg.Append(NewIntegerMessage(r.End))
+		}
+		nodesMsg := NewArrayMessage()
+		for _, node := range append([]*ClusterNode{master}, cluster.replicasLocked(master)...) {
+			role := MasterRole
+			if node.IsReplica() {
+				role = "replica"
+			}
+			nodeMsg := NewArrayMessage()
+			nodeMsg.Append(NewBulkMessage("id"))
+			nodeMsg.Append(NewBulkMessage(node.ID))
+			nodeMsg.Append(NewBulkMessage("port"))
+			nodeMsg.Append(NewIntegerMessage(node.Port))
+			nodeMsg.Append(NewBulkMessage("ip"))
+			nodeMsg.Append(NewBulkMessage(node.Host))
+			nodeMsg.Append(NewBulkMessage("endpoint"))
+			nodeMsg.Append(NewBulkMessage(node.Host))
+			nodeMsg.Append(NewBulkMessage("role"))
+			nodeMsg.Append(NewBulkMessage(role))
+			nodeMsg.Append(NewBulkMessage("replication-offset"))
+			nodeMsg.Append(NewIntegerMessage(0))
+			nodeMsg.Append(NewBulkMessage("health"))
+			nodeMsg.Append(NewBulkMessage("online"))
+			nodesMsg.Append(nodeMsg)
+		}
+		shardMsg := NewArrayMessage()
+		shardMsg.Append(NewBulkMessage("slots"))
+		shardMsg.Append(slotsMsg)
+		shardMsg.Append(NewBulkMessage("nodes"))
+		shardMsg.Append(nodesMsg)
+		msg.Append(shardMsg)
+	}
+	return msg, nil
+}
+
+// ClusterKeySlot returns the hash slot of the specified key.
+func (server *Server) ClusterKeySlot(conn *Conn, key string) (*Message, error) {
+	return NewIntegerMessage(KeySlot(key)), nil
+}
+
+// ClusterCountKeysInSlot returns the number of the keys in the specified hash slot.
+func (server *Server) ClusterCountKeysInSlot(conn *Conn, slot int) (*Message, error) {
+	keys, err := server.keysInSlot(conn, slot)
+	if err != nil {
+		return nil, err
+	}
+	return NewIntegerMessage(len(keys)), nil
+}
+
+// ClusterGetKeysInSlot returns the specified number of the keys in the specified hash slot.
+func (server *Server) ClusterGetKeysInSlot(conn *Conn, slot int, count int) (*Message, error) {
+	if count < 0 {
+		return nil, NewError(ErrorPrefix, "Invalid number of keys")
+	}
+	keys, err := server.keysInSlot(conn, slot)
+	if err != nil {
+		return nil, err
+	}
+	if count < len(keys) {
+		keys = keys[:count]
+	}
+	return NewStringArrayMessage(keys), nil
+}
+
+// Asking allows the next command of the connection to access an importing hash slot.
+func (server *Server) Asking(conn *Conn) (*Message, error) {
+	conn.setAsking(true)
+	return NewOKMessage(), nil
+}
@@ -0,0 +1,211 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"strings"
+)
+
+// newStatusArrayMessage creates an array message of the specified status strings.
+func newStatusArrayMessage(strs []string) *Message {
+	msg := NewArrayMessage()
+	for _, str := range strs {
+		msg.Append(NewStringMessage(str))
+	}
+	return msg
+}
+
+// newCommandKeySpecsMessage creates a key specification message of the specified command.
+func newCommandKeySpecsMessage(info *CommandInfo) *Message {
+	msg := NewArrayMessage()
+	numKeysPos, isNumKeys := numKeysCommands[info.Name]
+	if info.FirstKey <= 0 && !isNumKeys {
+		return msg
+	}
+
+	flags := []string{}
+	switch {
+	case info.HasFlag(WriteFlag):
+		flags = append(flags, "RW")
+	case info.HasFlag(ReadonlyFlag):
+		flags = append(flags, "RO")
+	}
+
+	lastKey := info.LastKey
+	if 0 <= lastKey {
+		lastKey -= info.FirstKey
+	}
+
+	beginSearch := NewArrayMessage()
+	beginSearch.Append(NewBulkMessage("type"))
+	beginSearch.Append(NewBulkMessage("index"))
+	beginSearch.Append(NewBulkMessage("spec"))
+	beginSearchSpec := NewArrayMessage()
+	beginSearchSpec.Append(NewBulkMessage("index"))
+
+	findKeys := NewArrayMessage()
+	findKeys.Append(NewBulkMessage("type"))
+	findKeysSpec := NewArrayMessage()
+	if isNumKeys {
+		// The keys follow the number of the keys such as EVAL script numkeys key [key ...].
+		beginSearchSpec.Append(NewIntegerMessage(numKeysPos))
+		findKeys.Append(NewBulkMessage("keynum"))
+		findKeysSpec.Append(NewBulkMessage("keynumidx"))
+		findKeysSpec.Append(NewIntegerMessage(0))
+		findKeysSpec.Append(NewBulkMessage("firstkey"))
+		findKeysSpec.Append(NewIntegerMessage(1))
+		findKeysSpec.Append(NewBulkMessage("keystep"))
+		findKeysSpec.Append(NewIntegerMessage(1))
+	} else {
+		beginSearchSpec.Append(NewIntegerMessage(info.FirstKey))
+		findKeys.Append(NewBulkMessage("range"))
+		findKeysSpec.Append(NewBulkMessage("lastkey"))
+		findKeysSpec.Append(NewIntegerMessage(lastKey))
+		findKeysSpec.Append(NewBulkMessage("keystep"))
+		findKeysSpec.Append(NewIntegerMessage(info.Step))
+		findKeysSpec.Append(NewBulkMessage("limit"))
+		findKeysSpec.Append(NewIntegerMessage(0))
+	}
+	beginSearch.Append(beginSearchSpec)
+	findKeys.Append(NewBulkMessage("spec"))
+	findKeys.Append(findKeysSpec)
+
+	spec := NewArrayMessage()
+	spec.Append(NewBulkMessage("flags"))
+	spec.Append(newStatusArrayMessage(flags))
+	spec.Append(NewBulkMessage("begin_search"))
+	spec.Append(beginSearch)
+	spec.Append(NewBulkMessage("find_keys"))
+	spec.Append(findKeys)
+	msg.Append(spec)
+
+	return msg
+}
+
+// newCommandInfoMessage creates a COMMAND INFO reply message of the specified command.
+func newCommandInfoMessage(info *CommandInfo) *Message {
+	categories := make([]string, len(info.ACLCategories))
+	copy(categories, info.ACLCategories)
+
+	msg := NewArrayMessage()
+	msg.Append(NewBulkMessage(strings.ToLower(info.Name)))
+	msg.Append(NewIntegerMessage(info.Arity))
+	msg.Append(newStatusArrayMessage(info.Flags))
+	msg.Append(NewIntegerMessage(info.FirstKey))
+	msg.Append(NewIntegerMessage(info.LastKey))
+	msg.Append(NewIntegerMessage(info.Step))
+	msg.Append(newStatusArrayMessage(categories))
+	msg.Append(NewArrayMessage()) // tips
+	msg.Append(newCommandKeySpecsMessage(info))
+	msg.Append(NewArrayMessage()) // subcommands
+	return msg
+}
+
+// newCommandDocsMessage creates a COMMAND DOCS reply message of the specified command.
+func newCommandDocsMessage(info *CommandInfo) *Message {
+	msg := NewArrayMessage()
+	docs := []struct {
+		name string
+		val  string
+	}{
+		{"summary", info.Summary},
+		{"since", info.Since},
+		{"group", info.Group},
+		{"complexity", info.Complexity},
+	}
+	for _, doc := range docs {
+		if len(doc.val) == 0 {
+			continue
+		}
+		msg.Append(NewBulkMessage(doc.name))
+		msg.Append(NewBulkMessage(doc.val))
+	}
+	return msg
+}
+
+func (server *Server) Command(conn *Conn) (*Message, error) {
+	msg := NewArrayMessage()
+	for _, info := range server.clientCommandInfos() {
+		msg.Append(newCommandInfoMessage(info))
+	}
+	return msg, nil
+}
+
+func (server *Server) CommandCount(conn *Conn) (*Message, error) {
+	return NewIntegerMessage(len(server.clientCommandInfos())), nil
+}
+
+func (server *Server) CommandList(conn *Conn) (*Message, error) {
+	msg := NewArrayMessage()
+	for _, info := range server.clientCommandInfos() {
+		msg.Append(NewBulkMessage(strings.ToLower(info.Name)))
+	}
+	return msg, nil
+}
+
+func (server *Server) CommandInfo(conn *Conn, names []string) (*Message, error) {
+	if len(names) == 0 {
+		return server.Command(conn)
+	}
+	msg := NewArrayMessage()
+	for _, name := range names {
+		info, ok := server.lookupClientCommandInfo(name)
+		if !ok {
+			msg.Append(NewNilArrayMessage())
+			continue
+		}
+		msg.Append(newCommandInfoMessage(info))
+	}
+	return msg, nil
+}
+
+func (server *Server) CommandDocs(conn *Conn, names []string) (*Message, error) {
+	infos := []*CommandInfo{}
+	if len(names) == 0 {
+		infos = server.clientCommandInfos()
+	} else {
+		for _, name := range names {
+			info, ok := server.lookupClientCommandInfo(name)
+			if !ok {
+				continue
+			}
+			infos = append(infos, info)
+		}
+	}
+	msg := NewArrayMessage()
+	for _, info := range infos {
+		msg.Append(NewBulkMessage(strings.ToLower(info.Name)))
+		msg.Append(newCommandDocsMessage(info))
+	}
+	return msg, nil
+}
+
+func (server *Server) CommandGetKeys(conn *Conn, args []string) (*Message, error) {
+	if len(args) == 0 {
+		return nil, ErrInvalidCommand
+	}
+	info, ok := server.lookupClientCommandInfo(args[0])
+	if !ok {
+		return nil, ErrInvalidCommand
+	}
+	if !info.IsValidArity(len(args)) {
+		return nil, ErrInvalidCommandArguments
+	}
+	keys := info.Keys(args)
+	if len(keys) == 0 {
+		return nil, ErrNoKeyArguments
+	}
+	return NewStringArrayMessage(keys), nil
+}
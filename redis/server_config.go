@@ -14,13 +14,90 @@
 
 package redis
 
-import "strconv"
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
 
 const (
-	portConfig  = "port"
-	requirePass = "requirepass"
+	portConfig                           = "port"
+	requirePass                          = "requirepass"
+	latencyTrackingInfoPercentilesConfig = "latency-tracking-info-percentiles"
+	slowlogLogSlowerThanConfig           = "slowlog-log-slower-than"
+	slowlogMaxLenConfig                  = "slowlog-max-len"
+	metricsPortConfig                    = "metrics-port"
+	timeoutConfig                        = "timeout"
+	tcpKeepAliveConfig                   = "tcp-keepalive"
+	maxClientsConfig                     = "maxclients"
+	maxClientsPerIPConfig                = "maxclients-per-ip"
+	clientOutputBufferLimitConfig        = "client-output-buffer-limit"
+	bindConfig                           = "bind"
+	tlsPortConfig                        = "tls-port"
+	unixSocketConfig                     = "unixsocket"
+	protectedModeConfig                  = "protected-mode"
+	renameCommandConfig                  = "rename-command"
+	replicaOfConfig                      = "replicaof"
+	replicaReadOnlyConfig                = "replica-read-only"
+	replBacklogSizeConfig                = "repl-backlog-size"
+	replPingReplicaPeriodConfig          = "repl-ping-replica-period"
+	replTimeoutConfig                    = "repl-timeout"
+	masterAuthConfig                     = "masterauth"
+	clusterEnabledConfig                 = "cluster-enabled"
+	busyReplyThresholdConfig             = "busy-reply-threshold"
 )
 
+const (
+	// DefaultLatencyTrackingInfoPercentiles is the default latency percentiles reported by INFO latencystats.
+	DefaultLatencyTrackingInfoPercentiles = "50 99 99.9"
+	// DefaultSlowlogLogSlowerThan is the default execution time threshold of the slow log in microseconds.
+	DefaultSlowlogLogSlowerThan = 10000
+	// DefaultSlowlogMaxLen is the default maximum number of the slow log entries.
+	DefaultSlowlogMaxLen = 128
+	// DefaultTimeout is the default idle timeout of the client connections in seconds.
+	DefaultTimeout = 0
+	// DefaultTCPKeepAlive is the default TCP keepalive period of the client connections in seconds.
+	DefaultTCPKeepAlive = 300
+	// DefaultMaxClients is the default maximum number of the connected clients.
+	DefaultMaxClients = 10000
+	// DefaultClientOutputBufferLimit is the default output buffer limits of the client classes.
+	DefaultClientOutputBufferLimit = "normal 0 0 0 replica 256mb 64mb 60 pubsub 32mb 8mb 60"
+	// DefaultProtectedMode is the default protected mode.
+	DefaultProtectedMode = true
+	// DefaultReplicaReadOnly is the default read-only mode of the replicas.
+	DefaultReplicaReadOnly = true
+	// DefaultReplBacklogSize is the default size of the replication backlog in bytes.
+	DefaultReplBacklogSize = 1024 * 1024
+	// DefaultReplPingReplicaPeriod is the default period of the pings from the master to the replicas in seconds.
+	DefaultReplPingReplicaPeriod = 10
+	// DefaultReplTimeout is the default replication timeout in seconds.
+	DefaultReplTimeout = 60
+	// DefaultBusyReplyThreshold is the default execution time of the scripts in milliseconds to reply BUSY to the other clients.
+	DefaultBusyReplyThreshold = 5000
+)
+
+// ClientOutputBufferLimit represents an output buffer limit of a client class.
+type ClientOutputBufferLimit struct {
+	// HardLimit is the output buffer size in bytes which disconnects the client immediately. Zero disables the limit.
+	HardLimit int64
+	// SoftLimit is the output buffer size in bytes which disconnects the client if it is exceeded continuously for SoftSeconds. Zero disables the limit.
+	SoftLimit int64
+	// SoftSeconds is the duration of the soft limit in seconds.
+	SoftSeconds int
+}
+
+// RenamedCommand represents a command renamed by the rename-command configuration.
+type RenamedCommand struct {
+	// Name is the command name to be renamed.
+	Name string
+	// NewName is the new command name, or empty if the command is disabled.
+	NewName string
+}
+
 // ServerConfig is a configuration for the Redis server.
 type ServerConfig struct {
 	*Config
@@ -28,9 +105,90 @@ type ServerConfig struct {
 
 // NewDefaultServerConfig returns a default server configuration.
 func NewDefaultServerConfig() *ServerConfig {
-	return &ServerConfig{
+	cfg := &ServerConfig{
 		Config: newConfig(),
 	}
+	cfg.RegisterConfigParam(serverConfigParams()...)
+	return cfg
+}
+
+// Snapshot returns a snapshot of the server configuration which is not affected by the later changes.
+func (cfg *ServerConfig) Snapshot() *ServerConfig {
+	return &ServerConfig{
+		Config: cfg.Config.Snapshot(),
+	}
+}
+
+// serverConfigParams returns the definitions of the server parameters.
+func serverConfigParams() []*ConfigParam {
+	maxSeconds := time.Duration(math.MaxInt32) * time.Second
+	return []*ConfigParam{
+		NewIntegerConfigParam(portConfig, DefaultPort, 0, 65535),
+		NewStringListConfigParam(bindConfig),
+		NewIntegerConfigParam(tlsPortConfig, 0, 0, 65535).SetValidator(validateTLSPort),
+		NewStringConfigParam(unixSocketConfig, ""),
+		NewBooleanConfigParam(protectedModeConfig, DefaultProtectedMode),
+		NewStringConfigParam(requirePass, ""),
+		NewStringListConfigParam(latencyTrackingInfoPercentilesConfig, DefaultLatencyTrackingInfoPercentiles).SetValidator(validatePercentiles),
+		NewIntegerConfigParam(slowlogLogSlowerThanConfig, DefaultSlowlogLogSlowerThan, -1, math.MaxInt64),
+		NewIntegerConfigParam(slowlogMaxLenConfig, DefaultSlowlogMaxLen, 0, math.MaxInt32),
+		NewIntegerConfigParam(metricsPortConfig, 0, 0, 65535).SetImmutable(true),
+		NewDurationConfigParam(timeoutConfig, DefaultTimeout*time.Second, time.Second, 0, maxSeconds),
+		NewDurationConfigParam(tcpKeepAliveConfig, DefaultTCPKeepAlive*time.Second, time.Second, 0, maxSeconds),
+		NewIntegerConfigParam(maxClientsConfig, DefaultMaxClients, 0, math.MaxInt32),
+		NewIntegerConfigParam(maxClientsPerIPConfig, 0, 0, math.MaxInt32),
+		NewStringListConfigParam(clientOutputBufferLimitConfig, DefaultClientOutputBufferLimit).SetValidator(validateClientOutputBufferLimits),
+		NewStringListConfigParam(renameCommandConfig).SetMultiple(2).SetImmutable(true),
+		NewStringListConfigParam(replicaOfConfig).SetValidator(validateReplicaOf).SetImmutable(true),
+		NewBooleanConfigParam(replicaReadOnlyConfig, DefaultReplicaReadOnly),
+		NewMemoryConfigParam(replBacklogSizeConfig, DefaultReplBacklogSize, 1, math.MaxInt64),
+		NewDurationConfigParam(replPingReplicaPeriodConfig, DefaultReplPingReplicaPeriod*time.Second, time.Second, time.Second, maxSeconds),
+		NewDurationConfigParam(replTimeoutConfig, DefaultReplTimeout*time.Second, time.Second, time.Second, maxSeconds),
+		NewStringConfigParam(masterAuthConfig, ""),
+		NewBooleanConfigParam(clusterEnabledConfig, false).SetImmutable(true),
+		NewIntegerConfigParam(busyReplyThresholdConfig, DefaultBusyReplyThreshold, 0, math.MaxInt32),
+	}
+}
+
+// validateTLSPort validates the tls-port parameter. TLS is not supported, so only zero is allowed.
+func validateTLSPort(value string) error {
+	if value != "0" {
+		return fmt.Errorf("TLS is %w", ErrNotSupported)
+	}
+	return nil
+}
+
+// validatePercentiles validates the percentiles separated by spaces.
+func validatePercentiles(value string) error {
+	for _, str := range strings.Fields(value) {
+		p, err := strconv.ParseFloat(str, 64)
+		if err != nil || p < 0 || 100 < p {
+			return errors.New("percentile must be between 0.0 and 100.0")
+		}
+	}
+	return nil
+}
+
+// validateClientOutputBufferLimits validates the client output buffer limits.
+func validateClientOutputBufferLimits(value string) error {
+	_, err := parseClientOutputBufferLimits(value)
+	return err
+}
+
+// validateReplicaOf validates the replicaof parameter which is a host and a port.
+func validateReplicaOf(value string) error {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return nil
+	}
+	if len(fields) != 2 {
+		return ErrWrongNumberOfArguments
+	}
+	port, err := strconv.Atoi(fields[1])
+	if err != nil || port <= 0 || 65535 < port {
+		return fmt.Errorf("%w port (%s)", ErrInvalid, fields[1])
+	}
+	return nil
 }
 
 // SetPort sets a listen port number.
@@ -40,15 +198,46 @@ func (cfg *ServerConfig) SetPort(port int) {
 
 // ConfigPort returns a listen port number.
 func (cfg *ServerConfig) ConfigPort() int {
-	portStr, ok := cfg.ConfigParameter(portConfig)
+	return cfg.configInteger(portConfig, DefaultPort)
+}
+
+// SetBind sets the listen addresses. The addresses prefixed with "-" are skipped if they are not available,
+// and "*" and "::*" mean all IPv4 and IPv6 interfaces.
+func (cfg *ServerConfig) SetBind(addrs ...string) {
+	cfg.SetConfig(bindConfig, strings.Join(addrs, " "))
+}
+
+// ConfigBind returns the listen addresses.
+func (cfg *ServerConfig) ConfigBind() []string {
+	param, ok := cfg.ConfigParameter(bindConfig)
 	if !ok {
-		return DefaultPort
+		return []string{}
 	}
-	port, err := strconv.Atoi(portStr)
-	if err != nil {
-		return DefaultPort
+	return strings.Fields(param)
+}
+
+// SetUnixSocket sets the path of the unix socket to listen.
+func (cfg *ServerConfig) SetUnixSocket(path string) {
+	cfg.SetConfig(unixSocketConfig, path)
+}
+
+// ConfigUnixSocket returns the path of the unix socket to listen.
+func (cfg *ServerConfig) ConfigUnixSocket() (string, bool) {
+	path, ok := cfg.ConfigParameter(unixSocketConfig)
+	if !ok || len(path) == 0 {
+		return "", false
 	}
-	return port
+	return path, true
+}
+
+// SetProtectedMode sets the protected mode which accepts only loopback clients when no password is set.
+func (cfg *ServerConfig) SetProtectedMode(enabled bool) {
+	cfg.SetConfig(protectedModeConfig, formatBoolean(enabled))
+}
+
+// ConfigProtectedMode returns true if the protected mode is enabled.
+func (cfg *ServerConfig) ConfigProtectedMode() bool {
+	return cfg.configBoolean(protectedModeConfig, DefaultProtectedMode)
 }
 
 // SetRequirePass sets a password.
@@ -59,7 +248,7 @@ func (cfg *ServerConfig) SetRequirePass(password string) {
 // ConfigRequirePass returns a password.
 func (cfg *ServerConfig) ConfigRequirePass() (bool, string) {
 	passwd, ok := cfg.ConfigParameter(requirePass)
-	if !ok {
+	if !ok || len(passwd) == 0 {
 		return false, ""
 	}
 	return true, passwd
@@ -69,3 +258,341 @@ func (cfg *ServerConfig) ConfigRequirePass() (bool, string) {
 func (cfg *ServerConfig) RemoveRequirePass() {
 	cfg.RemoveConfig(requirePass)
 }
+
+// SetLatencyTrackingInfoPercentiles sets the latency percentiles reported by INFO latencystats.
+func (cfg *ServerConfig) SetLatencyTrackingInfoPercentiles(percentiles []float64) {
+	strs := make([]string, len(percentiles))
+	for n, p := range percentiles {
+		strs[n] = strconv.FormatFloat(p, 'f', -1, 64)
+	}
+	cfg.SetConfig(latencyTrackingInfoPercentilesConfig, strings.Join(strs, ConfigSep))
+}
+
+// ConfigLatencyTrackingInfoPercentiles returns the latency percentiles reported by INFO latencystats.
+func (cfg *ServerConfig) ConfigLatencyTrackingInfoPercentiles() []float64 {
+	param, ok := cfg.ConfigParameter(latencyTrackingInfoPercentilesConfig)
+	if !ok {
+		param = DefaultLatencyTrackingInfoPercentiles
+	}
+	percentiles := []float64{}
+	for _, str := range strings.Fields(param) {
+		p, err := strconv.ParseFloat(str, 64)
+		if err != nil || p < 0 || 100 < p {
+			continue
+		}
+		percentiles = append(percentiles, p)
+	}
+	return percentiles
+}
+
+// SetSlowlogLogSlowerThan sets the execution time threshold of the slow log in microseconds.
+// A negative value disables the slow log, and zero logs every command.
+func (cfg *ServerConfig) SetSlowlogLogSlowerThan(usec int) {
+	cfg.SetConfig(slowlogLogSlowerThanConfig, strconv.Itoa(usec))
+}
+
+// ConfigSlowlogLogSlowerThan returns the execution time threshold of the slow log in microseconds.
+func (cfg *ServerConfig) ConfigSlowlogLogSlowerThan() int {
+	return cfg.configInteger(slowlogLogSlowerThanConfig, DefaultSlowlogLogSlowerThan)
+}
+
+// SetSlowlogMaxLen sets the maximum number of the slow log entries.
+func (cfg *ServerConfig) SetSlowlogMaxLen(n int) {
+	cfg.SetConfig(slowlogMaxLenConfig, strconv.Itoa(n))
+}
+
+// ConfigSlowlogMaxLen returns the maximum number of the slow log entries.
+func (cfg *ServerConfig) ConfigSlowlogMaxLen() int {
+	return cfg.configInteger(slowlogMaxLenConfig, DefaultSlowlogMaxLen)
+}
+
+// SetMetricsPort sets a listen port number of the Prometheus metrics HTTP server. Zero disables the metrics server.
+func (cfg *ServerConfig) SetMetricsPort(port int) {
+	cfg.SetConfig(metricsPortConfig, strconv.Itoa(port))
+}
+
+// ConfigMetricsPort returns a listen port number of the Prometheus metrics HTTP server.
+func (cfg *ServerConfig) ConfigMetricsPort() int {
+	return cfg.configInteger(metricsPortConfig, 0)
+}
+
+// SetTimeout sets the idle timeout of the client connections in seconds. Zero disables the timeout.
+func (cfg *ServerConfig) SetTimeout(sec int) {
+	cfg.SetConfig(timeoutConfig, strconv.Itoa(sec))
+}
+
+// ConfigTimeout returns the idle timeout of the client connections in seconds.
+func (cfg *ServerConfig) ConfigTimeout() int {
+	return cfg.configInteger(timeoutConfig, DefaultTimeout)
+}
+
+// SetTCPKeepAlive sets the TCP keepalive period of the client connections in seconds. Zero disables the keepalive.
+func (cfg *ServerConfig) SetTCPKeepAlive(sec int) {
+	cfg.SetConfig(tcpKeepAliveConfig, strconv.Itoa(sec))
+}
+
+// ConfigTCPKeepAlive returns the TCP keepalive period of the client connections in seconds.
+func (cfg *ServerConfig) ConfigTCPKeepAlive() int {
+	return cfg.configInteger(tcpKeepAliveConfig, DefaultTCPKeepAlive)
+}
+
+// SetMaxClients sets the maximum number of the connected clients. Zero disables the limit.
+func (cfg *ServerConfig) SetMaxClients(n int) {
+	cfg.SetConfig(maxClientsConfig, strconv.Itoa(n))
+}
+
+// ConfigMaxClients returns the maximum number of the connected clients.
+func (cfg *ServerConfig) ConfigMaxClients() int {
+	return cfg.configInteger(maxClientsConfig, DefaultMaxClients)
+}
+
+// SetMaxClientsPerIP sets the maximum number of the connected clients from the same IP address. Zero disables the limit.
+func (cfg *ServerConfig) SetMaxClientsPerIP(n int) {
+	cfg.SetConfig(maxClientsPerIPConfig, strconv.Itoa(n))
+}
+
+// ConfigMaxClientsPerIP returns the maximum number of the connected clients from the same IP address.
+func (cfg *ServerConfig) ConfigMaxClientsPerIP() int {
+	return cfg.configInteger(maxClientsPerIPConfig, 0)
+}
+
+// SetClientOutputBufferLimit sets the output buffer limit of the specified client class.
+func (cfg *ServerConfig) SetClientOutputBufferLimit(t ClientType, limit ClientOutputBufferLimit) {
+	limits := maps.Clone(cfg.configClientOutputBufferLimits())
+	limits[t.outputBufferClass()] = limit
+	params := []string{}
+	for _, class := range []ClientType{NormalClient, ReplicaClient, PubSubClient} {
+		limit := limits[class]
+		params = append(params,
+			class.String(),
+			formatMemorySize(limit.HardLimit),
+			formatMemorySize(limit.SoftLimit),
+			strconv.Itoa(limit.SoftSeconds))
+	}
+	cfg.SetConfig(clientOutputBufferLimitConfig, strings.Join(params, " "))
+}
+
+// ConfigClientOutputBufferLimit returns the output buffer limit of the specified client class.
+func (cfg *ServerConfig) ConfigClientOutputBufferLimit(t ClientType) ClientOutputBufferLimit {
+	return cfg.configClientOutputBufferLimits()[t.outputBufferClass()]
+}
+
+// configClientOutputBufferLimits returns the output buffer limits of all client classes, which must not be modified.
+// The classes which are not set or invalid have the default limits. The limits are parsed once per configuration change
+// because they are checked on every write to the clients.
+func (cfg *ServerConfig) configClientOutputBufferLimits() map[ClientType]ClientOutputBufferLimit {
+	return cfg.cachedValue(clientOutputBufferLimitConfig, func(param string, ok bool) any {
+		limits, _ := parseClientOutputBufferLimits(DefaultClientOutputBufferLimit)
+		if !ok {
+			return limits
+		}
+		params, err := parseClientOutputBufferLimits(param)
+		if err != nil {
+			return limits
+		}
+		for class, limit := range params {
+			limits[class] = limit
+		}
+		return limits
+	}).(map[ClientType]ClientOutputBufferLimit)
+}
+
+// parseClientOutputBufferLimits parses the client output buffer limits such as "pubsub 32mb 8mb 60".
+func parseClientOutputBufferLimits(param string) (map[ClientType]ClientOutputBufferLimit, error) {
+	fields := strings.Fields(param)
+	if len(fields)%4 != 0 {
+		return nil, fmt.Errorf("%w %s (%s)", ErrInvalid, clientOutputBufferLimitConfig, param)
+	}
+	limits := map[ClientType]ClientOutputBufferLimit{}
+	for n := 0; n < len(fields); n += 4 {
+		var class ClientType
+		switch strings.ToLower(fields[n]) {
+		case NormalClient.String():
+			class = NormalClient
+		case ReplicaClient.String(), "slave":
+			class = ReplicaClient
+		case PubSubClient.String():
+			class = PubSubClient
+		default:
+			return nil, fmt.Errorf("%w client class (%s)", ErrInvalid, fields[n])
+		}
+		hard, err := parseMemorySize(fields[n+1])
+		if err != nil {
+			return nil, err
+		}
+		soft, err := parseMemorySize(fields[n+2])
+		if err != nil {
+			return nil, err
+		}
+		secs, err := strconv.Atoi(fields[n+3])
+		if err != nil || secs < 0 {
+			return nil, fmt.Errorf("%w soft limit seconds (%s)", ErrInvalid, fields[n+3])
+		}
+		limits[class] = ClientOutputBufferLimit{
+			HardLimit:   hard,
+			SoftLimit:   soft,
+			SoftSeconds: secs,
+		}
+	}
+	return limits, nil
+}
+
+// configBoolean returns the specified yes or no parameter, or the default value if the parameter is not set or invalid.
+func (cfg *ServerConfig) configBoolean(key string, defaultValue bool) bool {
+	param, ok := cfg.ConfigParameter(key)
+	if !ok {
+		return defaultValue
+	}
+	switch strings.ToLower(param) {
+	case "yes":
+		return true
+	case "no":
+		return false
+	}
+	return defaultValue
+}
+
+// formatBoolean returns the yes or no parameter of the specified value.
+func formatBoolean(val bool) string {
+	if val {
+		return "yes"
+	}
+	return "no"
+}
+
+// configInteger returns the specified integer parameter, or the default value if the parameter is not set or invalid.
+func (cfg *ServerConfig) configInteger(key string, defaultValue int) int {
+	param, ok := cfg.ConfigParameter(key)
+	if !ok {
+		return defaultValue
+	}
+	val, err := strconv.Atoi(param)
+	if err != nil {
+		return defaultValue
+	}
+	return val
+}
+
+// SetRenameCommand renames the specified command to the new name, or disables it if the new name is empty.
+// The renamed commands are applied when the server starts.
+func (cfg *ServerConfig) SetRenameCommand(name string, newName string) {
+	cfg.AppendConfig(renameCommandConfig, joinConfigArgs([]string{name, newName}))
+}
+
+// ConfigRenameCommands returns the renamed commands in the specified order.
+func (cfg *ServerConfig) ConfigRenameCommands() []*RenamedCommand {
+	param, ok := cfg.ConfigParameter(renameCommandConfig)
+	if !ok {
+		return []*RenamedCommand{}
+	}
+	args, err := splitConfigArgs(param)
+	if err != nil {
+		return []*RenamedCommand{}
+	}
+	cmds := []*RenamedCommand{}
+	for n := 0; n+1 < len(args); n += 2 {
+		cmds = append(cmds, &RenamedCommand{
+			Name:    strings.ToUpper(args[n]),
+			NewName: strings.ToUpper(args[n+1]),
+		})
+	}
+	return cmds
+}
+
+// SetReplicaOf sets the address of the master to replicate. An empty host makes the server a master.
+// The master is applied when the server starts, use REPLICAOF command to change the master at runtime.
+func (cfg *ServerConfig) SetReplicaOf(host string, port int) {
+	if len(host) == 0 {
+		cfg.RemoveConfig(replicaOfConfig)
+		return
+	}
+	cfg.SetConfig(replicaOfConfig, host+ConfigSep+strconv.Itoa(port))
+}
+
+// ConfigReplicaOf returns the address of the master to replicate. It returns false if the server is a master.
+func (cfg *ServerConfig) ConfigReplicaOf() (string, int, bool) {
+	param, ok := cfg.ConfigParameter(replicaOfConfig)
+	if !ok || validateReplicaOf(param) != nil {
+		return "", 0, false
+	}
+	fields := strings.Fields(param)
+	if len(fields) != 2 {
+		return "", 0, false
+	}
+	port, _ := strconv.Atoi(fields[1])
+	return fields[0], port, true
+}
+
+// SetReplicaReadOnly sets the read-only mode which rejects write commands from the clients of the replicas.
+func (cfg *ServerConfig) SetReplicaReadOnly(enabled bool) {
+	cfg.SetConfig(replicaReadOnlyConfig, formatBoolean(enabled))
+}
+
+// ConfigReplicaReadOnly returns true if the replicas reject write commands from the clients.
+func (cfg *ServerConfig) ConfigReplicaReadOnly() bool {
+	return cfg.configBoolean(replicaReadOnlyConfig, DefaultReplicaReadOnly)
+}
+
+// SetReplBacklogSize sets the size of the replication backlog in bytes.
+func (cfg *ServerConfig) SetReplBacklogSize(size int64) {
+	cfg.SetConfig(replBacklogSizeConfig, strconv.FormatInt(size, 10))
+}
+
+// ConfigReplBacklogSize returns the size of the replication backlog in bytes.
+func (cfg *ServerConfig) ConfigReplBacklogSize() int64 {
+	return int64(cfg.configInteger(replBacklogSizeConfig, DefaultReplBacklogSize))
+}
+
+// SetReplPingReplicaPeriod sets the period of the pings from the master to the replicas in seconds.
+func (cfg *ServerConfig) SetReplPingReplicaPeriod(sec int) {
+	cfg.SetConfig(replPingReplicaPeriodConfig, strconv.Itoa(sec))
+}
+
+// ConfigReplPingReplicaPeriod returns the period of the pings from the master to the replicas in seconds.
+func (cfg *ServerConfig) ConfigReplPingReplicaPeriod() int {
+	return cfg.configInteger(replPingReplicaPeriodConfig, DefaultReplPingReplicaPeriod)
+}
+
+// SetReplTimeout sets the timeout in seconds to detect the broken replication links.
+func (cfg *ServerConfig) SetReplTimeout(sec int) {
+	cfg.SetConfig(replTimeoutConfig, strconv.Itoa(sec))
+}
+
+// ConfigReplTimeout returns the timeout in seconds to detect the broken replication links.
+func (cfg *ServerConfig) ConfigReplTimeout() int {
+	return cfg.configInteger(replTimeoutConfig, DefaultReplTimeout)
+}
+
+// SetMasterAuth sets the password to authenticate with the master.
+func (cfg *ServerConfig) SetMasterAuth(password string) {
+	cfg.SetConfig(masterAuthConfig, password)
+}
+
+// ConfigMasterAuth returns the password to authenticate with the master.
+func (cfg *ServerConfig) ConfigMasterAuth() (string, bool) {
+	passwd, ok := cfg.ConfigParameter(masterAuthConfig)
+	if !ok || len(passwd) == 0 {
+		return "", false
+	}
+	return passwd, true
+}
+
+// SetClusterEnabled sets the cluster mode which redirects the commands to the nodes serving the hash slots of the keys.
+func (cfg *ServerConfig) SetClusterEnabled(enabled bool) {
+	cfg.SetConfig(clusterEnabledConfig, formatBoolean(enabled))
+}
+
+// ConfigClusterEnabled returns true if the cluster mode is enabled.
+func (cfg *ServerConfig) ConfigClusterEnabled() bool {
+	return cfg.configBoolean(clusterEnabledConfig, false)
+}
+
+// SetBusyReplyThreshold sets the execution time of the scripts in milliseconds to reply BUSY to the other clients.
+func (cfg *ServerConfig) SetBusyReplyThreshold(msec int) {
+	cfg.SetConfig(busyReplyThresholdConfig, strconv.Itoa(msec))
+}
+
+// ConfigBusyReplyThreshold returns the execution time of the scripts in milliseconds to reply BUSY to the other clients.
+func (cfg *ServerConfig) ConfigBusyReplyThreshold() int {
+	return cfg.configInteger(busyReplyThresholdConfig, DefaultBusyReplyThreshold)
+}
@@ -0,0 +1,67 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClientOutputBufferLimit(t *testing.T) {
+	cfg := NewDefaultServerConfig()
+	cfg.SetConfig(clientOutputBufferLimitConfig, "pubsub 64mb 16mb 30")
+
+	limits := []struct {
+		t        ClientType
+		expected ClientOutputBufferLimit
+	}{
+		{NormalClient, ClientOutputBufferLimit{HardLimit: 0, SoftLimit: 0, SoftSeconds: 0}},
+		{MonitorClient, ClientOutputBufferLimit{HardLimit: 0, SoftLimit: 0, SoftSeconds: 0}},
+		{ReplicaClient, ClientOutputBufferLimit{HardLimit: 256 * 1024 * 1024, SoftLimit: 64 * 1024 * 1024, SoftSeconds: 60}},
+		{PubSubClient, ClientOutputBufferLimit{HardLimit: 64 * 1024 * 1024, SoftLimit: 16 * 1024 * 1024, SoftSeconds: 30}},
+	}
+	for _, limit := range limits {
+		t.Run(limit.t.String(), func(t *testing.T) {
+			val := cfg.ConfigClientOutputBufferLimit(limit.t)
+			if val != limit.expected {
+				t.Errorf("%v != %v", val, limit.expected)
+			}
+		})
+	}
+
+	cfg.SetClientOutputBufferLimit(NormalClient, ClientOutputBufferLimit{HardLimit: 1024, SoftLimit: 512, SoftSeconds: 10})
+	param, _ := cfg.ConfigParameter(clientOutputBufferLimitConfig)
+	expected := "normal 1kb 512 10 replica 256mb 64mb 60 pubsub 64mb 16mb 30"
+	if param != expected {
+		t.Errorf("%s != %s", param, expected)
+	}
+
+	// The limits are parsed once per configuration change.
+	limits1 := cfg.configClientOutputBufferLimits()
+	limits2 := cfg.configClientOutputBufferLimits()
+	if reflect.ValueOf(limits1).Pointer() != reflect.ValueOf(limits2).Pointer() {
+		t.Errorf("limits are parsed again")
+	}
+	if val := cfg.ConfigClientOutputBufferLimit(NormalClient); val.HardLimit != 1024 {
+		t.Errorf("%d != %d", val.HardLimit, 1024)
+	}
+	cfg.SetClientOutputBufferLimit(NormalClient, ClientOutputBufferLimit{HardLimit: 2048, SoftLimit: 0, SoftSeconds: 0})
+	if val := cfg.ConfigClientOutputBufferLimit(NormalClient); val.HardLimit != 2048 {
+		t.Errorf("%d != %d", val.HardLimit, 2048)
+	}
+	if limits1[NormalClient].HardLimit != 1024 {
+		t.Errorf("cached limits are modified")
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"time"
+)
+
+// FunctionLoad loads the specified library code and returns the library name.
+// The existing library of the same name is replaced only if replace is true.
+func (server *Server) FunctionLoad(conn *Conn, code string, replace bool) (*Message, error) {
+	lib, err := newLuaLibrary(code)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"LOAD"}
+	if replace {
+		args = append(args, "REPLACE")
+	}
+	args = append(args, code)
+	err = server.writeFunctions(conn, args, func() error {
+		return server.libraries.load([]*luaLibrary{lib}, replace)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return NewBulkMessage(lib.name), nil
+}
+
+// FunctionDelete deletes the library of the specified name and its functions.
+func (server *Server) FunctionDelete(conn *Conn, name string) (*Message, error) {
+	err := server.writeFunctions(conn, []string{"DELETE", name}, func() error {
+		return server.libraries.remove(name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return NewOKMessage(), nil
+}
+
+// FunctionList returns the libraries which match the specified glob-style pattern with their functions.
+func (server *Server) FunctionList(conn *Conn, pattern string, withCode bool) (*Message, error) {
+	libs, err := server.libraries.list(pattern)
+	if err != nil {
+		return nil, err
+	}
+	msg := NewArrayMessage()
+	for _, lib := range libs {
+		funcsMsg := NewArrayMessage()
+		for _, fn := range lib.functions {
+			fnMsg := newFieldsMessage("name", fn.name)
+			fnMsg.Append(NewBulkMessage("description"))
+			if 0 < len(fn.description) {
+				fnMsg.Append(NewBulkMessage(fn.description))
+			} else {
+				fnMsg.Append(NewNilMessage())
+			}
+			fnMsg.Append(NewBulkMessage("flags"))
+			fnMsg.Append(NewStringArrayMessage(fn.flags))
+			funcsMsg.Append(fnMsg)
+		}
+		libMsg := newFieldsMessage("library_name", lib.name, "engine", FunctionEngine)
+		libMsg.Append(NewBulkMessage("functions"))
+		libMsg.Append(funcsMsg)
+		if withCode {
+			libMsg.Append(NewBulkMessage("library_code"))
+			libMsg.Append(NewBulkMessage(lib.code))
+		}
+		msg.Append(libMsg)
+	}
+	return msg, nil
+}
+
+// FunctionDump returns the serialized payload of all libraries.
+func (server *Server) FunctionDump(conn *Conn) (*Message, error) {
+	libs, err := server.libraries.list("*")
+	if err != nil {
+		return nil, err
+	}
+	payload, err := dumpLibraries(libs)
+	if err != nil {
+		return nil, err
+	}
+	return NewBulkMessage(string(payload)), nil
+}
+
+// FunctionRestore restores the libraries of the specified payload with the specified policy such as FLUSH, APPEND and REPLACE.
+func (server *Server) FunctionRestore(conn *Conn, payload string, policy string) (*Message, error) {
+	libs, err := loadLibraries([]byte(payload))
+	if err != nil {
+		return nil, err
+	}
+	err = server.writeFunctions(conn, []string{"RESTORE", payload, policy}, func() error {
+		return server.libraries.restore(libs, policy)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return NewOKMessage(), nil
+}
+
+// FunctionFlush deletes all libraries.
+func (server *Server) FunctionFlush(conn *Conn) (*Message, error) {
+	err := server.writeFunctions(conn, []string{"FLUSH"}, func() error {
+		server.libraries.flush()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return NewOKMessage(), nil
+}
+
+// FunctionKill stops the running function which has not executed write commands.
+func (server *Server) FunctionKill(conn *Conn) (*Message, error) {
+	return server.ScriptKill(conn)
+}
+
+// FunctionStats returns the running script and the number of the libraries and the functions.
+func (server *Server) FunctionStats(conn *Conn) (*Message, error) {
+	msg := NewArrayMessage()
+	msg.Append(NewBulkMessage("running_script"))
+	s := server.scripting
+	s.Lock()
+	if running := s.running; running != nil {
+		runningMsg := newFieldsMessage("name", running.name, "command")
+		runningMsg.Append(NewStringArrayMessage(running.command))
+		runningMsg.Append(NewBulkMessage("duration_ms"))
+		runningMsg.Append(NewIntegerMessage(int(time.Since(running.startTime).Milliseconds())))
+		msg.Append(runningMsg)
+	} else {
+		msg.Append(NewNilMessage())
+	}
+	s.Unlock()
+
+	numLibs, numFuncs := server.libraries.counts()
+	countsMsg := newFieldsMessage("libraries_count")
+	countsMsg.Append(NewIntegerMessage(numLibs))
+	countsMsg.Append(NewBulkMessage("functions_count"))
+	countsMsg.Append(NewIntegerMessage(numFuncs))
+	enginesMsg := newFieldsMessage(FunctionEngine)
+	enginesMsg.Append(countsMsg)
+	msg.Append(NewBulkMessage("engines"))
+	msg.Append(enginesMsg)
+	return msg, nil
+}
+
+// FCall runs the function of the specified name with the specified keys and arguments.
+// The read-only calls can run only the functions which have the no-writes flag.
+func (server *Server) FCall(conn *Conn, name string, keys []string, args []string, readOnly bool) (*Message, error) {
+	lib, fn, ok := server.libraries.lookup(name)
+	if !ok {
+		return nil, ErrNoSuchFunction
+	}
+	if readOnly && !fn.noWrites {
+		return nil, ErrFunctionWriteFlag
+	}
+	return server.callFunction(conn, lib, fn, keys, args, readOnly)
+}
+
+// writeFunctions runs the specified function which modifies the libraries as a write command,
+// and propagates the specified FUNCTION arguments to the replicas.
+func (server *Server) writeFunctions(conn *Conn, args []string, write func() error) error {
+	if server.isReadOnlyReplica(conn) {
+		return ErrReadOnly
+	}
+	// The commands from the master are applied holding the write lock.
+	if conn.ClientType() != MasterClient {
+		server.writeMutex.Lock()
+		defer server.writeMutex.Unlock()
+	}
+	if err := write(); err != nil {
+		return err
+	}
+	argMsgs := make([]*Message, len(args))
+	for n, arg := range args {
+		argMsgs[n] = NewBulkMessage(arg)
+	}
+	server.propagate(conn, "FUNCTION", argMsgs)
+	return nil
+}
@@ -15,7 +15,9 @@
 package redis
 
 import (
+	"errors"
 	"strings"
+	"time"
 
 	"github.com/cybergarage/go-redis/redis/proto"
 )
@@ -24,26 +26,200 @@ type Arguments = *proto.Array
 type Executor func(*Conn, string, Arguments) (*Message, error)
 type Executors map[string]Executor
 
-// executeCommand handles a client command message.
+// Middleware wraps the next executor to intercept command executions.
+type Middleware func(next Executor) Executor
+
+// executeCommand executes a command without the middlewares such as the commands of the scripts and the master.
 func (server *Server) executeCommand(conn *Conn, cmd string, args Arguments) (*Message, error) {
-	if server.userCommandHandler == nil {
+	return server.executeCommandWith(conn, cmd, args, server.runCommand)
+}
+
+// executeCommandWith checks whether the specified command is executable on the connection, and then executes it
+// with the specified executor chain which ends with runCommand. The chain gets a fresh copy of the arguments.
+func (server *Server) executeCommandWith(conn *Conn, cmd string, args Arguments, chain Executor) (*Message, error) {
+	sentinelEnabled := server.IsSentinelEnabled()
+	argMsgs := args.PeekMessages()
+
+	upperCmd := strings.ToUpper(cmd)
+	_, ok, _ := server.lookupExecutor(upperCmd)
+	info, hasInfo := server.commandTable.LookupCommandInfo(upperCmd)
+	if !ok || (sentinelEnabled && !sentinelCommands[upperCmd]) {
+		return nil, newUnknownCommandError(cmd, messageStrings(argMsgs))
+	}
+
+	conn.StartSpan(upperCmd)
+	defer conn.FinishSpan()
+
+	statName := commandStatName(upperCmd, hasInfo)
+	if !hasInfo {
+		info = newDefaultCommandInfo(upperCmd)
+	}
+
+	if !info.IsValidArity(len(argMsgs) + 1) {
+		server.commandStats.RecordRejectedCall(statName)
+		return nil, newWrongNumberOfArgumentsError(cmd)
+	}
+
+	if !conn.IsAuthrized() && !info.HasFlag(NoAuthFlag) {
+		server.commandStats.RecordRejectedCall(statName)
+		return nil, ErrNotAuthrized
+	}
+
+	server.feedMonitors(conn, cmd, argMsgs)
+
+	if server.isPubSubContext(conn) && !pubSubContextCommands[upperCmd] {
+		server.commandStats.RecordRejectedCall(statName)
+		return nil, newPubSubContextError(cmd)
+	}
+
+	if err := server.clusterRedirection(conn, info, cmd, argMsgs); err != nil {
+		server.commandStats.RecordRejectedCall(statName)
+		return nil, err
+	}
+
+	if info.IsWrite() && !server.isFallbackCommand(upperCmd) && server.isReadOnlyReplica(conn) {
+		server.commandStats.RecordRejectedCall(statName)
+		return nil, ErrReadOnly
+	}
+
+	// The commands read before the client closes the connection are still executed unless the server is stopped.
+	if err := conn.ctx.Err(); err != nil {
+		server.commandStats.RecordRejectedCall(statName)
+		return nil, err
+	}
+
+	if err := server.waitScript(conn, info, argMsgs); err != nil {
+		server.commandStats.RecordRejectedCall(statName)
+		return nil, err
+	}
+
+	return chain(conn, cmd, newArguments(argMsgs))
+}
+
+// runCommand runs the specified command with the executor, which is the innermost executor of the middlewares.
+// The command may be rewritten by the middlewares, and the write commands are replicated as they are executed here.
+func (server *Server) runCommand(conn *Conn, cmd string, args Arguments) (*Message, error) {
+	if server.userCommandHandler == nil && server.fallbackExecutor == nil && !server.IsSentinelEnabled() {
 		return NewErrorNotSupportedMessage(cmd), nil
 	}
 
+	argMsgs := args.PeekMessages()
+
 	upperCmd := strings.ToUpper(cmd)
-	cmdExecutor, ok := server.commandExecutors[upperCmd]
+	cmdExecutor, ok, isFallback := server.lookupExecutor(upperCmd)
 	if !ok {
-		return NewErrorNotSupportedMessage(cmd), nil
+		return nil, newUnknownCommandError(cmd, messageStrings(argMsgs))
+	}
+	info, hasInfo := server.commandTable.LookupCommandInfo(upperCmd)
+	statName := commandStatName(upperCmd, hasInfo)
+	if !hasInfo {
+		info = newDefaultCommandInfo(upperCmd)
 	}
 
-	conn.StartSpan(upperCmd)
-	defer conn.FinishSpan()
+	// Serializes the write commands to keep the order of the replication stream. The scripts and the commands of
+	// the master are run holding the write lock, and the fallback executor such as the proxy writes to its own upstream servers.
+	isPropagated := info.IsWrite() && !isFallback && !conn.IsScripting() && conn.ClientType() != MasterClient
+	if isPropagated {
+		server.writeMutex.Lock()
+		defer server.writeMutex.Unlock()
+	}
+
+	// Scripts run exclusively with the data commands to be atomic.
+	if info.IsUserCommand() && !conn.IsScripting() {
+		server.scriptMutex.RLock()
+		defer server.scriptMutex.RUnlock()
+	}
+
+	startTime := time.Now()
+	msg, err := cmdExecutor(conn, cmd, args)
+	execTime := time.Since(startTime)
+	isFailed := isFailedCommandResult(msg, err)
+	server.commandStats.RecordCall(statName, execTime, isFailed)
+	server.logSlowCommand(conn, cmd, argMsgs, startTime, execTime)
+
+	if isPropagated && !isFailed {
+		server.propagate(conn, cmd, argMsgs)
+	}
+
+	return msg, err
+}
+
+// commandStatName returns the name to record the statistics of the specified command. The commands which are not
+// in the command table such as the commands passed to the fallback executor are recorded together not to grow
+// the statistics by the command names which the clients send.
+func commandStatName(upperCmd string, hasInfo bool) string {
+	if !hasInfo {
+		return UnknownCommandStatName
+	}
+	return upperCmd
+}
+
+// withArgumentsCopy returns the executor which passes a fresh copy of the arguments to the specified executor,
+// so that the middlewares can read the arguments without consuming them for the next executor.
+func withArgumentsCopy(next Executor) Executor {
+	return func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		return next(conn, cmd, args.Clone())
+	}
+}
 
-	if !conn.IsAuthrized() {
-		if upperCmd != "AUTH" {
-			return nil, ErrNotAuthrized
-		}
+// newArguments returns the arguments of the specified messages.
+func newArguments(msgs []*Message) Arguments {
+	args := proto.NewArray()
+	for _, msg := range msgs {
+		args.Append(msg)
 	}
+	return args
+}
+
+// lookupExecutor returns the executor of the specified command, and true if the fallback executor runs it.
+func (server *Server) lookupExecutor(upperCmd string) (Executor, bool, bool) {
+	if server.isFallbackCommand(upperCmd) {
+		return server.fallbackExecutor, true, true
+	}
+	executor, ok := server.commandExecutors[upperCmd]
+	return executor, ok, false
+}
+
+// isFallbackCommand returns true if the specified command is run by the fallback executor.
+func (server *Server) isFallbackCommand(upperCmd string) bool {
+	if server.fallbackExecutor == nil || server.IsSentinelEnabled() {
+		return false
+	}
+	if _, ok := server.commandExecutors[upperCmd]; !ok {
+		return true
+	}
+	info, ok := server.commandTable.LookupCommandInfo(upperCmd)
+	return server.userCommandHandler == nil && ok && info.IsUserCommand()
+}
 
-	return cmdExecutor(conn, cmd, args)
+// logSlowCommand adds the specified command into the slow log if the execution time exceeds the threshold.
+func (server *Server) logSlowCommand(conn *Conn, cmd string, argMsgs []*Message, startTime time.Time, execTime time.Duration) {
+	threshold := server.ConfigSlowlogLogSlowerThan()
+	if threshold < 0 || execTime.Microseconds() < int64(threshold) {
+		return
+	}
+	args := append([]string{cmd}, messageStrings(argMsgs)...)
+	clientAddr := ""
+	if conn.Conn != nil {
+		clientAddr = conn.RemoteAddr().String()
+	}
+	server.slowLog.Push(server.ConfigSlowlogMaxLen(), startTime, execTime, args, clientAddr, conn.Name())
+}
+
+// messageStrings returns the string representations of the specified argument messages.
+func messageStrings(msgs []*Message) []string {
+	strs := make([]string, len(msgs))
+	for n, msg := range msgs {
+		msgBytes, _ := msg.Bytes()
+		strs[n] = string(msgBytes)
+	}
+	return strs
+}
+
+// isFailedCommandResult returns true if the specified command result is an error.
+func isFailedCommandResult(msg *Message, err error) bool {
+	if err != nil {
+		return !errors.Is(err, ErrQuit)
+	}
+	return msg != nil && msg.IsError()
 }
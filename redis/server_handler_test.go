@@ -0,0 +1,345 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cybergarage/go-redis/redis/proto"
+)
+
+func TestServerMiddlewares(t *testing.T) {
+	server := NewServer()
+	server.SetRequirePass("password")
+	store := &stringStore{UserCommandHandler: nil, values: map[string]string{}}
+	server.SetCommandHandler(store)
+
+	calls := []string{}
+	newMiddleware := func(name string) Middleware {
+		return func(next Executor) Executor {
+			return func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+				calls = append(calls, name+":"+cmd)
+				return next(conn, cmd, args)
+			}
+		}
+	}
+	server.Use(newMiddleware("first"), newMiddleware("second"))
+	// The last middleware reads the arguments, and passes them to the executor as they are.
+	server.Use(func(next Executor) Executor {
+		return func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+			key, err := args.NextString()
+			if err != nil {
+				return nil, err
+			}
+			calls = append(calls, "last:"+cmd+":"+key)
+			return next(conn, cmd, args)
+		}
+	})
+
+	newArray := func(args ...string) *proto.Array {
+		array := proto.NewArray()
+		for _, arg := range args {
+			array.Append(NewBulkMessage(arg))
+		}
+		return array
+	}
+	conn := newConnWith(nil)
+	conn.SetSpanContext(server.Tracer.StartSpan(PackageName))
+
+	// The unauthorized and invalid commands are rejected before the middlewares.
+	if _, err := server.handleArrayMessage(conn, newArray("SET", "key", "value")); !errors.Is(err, ErrNotAuthrized) {
+		t.Errorf("%v != %v", err, ErrNotAuthrized)
+	}
+	conn.SetAuthrized(true)
+	if _, err := server.handleArrayMessage(conn, newArray("SET", "key")); err == nil {
+		t.Errorf("invalid arity command is executed")
+	}
+	if len(calls) != 0 {
+		t.Errorf("%v is called", calls)
+	}
+
+	msg, err := server.handleArrayMessage(conn, newArray("SET", "key", "value"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if str, _ := msg.String(); str != OK {
+		t.Errorf("%s != %s", str, OK)
+	}
+	if store.values["key"] != "value" {
+		t.Errorf("%s != %s", store.values["key"], "value")
+	}
+
+	expected := "first:SET,second:SET,last:SET:key"
+	if strings.Join(calls, ",") != expected {
+		t.Errorf("%s != %s", strings.Join(calls, ","), expected)
+	}
+}
+
+func TestServerRenameCommand(t *testing.T) {
+	server := NewServer()
+	server.SetRenameCommand("config", "")
+	server.SetRenameCommand("keys", "keys-renamed")
+	if err := server.loadCommandRenames(); err != nil {
+		t.Error(err)
+		return
+	}
+	server.Use(func(next Executor) Executor {
+		return func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+			return NewStringMessage(cmd), nil
+		}
+	})
+
+	execCommand := func(cmd string) (*Message, error) {
+		array := proto.NewArray()
+		array.Append(NewBulkMessage(cmd))
+		array.Append(NewBulkMessage("*"))
+		conn := newConnWith(nil)
+		conn.SetSpanContext(server.Tracer.StartSpan(PackageName))
+		conn.SetAuthrized(true)
+		return server.handleArrayMessage(conn, array)
+	}
+
+	msg, err := execCommand("keys-renamed")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if str, _ := msg.String(); str != "KEYS" {
+		t.Errorf("%s != %s", str, "KEYS")
+	}
+
+	for _, cmd := range []string{"KEYS", "config"} {
+		if _, err := execCommand(cmd); !errors.Is(err, ErrUnknownCommand) {
+			t.Errorf("%s: %v", cmd, err)
+		}
+	}
+
+	names := map[string]bool{}
+	for _, info := range server.clientCommandInfos() {
+		names[info.Name] = true
+	}
+	for name, expected := range map[string]bool{"KEYS-RENAMED": true, "KEYS": false, "CONFIG": false, "PING": true} {
+		if names[name] != expected {
+			t.Errorf("%s: %t != %t", name, names[name], expected)
+		}
+	}
+	if _, ok := server.lookupClientCommandInfo("keys"); ok {
+		t.Errorf("KEYS is visible")
+	}
+
+	server.SetRenameCommand("ping", "keys-renamed")
+	if err := server.loadCommandRenames(); !errors.Is(err, ErrCommandExists) {
+		t.Error(err)
+	}
+	server.RemoveConfig(renameCommandConfig)
+	server.SetRenameCommand("no-such-command", "")
+	if err := server.loadCommandRenames(); !errors.Is(err, ErrNoSuchCommand) {
+		t.Error(err)
+	}
+}
+
+func TestServerFallbackExecutor(t *testing.T) {
+	server := NewServer()
+	server.SetFallbackExecutor(func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		return NewStringMessage(strings.ToLower(cmd)), nil
+	})
+
+	for _, cmd := range []string{"GET", "NOCOMMAND"} {
+		array := proto.NewArray()
+		array.Append(NewBulkMessage(cmd))
+		array.Append(NewBulkMessage("key"))
+		conn := newConnWith(nil)
+		conn.SetSpanContext(server.Tracer.StartSpan(PackageName))
+		conn.SetAuthrized(true)
+		msg, err := server.handleArrayMessage(conn, array)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if str, _ := msg.String(); str != strings.ToLower(cmd) {
+			t.Errorf("%s != %s", str, strings.ToLower(cmd))
+		}
+	}
+}
+
+func TestServerFallbackWriteCommand(t *testing.T) {
+	server := NewServer()
+	server.replication.backlog = newReplicationBacklog(server.ConfigReplBacklogSize(), 0)
+	server.SetFallbackExecutor(func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		// The forwarded write commands must not hold the write lock.
+		if !server.writeMutex.TryLock() {
+			t.Errorf("%s holds the write lock", cmd)
+		} else {
+			server.writeMutex.Unlock()
+		}
+		return NewOKMessage(), nil
+	})
+
+	array := proto.NewArray()
+	for _, arg := range []string{"SET", "key", "value"} {
+		array.Append(NewBulkMessage(arg))
+	}
+	conn := newConnWith(nil)
+	conn.SetSpanContext(server.Tracer.StartSpan(PackageName))
+	conn.SetAuthrized(true)
+	if _, err := server.handleArrayMessage(conn, array); err != nil {
+		t.Error(err)
+	}
+	if offset := server.replication.offset; offset != 0 {
+		t.Errorf("forwarded write command is propagated (%d)", offset)
+	}
+}
+
+type stringStore struct {
+	UserCommandHandler
+	values map[string]string
+}
+
+func (store *stringStore) Set(conn *Conn, key string, val string, opt SetOption) (*Message, error) {
+	store.values[key] = val
+	return NewOKMessage(), nil
+}
+
+func TestServerReplicaWriteCommand(t *testing.T) {
+	server := NewServer()
+	store := &stringStore{UserCommandHandler: nil, values: map[string]string{}}
+	server.SetCommandHandler(store)
+	server.replication.link = &replicaLink{
+		host:   "localhost",
+		port:   DefaultPort,
+		state:  atomic.Int32{},
+		lastIO: atomic.Int64{},
+		cancel: nil,
+		done:   nil,
+	}
+
+	newSetArray := func() *proto.Array {
+		array := proto.NewArray()
+		for _, arg := range []string{"SET", "key", "value"} {
+			array.Append(NewBulkMessage(arg))
+		}
+		return array
+	}
+
+	// The unauthorized clients are rejected before the read-only check.
+	conn := newConnWith(nil)
+	conn.SetSpanContext(server.Tracer.StartSpan(PackageName))
+	if _, err := server.handleArrayMessage(conn, newSetArray()); !errors.Is(err, ErrNotAuthrized) {
+		t.Errorf("%v != %v", err, ErrNotAuthrized)
+	}
+	conn.SetAuthrized(true)
+	if _, err := server.handleArrayMessage(conn, newSetArray()); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("%v != %v", err, ErrReadOnly)
+	}
+
+	// The middlewares can't veto the writes of the master.
+	server.Use(func(next Executor) Executor {
+		return func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+			return nil, ErrNotSupported
+		}
+	})
+	masterConn := newConnWith(nil)
+	masterConn.SetClientType(MasterClient)
+	masterConn.SetAuthrized(true)
+	server.applyMasterCommand(masterConn, newSetArray(), []byte{})
+	if store.values["key"] != "value" {
+		t.Errorf("%s != %s", store.values["key"], "value")
+	}
+}
+
+func TestServerPropagateRewrittenCommand(t *testing.T) {
+	server := NewServer()
+	store := &stringStore{UserCommandHandler: nil, values: map[string]string{}}
+	server.SetCommandHandler(store)
+	server.replication.backlog = newReplicationBacklog(server.ConfigReplBacklogSize(), 0)
+
+	newArray := func(args ...string) *proto.Array {
+		array := proto.NewArray()
+		for _, arg := range args {
+			array.Append(NewBulkMessage(arg))
+		}
+		return array
+	}
+	// The middleware rewrites PING into SET, and SET into ECHO.
+	server.Use(func(next Executor) Executor {
+		return func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+			switch strings.ToUpper(cmd) {
+			case "PING":
+				return next(conn, "SET", newArray("key", "value"))
+			case "SET":
+				return next(conn, "ECHO", newArray("value"))
+			}
+			return next(conn, cmd, args)
+		}
+	})
+
+	conn := newConnWith(nil)
+	conn.SetSpanContext(server.Tracer.StartSpan(PackageName))
+	conn.SetAuthrized(true)
+
+	if _, err := server.handleArrayMessage(conn, newArray("SET", "key", "value")); err != nil {
+		t.Error(err)
+	}
+	if offset := server.ReplicationOffset(); offset != 0 || len(store.values) != 0 {
+		t.Errorf("SET rewritten into ECHO is propagated (%d)", offset)
+	}
+	if _, err := server.handleArrayMessage(conn, newArray("PING")); err != nil {
+		t.Error(err)
+	}
+	if offset := server.ReplicationOffset(); offset == 0 || store.values["key"] != "value" {
+		t.Errorf("PING rewritten into SET is not propagated (%d)", offset)
+	}
+}
+
+func TestServerCommandStatsUnknownCommand(t *testing.T) {
+	server := NewServer()
+	server.SetFallbackExecutor(func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		return NewOKMessage(), nil
+	})
+
+	conn := newConnWith(nil)
+	conn.SetSpanContext(server.Tracer.StartSpan(PackageName))
+	conn.SetAuthrized(true)
+	for _, cmd := range []string{"GET", "UNKNOWN1", "UNKNOWN2"} {
+		array := proto.NewArray()
+		array.Append(NewBulkMessage(cmd))
+		array.Append(NewBulkMessage("key"))
+		if _, err := server.handleArrayMessage(conn, array); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// The commands which are not in the command table are recorded together.
+	stats := server.CommandStats()
+	for name, expected := range map[string]int64{"get": 1, UnknownCommandStatName: 2} {
+		stat, ok := stats.CommandStat(name)
+		if !ok {
+			t.Errorf("%s is not recorded", name)
+			continue
+		}
+		if stat.Calls != expected {
+			t.Errorf("%s: %d != %d", name, stat.Calls, expected)
+		}
+	}
+	for _, name := range []string{"unknown1", "unknown2"} {
+		if _, ok := stats.CommandStat(name); ok {
+			t.Errorf("%s is recorded", name)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	InfoServerSection       = "server"
+	InfoClientsSection      = "clients"
+	InfoStatsSection        = "stats"
+	InfoReplicationSection  = "replication"
+	InfoCommandStatsSection = "commandstats"
+	InfoLatencyStatsSection = "latencystats"
+	InfoClusterSection      = "cluster"
+	InfoKeyspaceSection     = "keyspace"
+	InfoSentinelSection     = "sentinel"
+	infoDefaultSections     = "default"
+	infoAllSections         = "all"
+	infoEverythingSections  = "everything"
+	infoLineSep             = "\r\n"
+)
+
+// infoSection represents a section of INFO command.
+type infoSection struct {
+	name      string
+	title     string
+	isDefault bool
+	lines     func(server *Server) []string
+}
+
+// infoSections returns the all INFO sections in the output order.
+func infoSections() []*infoSection {
+	return []*infoSection{
+		{InfoServerSection, "Server", true, (*Server).serverInfo},
+		{InfoClientsSection, "Clients", true, (*Server).clientsInfo},
+		{InfoStatsSection, "Stats", true, (*Server).statsInfo},
+		{InfoReplicationSection, "Replication", true, (*Server).replicationInfo},
+		{InfoCommandStatsSection, "Commandstats", false, (*Server).commandStatsInfo},
+		{InfoLatencyStatsSection, "Latencystats", false, (*Server).latencyStatsInfo},
+		{InfoClusterSection, "Cluster", true, (*Server).clusterInfo},
+		{InfoKeyspaceSection, "Keyspace", true, (*Server).keyspaceInfo},
+		{InfoSentinelSection, "Sentinel", true, (*Server).sentinelInfo},
+	}
+}
+
+// InfoString returns the INFO string of the specified sections.
+func (server *Server) InfoString(sectionNames []string) string {
+	selected := map[string]bool{}
+	isDefault := len(sectionNames) == 0
+	isAll := false
+	for _, name := range sectionNames {
+		name = strings.ToLower(name)
+		switch name {
+		case infoDefaultSections:
+			isDefault = true
+		case infoAllSections, infoEverythingSections:
+			isAll = true
+		default:
+			selected[name] = true
+		}
+	}
+
+	var info strings.Builder
+	for _, section := range infoSections() {
+		if !isAll && !selected[section.name] && !(isDefault && section.isDefault) {
+			continue
+		}
+		if section.name == InfoSentinelSection && !server.IsSentinelEnabled() {
+			continue
+		}
+		if 0 < info.Len() {
+			info.WriteString(infoLineSep)
+		}
+		info.WriteString("# " + section.title + infoLineSep)
+		for _, line := range section.lines(server) {
+			info.WriteString(line + infoLineSep)
+		}
+	}
+	return info.String()
+}
+
+func (server *Server) serverInfo() []string {
+	uptime := time.Duration(0)
+	if !server.startTime.IsZero() {
+		uptime = time.Since(server.startTime)
+	}
+	return []string{
+		"go_redis_version:" + Version,
+		"redis_mode:" + server.redisMode(),
+		"run_id:" + server.runID,
+		"os:" + runtime.GOOS + " " + runtime.GOARCH,
+		"go_version:" + runtime.Version(),
+		"process_id:" + strconv.Itoa(os.Getpid()),
+		"tcp_port:" + strconv.Itoa(server.ConfigPort()),
+		"uptime_in_seconds:" + strconv.FormatInt(int64(uptime.Seconds()), 10),
+		"uptime_in_days:" + strconv.FormatInt(int64(uptime.Hours()/24), 10),
+	}
+}
+
+func (server *Server) clientsInfo() []string {
+	return []string{
+		"connected_clients:" + strconv.Itoa(server.clientConns.Len()),
+		"maxclients:" + strconv.Itoa(server.ConfigMaxClients()),
+	}
+}
+
+func (server *Server) statsInfo() []string {
+	return []string{
+		"total_connections_received:" + strconv.FormatInt(server.serverStats.TotalConnectionsReceived(), 10),
+		"total_commands_processed:" + strconv.FormatInt(server.commandStats.TotalCalls(), 10),
+		"total_net_input_bytes:" + strconv.FormatInt(server.serverStats.NetInputBytes(), 10),
+		"total_net_output_bytes:" + strconv.FormatInt(server.serverStats.NetOutputBytes(), 10),
+		"rejected_connections:" + strconv.FormatInt(server.serverStats.RejectedConnections(), 10),
+		"client_output_buffer_limit_disconnections:" + strconv.FormatInt(server.serverStats.OutputBufferLimitDisconnections(), 10),
+		"sync_full:" + strconv.FormatInt(server.serverStats.SyncFull(), 10),
+		"sync_partial_ok:" + strconv.FormatInt(server.serverStats.SyncPartialOK(), 10),
+		"sync_partial_err:" + strconv.FormatInt(server.serverStats.SyncPartialErr(), 10),
+		"auth_failures:" + strconv.FormatInt(server.serverStats.AuthFailures(), 10),
+	}
+}
+
+func (server *Server) commandStatsInfo() []string {
+	lines := []string{}
+	for _, stat := range server.commandStats.CommandStats() {
+		line := fmt.Sprintf("cmdstat_%s:calls=%d,usec=%d,usec_per_call=%.2f,rejected_calls=%d,failed_calls=%d",
+			stat.Name,
+			stat.Calls,
+			stat.Usec,
+			stat.UsecPerCall(),
+			stat.RejectedCalls,
+			stat.FailedCalls)
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func (server *Server) latencyStatsInfo() []string {
+	percentiles := server.ConfigLatencyTrackingInfoPercentiles()
+	lines := []string{}
+	for _, stat := range server.commandStats.CommandStats() {
+		if stat.Latency.Count() == 0 {
+			continue
+		}
+		values := make([]string, len(percentiles))
+		for n, p := range percentiles {
+			values[n] = fmt.Sprintf("p%s=%.3f", strconv.FormatFloat(p, 'f', -1, 64), stat.Latency.Percentile(p))
+		}
+		lines = append(lines, "latency_percentiles_usec_"+stat.Name+":"+strings.Join(values, ","))
+	}
+	return lines
+}
+
+func (server *Server) clusterInfo() []string {
+	return []string{
+		"cluster_enabled:" + formatBit(server.ConfigClusterEnabled()),
+	}
+}
+
+// redisMode returns the server mode reported by INFO server section.
+func (server *Server) redisMode() string {
+	if server.IsSentinelEnabled() {
+		return "sentinel"
+	}
+	if server.ConfigClusterEnabled() {
+		return "cluster"
+	}
+	return "standalone"
+}
+
+func (server *Server) keyspaceInfo() []string {
+	keyspace, ok := server.keyspaceStats()
+	if !ok {
+		return []string{}
+	}
+	ids := make([]DatabaseID, 0, len(keyspace))
+	for id := range keyspace {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	lines := []string{}
+	for _, id := range ids {
+		stats := keyspace[id]
+		if stats.Keys == 0 {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("db%d:keys=%d,expires=%d,avg_ttl=0", id, stats.Keys, stats.Expires))
+	}
+	return lines
+}
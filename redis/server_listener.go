@@ -0,0 +1,340 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/cybergarage/go-logger/log"
+)
+
+const (
+	// bindAllIPv4 is the bind address which means all IPv4 interfaces.
+	bindAllIPv4 = "*"
+	// bindAllIPv6 is the bind address which means all IPv6 interfaces.
+	bindAllIPv6 = "::*"
+	// bindOptionalPrefix is the prefix of the bind address which is skipped if it is not available.
+	bindOptionalPrefix = "-"
+)
+
+// listenAddress represents a network address to listen.
+type listenAddress struct {
+	network  string
+	address  string
+	optional bool
+}
+
+// newBindAddress returns a new listen address of the specified bind address such as 127.0.0.1, ::1 and -::* with the specified port.
+func newBindAddress(addr string, port int) *listenAddress {
+	host := strings.TrimPrefix(addr, bindOptionalPrefix)
+	optional := strings.HasPrefix(addr, bindOptionalPrefix)
+	portStr := strconv.Itoa(port)
+	network := "tcp"
+	switch host {
+	case bindAllIPv4:
+		network, host = "tcp4", "0.0.0.0"
+	case bindAllIPv6:
+		network, host = "tcp6", "::"
+	default:
+		if ip := net.ParseIP(host); ip != nil {
+			network = "tcp6"
+			if ip.To4() != nil {
+				network = "tcp4"
+			}
+		}
+	}
+	return &listenAddress{
+		network:  network,
+		address:  net.JoinHostPort(host, portStr),
+		optional: optional,
+	}
+}
+
+// newUnixSocketAddress returns a new listen address of the specified unix socket path.
+func newUnixSocketAddress(path string) *listenAddress {
+	return &listenAddress{
+		network:  "unix",
+		address:  path,
+		optional: false,
+	}
+}
+
+// listen opens a listen socket of the address.
+func (addr *listenAddress) listen() (net.Listener, error) {
+	if addr.network == "unix" {
+		// Removes the stale socket file of the previous process.
+		os.Remove(addr.address)
+	}
+	return net.Listen(addr.network, addr.address)
+}
+
+// isAddressNotAvailable returns true if the specified listen error means that the address is not available on the host.
+func isAddressNotAvailable(err error) bool {
+	return errors.Is(err, syscall.EADDRNOTAVAIL) ||
+		errors.Is(err, syscall.EAFNOSUPPORT) ||
+		errors.Is(err, syscall.EPROTONOSUPPORT)
+}
+
+// serverListener represents a listen socket of the server.
+type serverListener struct {
+	net.Listener
+	addr *listenAddress
+}
+
+// listenAddresses returns the listen addresses of the bind and unixsocket configurations.
+// The server address is used if no bind address is configured.
+func (server *Server) listenAddresses() []*listenAddress {
+	bindAddrs := server.ConfigBind()
+	if len(bindAddrs) == 0 {
+		bindAddrs = []string{server.Addr}
+	}
+	port := server.ConfigPort()
+	addrs := []*listenAddress{}
+	for _, bindAddr := range bindAddrs {
+		addrs = append(addrs, newBindAddress(bindAddr, port))
+	}
+	if path, ok := server.ConfigUnixSocket(); ok {
+		addrs = append(addrs, newUnixSocketAddress(path))
+	}
+	return addrs
+}
+
+// openListeners opens the listen sockets of all listen addresses. The specified current listeners
+// which have the same addresses are reused, and the optional addresses which are not available on the host are skipped.
+func (server *Server) openListeners(currListeners []*serverListener) ([]*serverListener, error) {
+	listeners := []*serverListener{}
+	closeOpened := func() {
+		for _, l := range listeners {
+			if !containsListener(currListeners, l) {
+				l.Close()
+			}
+		}
+	}
+	for _, addr := range server.listenAddresses() {
+		if l, ok := findListener(currListeners, addr); ok {
+			listeners = append(listeners, l)
+			continue
+		}
+		l, err := addr.listen()
+		if err != nil {
+			if addr.optional && isAddressNotAvailable(err) {
+				log.Warnf("%s/%s (%s) skipped (%s)", PackageName, Version, addr.address, err.Error())
+				continue
+			}
+			closeOpened()
+			return nil, err
+		}
+		listeners = append(listeners, &serverListener{Listener: l, addr: addr})
+	}
+	if len(listeners) == 0 {
+		return nil, fmt.Errorf("%w listen address (%s)", ErrInvalid, strings.Join(server.ConfigBind(), " "))
+	}
+	return listeners, nil
+}
+
+// findListener returns the listener which listens on the specified address.
+func findListener(listeners []*serverListener, addr *listenAddress) (*serverListener, bool) {
+	for _, l := range listeners {
+		if l.addr.network == addr.network && l.addr.address == addr.address {
+			return l, true
+		}
+	}
+	return nil, false
+}
+
+// containsListener returns true if the specified listeners contain the listener.
+func containsListener(listeners []*serverListener, listener *serverListener) bool {
+	for _, l := range listeners {
+		if l == listener {
+			return true
+		}
+	}
+	return false
+}
+
+// closeListeners closes the specified listeners.
+func closeListeners(listeners []*serverListener) error {
+	var lastErr error
+	for _, l := range listeners {
+		if err := l.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// isBoundToNonLoopback returns true if any of the specified listeners accepts connections from non-loopback interfaces.
+func isBoundToNonLoopback(listeners []*serverListener) bool {
+	for _, l := range listeners {
+		addr, ok := l.Addr().(*net.TCPAddr)
+		if ok && !addr.IP.IsLoopback() {
+			return true
+		}
+	}
+	return false
+}
+
+// setListeners sets the specified listeners, serving the new listeners and closing the listeners which are no longer used.
+// The existing client connections are kept.
+func (server *Server) setListeners(listeners []*serverListener) {
+	for _, l := range listeners {
+		if containsListener(server.tcpListeners, l) {
+			continue
+		}
+		server.waitGroup.Add(1)
+		go server.serve(l)
+	}
+	for _, l := range server.tcpListeners {
+		if containsListener(listeners, l) {
+			continue
+		}
+		l.Close()
+	}
+	server.tcpListeners = listeners
+	server.boundToNonLoopback.Store(isBoundToNonLoopback(listeners))
+}
+
+// rebind opens the listen sockets of the current listen addresses and closes the old listen sockets while the server is listening.
+func (server *Server) rebind() error {
+	server.listenerMutex.Lock()
+	defer server.listenerMutex.Unlock()
+
+	if !server.listening {
+		return nil
+	}
+
+	listeners, err := server.openListeners(server.tcpListeners)
+	if errors.Is(err, syscall.EADDRINUSE) {
+		// The new addresses may conflict with the current listen sockets such as 0.0.0.0 and 127.0.0.1 on the same port,
+		// so only the conflicting listen sockets which are no longer used are closed before retrying.
+		listeners, err = server.reopenConflictingListeners()
+	}
+	if err != nil {
+		return err
+	}
+	server.setListeners(listeners)
+
+	log.Infof("%s/%s (%s) rebound", PackageName, Version, strings.Join(server.listenAddrsLocked(), " "))
+
+	return nil
+}
+
+// reopenConflictingListeners closes the current listeners which are no longer used and listen on the same ports as
+// the current listen addresses, and opens the listen sockets again. The closed listeners are reopened if it fails.
+func (server *Server) reopenConflictingListeners() ([]*serverListener, error) {
+	addrs := server.listenAddresses()
+	ports := map[string]bool{}
+	for _, addr := range addrs {
+		if _, port, err := net.SplitHostPort(addr.address); err == nil {
+			ports[port] = true
+		}
+	}
+	kept := []*serverListener{}
+	conflicts := []*serverListener{}
+	for _, l := range server.tcpListeners {
+		_, port, err := net.SplitHostPort(l.addr.address)
+		if err != nil || !ports[port] || containsListenAddress(addrs, l.addr) {
+			kept = append(kept, l)
+			continue
+		}
+		conflicts = append(conflicts, l)
+	}
+	if len(conflicts) == 0 {
+		return nil, syscall.EADDRINUSE
+	}
+	server.setListeners(kept)
+	listeners, err := server.openListeners(kept)
+	if err == nil {
+		return listeners, nil
+	}
+	for _, l := range conflicts {
+		reopened, reopenErr := l.addr.listen()
+		if reopenErr != nil {
+			log.Error(reopenErr)
+			continue
+		}
+		kept = append(kept, &serverListener{Listener: reopened, addr: l.addr})
+	}
+	server.setListeners(kept)
+	return nil, err
+}
+
+// containsListenAddress returns true if the specified addresses contain the specified address.
+func containsListenAddress(addrs []*listenAddress, addr *listenAddress) bool {
+	for _, a := range addrs {
+		if a.network == addr.network && a.address == addr.address {
+			return true
+		}
+	}
+	return false
+}
+
+// listenerConfigs is the configurations which change the listen sockets.
+var listenerConfigs = []string{
+	portConfig,
+	bindConfig,
+	unixSocketConfig,
+}
+
+// onListenerConfigChange rebinds the listen sockets when the listener configurations are changed.
+func (server *Server) onListenerConfigChange(changes []*ConfigChange) error {
+	return server.rebind()
+}
+
+// listenAddrs returns the listen address strings of the listeners.
+func (server *Server) listenAddrs() string {
+	return strings.Join(server.ListenAddrs(), " ")
+}
+
+// ListenAddrs returns the addresses of the listening sockets such as "127.0.0.1:6379" and "/tmp/redis.sock".
+func (server *Server) ListenAddrs() []string {
+	server.listenerMutex.Lock()
+	defer server.listenerMutex.Unlock()
+	return server.listenAddrsLocked()
+}
+
+// listenAddrsLocked returns the addresses of the listening sockets without locking.
+func (server *Server) listenAddrsLocked() []string {
+	addrs := make([]string, len(server.tcpListeners))
+	for n, l := range server.tcpListeners {
+		addrs[n] = l.Addr().String()
+	}
+	return addrs
+}
+
+// isProtectedModeDenied returns true if the connection from the specified remote address is denied by the protected mode of the specified configuration.
+// The protected mode accepts only loopback clients when no password is set and the server is bound to non-loopback interfaces.
+func (server *Server) isProtectedModeDenied(cfg *ServerConfig, remoteAddr net.Addr) bool {
+	if !cfg.ConfigProtectedMode() {
+		return false
+	}
+	if ok, _ := cfg.ConfigRequirePass(); ok {
+		return false
+	}
+	if !server.boundToNonLoopback.Load() {
+		return false
+	}
+	addr, ok := remoteAddr.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+	return !addr.IP.IsLoopback()
+}
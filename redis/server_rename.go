@@ -0,0 +1,138 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"fmt"
+	"strings"
+)
+
+// commandRenames represents the command names renamed by the rename-command configuration.
+type commandRenames struct {
+	// aliases maps the renamed names to the registered command names.
+	aliases map[string]string
+	// names maps the registered command names to the renamed names, or empty names if the commands are disabled.
+	names map[string]string
+}
+
+// newCommandRenames returns a new command renames without any renamed command.
+func newCommandRenames() *commandRenames {
+	return &commandRenames{
+		aliases: map[string]string{},
+		names:   map[string]string{},
+	}
+}
+
+// newCommandRenamesWith returns the command renames of the specified renamed commands for the registered executors.
+func newCommandRenamesWith(executors Executors, cmds []*RenamedCommand) (*commandRenames, error) {
+	renames := newCommandRenames()
+	visibles := map[string]bool{}
+	for name := range executors {
+		visibles[name] = true
+	}
+	for _, cmd := range cmds {
+		if !visibles[cmd.Name] {
+			return nil, fmt.Errorf("%w in %s (%s)", ErrNoSuchCommand, renameCommandConfig, cmd.Name)
+		}
+		delete(visibles, cmd.Name)
+		name := cmd.Name
+		if alias, ok := renames.aliases[cmd.Name]; ok {
+			name = alias
+			delete(renames.aliases, cmd.Name)
+		}
+		renames.names[name] = cmd.NewName
+		if len(cmd.NewName) == 0 {
+			continue
+		}
+		if visibles[cmd.NewName] {
+			return nil, fmt.Errorf("%w in %s (%s)", ErrCommandExists, renameCommandConfig, cmd.NewName)
+		}
+		visibles[cmd.NewName] = true
+		renames.aliases[cmd.NewName] = name
+	}
+	return renames, nil
+}
+
+// resolve returns the registered command name of the specified client command name.
+// It returns false if the command is renamed or disabled.
+func (renames *commandRenames) resolve(cmd string) (string, bool) {
+	upperCmd := strings.ToUpper(cmd)
+	if name, ok := renames.aliases[upperCmd]; ok {
+		return name, true
+	}
+	if _, ok := renames.names[upperCmd]; ok {
+		return "", false
+	}
+	return cmd, true
+}
+
+// clientName returns the command name visible to clients of the specified registered command name.
+// It returns false if the command is disabled.
+func (renames *commandRenames) clientName(name string) (string, bool) {
+	newName, ok := renames.names[strings.ToUpper(name)]
+	if !ok {
+		return name, true
+	}
+	return newName, 0 < len(newName)
+}
+
+// loadCommandRenames applies the renamed commands of the configuration to the registered executors.
+func (server *Server) loadCommandRenames() error {
+	renames, err := newCommandRenamesWith(server.commandExecutors, server.ConfigRenameCommands())
+	if err != nil {
+		return err
+	}
+	server.commandRenames = renames
+	return nil
+}
+
+// clientCommandInfo returns the command metadata with the command name visible to clients.
+// It returns false if the command is disabled.
+func (server *Server) clientCommandInfo(info *CommandInfo) (*CommandInfo, bool) {
+	name, ok := server.commandRenames.clientName(info.Name)
+	if !ok {
+		return nil, false
+	}
+	if name == info.Name {
+		return info, true
+	}
+	renamedInfo := *info
+	renamedInfo.Name = name
+	return &renamedInfo, true
+}
+
+// clientCommandInfos returns all command metadata visible to clients sorted by the command name.
+func (server *Server) clientCommandInfos() []*CommandInfo {
+	table := NewCommandTable()
+	for _, info := range server.commandTable {
+		if clientInfo, ok := server.clientCommandInfo(info); ok {
+			table.SetCommandInfo(clientInfo)
+		}
+	}
+	return table.CommandInfos()
+}
+
+// lookupClientCommandInfo returns the command metadata of the specified client command name.
+func (server *Server) lookupClientCommandInfo(cmd string) (*CommandInfo, bool) {
+	name, ok := server.commandRenames.resolve(cmd)
+	if !ok {
+		return nil, false
+	}
+	info, ok := server.commandTable.LookupCommandInfo(name)
+	if !ok {
+		return nil, false
+	}
+	return server.clientCommandInfo(info)
+}
@@ -0,0 +1,70 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+// Eval runs the specified script with the specified keys and arguments. The read-only scripts can not run write commands.
+func (server *Server) Eval(conn *Conn, body string, keys []string, args []string, readOnly bool) (*Message, error) {
+	script, err := server.scripting.load(body)
+	if err != nil {
+		return nil, err
+	}
+	return server.evalScript(conn, script, keys, args, readOnly)
+}
+
+// EvalSha runs the cached script of the specified SHA1 digest with the specified keys and arguments.
+func (server *Server) EvalSha(conn *Conn, sha string, keys []string, args []string, readOnly bool) (*Message, error) {
+	script, ok := server.scripting.lookup(sha)
+	if !ok {
+		return nil, ErrNoScript
+	}
+	return server.evalScript(conn, script, keys, args, readOnly)
+}
+
+// ScriptLoad caches the specified script and returns the SHA1 digest.
+func (server *Server) ScriptLoad(conn *Conn, body string) (*Message, error) {
+	script, err := server.scripting.load(body)
+	if err != nil {
+		return nil, err
+	}
+	return NewBulkMessage(script.sha), nil
+}
+
+// ScriptExists returns whether the scripts of the specified SHA1 digests are cached.
+func (server *Server) ScriptExists(conn *Conn, shas []string) (*Message, error) {
+	msg := NewArrayMessage()
+	for _, sha := range shas {
+		_, ok := server.scripting.lookup(sha)
+		if ok {
+			msg.Append(NewIntegerMessage(1))
+		} else {
+			msg.Append(NewIntegerMessage(0))
+		}
+	}
+	return msg, nil
+}
+
+// ScriptFlush removes all cached scripts.
+func (server *Server) ScriptFlush(conn *Conn) (*Message, error) {
+	server.scripting.flush()
+	return NewOKMessage(), nil
+}
+
+// ScriptKill stops the running script which has not executed write commands.
+func (server *Server) ScriptKill(conn *Conn) (*Message, error) {
+	if err := server.scripting.kill(); err != nil {
+		return nil, err
+	}
+	return NewOKMessage(), nil
+}
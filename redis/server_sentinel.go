@@ -0,0 +1,309 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"net"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// SetSentinelEnabled sets the sentinel mode which serves only the sentinel commands and monitors the masters.
+func (server *Server) SetSentinelEnabled(enabled bool) {
+	server.sentinelEnabled.Store(enabled)
+}
+
+// IsSentinelEnabled returns true if the server runs in the sentinel mode.
+func (server *Server) IsSentinelEnabled() bool {
+	return server.sentinelEnabled.Load()
+}
+
+// Sentinel returns the sentinel of the server.
+func (server *Server) Sentinel() *Sentinel {
+	return server.sentinel
+}
+
+// sentinelRole returns the ROLE reply of the sentinel which lists the names of the monitored masters.
+func (server *Server) sentinelRole() (*Message, error) {
+	sentinel := server.sentinel
+	sentinel.Lock()
+	defer sentinel.Unlock()
+	msg := NewArrayMessage()
+	msg.Append(NewBulkMessage(SentinelRole))
+	namesMsg := NewArrayMessage()
+	for _, master := range sentinel.sortedMastersLocked() {
+		namesMsg.Append(NewBulkMessage(master.name))
+	}
+	msg.Append(namesMsg)
+	return msg, nil
+}
+
+func (server *Server) sentinelInfo() []string {
+	sentinel := server.sentinel
+	sentinel.Lock()
+	defer sentinel.Unlock()
+	lines := []string{
+		"sentinel_masters:" + strconv.Itoa(len(sentinel.masters)),
+	}
+	for n, master := range sentinel.sortedMastersLocked() {
+		status := "ok"
+		if master.odown {
+			status = "odown"
+		} else if master.sdown {
+			status = "sdown"
+		}
+		lines = append(lines, "master"+strconv.Itoa(n)+":name="+master.name+",status="+status+
+			",address="+master.Addr()+
+			",slaves="+strconv.Itoa(len(master.replicas))+
+			",sentinels="+strconv.Itoa(len(master.sentinels)+1))
+	}
+	return lines
+}
+
+// sortedMastersLocked returns the monitored masters sorted by the names.
+func (sentinel *Sentinel) sortedMastersLocked() []*sentinelMaster {
+	masters := make([]*sentinelMaster, 0, len(sentinel.masters))
+	for _, master := range sentinel.masters {
+		masters = append(masters, master)
+	}
+	sort.Slice(masters, func(i, j int) bool {
+		return masters[i].name < masters[j].name
+	})
+	return masters
+}
+
+// newFieldsMessage returns a flat array message of the specified field and value pairs.
+func newFieldsMessage(fields ...string) *Message {
+	msg := NewArrayMessage()
+	for _, field := range fields {
+		msg.Append(NewBulkMessage(field))
+	}
+	return msg
+}
+
+// instanceFlags returns the flags of the specified instance reported by SENTINEL commands.
+func instanceFlags(instance *sentinelInstance, role string) string {
+	flags := role
+	if instance.sdown {
+		flags += ",s_down"
+	}
+	return flags
+}
+
+// newSentinelMasterMessage returns the fields of the specified master.
+func newSentinelMasterMessage(master *sentinelMaster) *Message {
+	flags := instanceFlags(master.sentinelInstance, "master")
+	if master.odown {
+		flags += ",o_down"
+	}
+	if master.failoverRunning {
+		flags += ",failover_in_progress"
+	}
+	return newFieldsMessage(
+		"name", master.name,
+		"ip", master.host,
+		"port", strconv.Itoa(master.port),
+		"runid", master.runID,
+		"flags", flags,
+		"last-ok-ping-reply", strconv.FormatInt(time.Since(master.lastOKPing).Milliseconds(), 10),
+		"role-reported", master.role,
+		"config-epoch", strconv.FormatInt(master.configEpoch, 10),
+		"num-slaves", strconv.Itoa(len(master.replicas)),
+		"num-other-sentinels", strconv.Itoa(len(master.sentinels)),
+		"quorum", strconv.Itoa(master.quorum),
+		"down-after-milliseconds", strconv.FormatInt(master.downAfter.Milliseconds(), 10),
+		"failover-timeout", strconv.FormatInt(master.failoverTimeout.Milliseconds(), 10),
+	)
+}
+
+// lookupMasterLocked returns the specified master, or ErrNoSuchMaster if the master is not monitored.
+func (sentinel *Sentinel) lookupMasterLocked(name string) (*sentinelMaster, error) {
+	master, ok := sentinel.masters[name]
+	if !ok {
+		return nil, ErrNoSuchMaster
+	}
+	return master, nil
+}
+
+// SentinelGetMasterAddrByName returns the address of the specified master.
+func (server *Server) SentinelGetMasterAddrByName(conn *Conn, name string) (*Message, error) {
+	host, port, ok := server.sentinel.MasterAddr(name)
+	if !ok {
+		return NewNilMessage(), nil
+	}
+	return newFieldsMessage(host, strconv.Itoa(port)), nil
+}
+
+// SentinelMasters returns the states of the all monitored masters.
+func (server *Server) SentinelMasters(conn *Conn) (*Message, error) {
+	sentinel := server.sentinel
+	sentinel.Lock()
+	defer sentinel.Unlock()
+	msg := NewArrayMessage()
+	for _, master := range sentinel.sortedMastersLocked() {
+		msg.Append(newSentinelMasterMessage(master))
+	}
+	return msg, nil
+}
+
+// SentinelMaster returns the state of the specified master.
+func (server *Server) SentinelMaster(conn *Conn, name string) (*Message, error) {
+	sentinel := server.sentinel
+	sentinel.Lock()
+	defer sentinel.Unlock()
+	master, err := sentinel.lookupMasterLocked(name)
+	if err != nil {
+		return nil, err
+	}
+	return newSentinelMasterMessage(master), nil
+}
+
+// SentinelReplicas returns the states of the replicas of the specified master.
+func (server *Server) SentinelReplicas(conn *Conn, name string) (*Message, error) {
+	sentinel := server.sentinel
+	sentinel.Lock()
+	defer sentinel.Unlock()
+	master, err := sentinel.lookupMasterLocked(name)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]string, 0, len(master.replicas))
+	for addr := range master.replicas {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	msg := NewArrayMessage()
+	for _, addr := range addrs {
+		replica := master.replicas[addr]
+		linkStatus := "err"
+		if replica.masterLinkUp {
+			linkStatus = "ok"
+		}
+		msg.Append(newFieldsMessage(
+			"name", addr,
+			"ip", replica.host,
+			"port", strconv.Itoa(replica.port),
+			"runid", replica.runID,
+			"flags", instanceFlags(replica, "slave"),
+			"last-ok-ping-reply", strconv.FormatInt(time.Since(replica.lastOKPing).Milliseconds(), 10),
+			"role-reported", replica.role,
+			"master-host", replica.masterHost,
+			"master-port", strconv.Itoa(replica.masterPort),
+			"master-link-status", linkStatus,
+			"slave-repl-offset", strconv.FormatInt(replica.offset, 10),
+		))
+	}
+	return msg, nil
+}
+
+// SentinelSentinels returns the states of the other sentinels monitoring the specified master.
+func (server *Server) SentinelSentinels(conn *Conn, name string) (*Message, error) {
+	sentinel := server.sentinel
+	sentinel.Lock()
+	defer sentinel.Unlock()
+	master, err := sentinel.lookupMasterLocked(name)
+	if err != nil {
+		return nil, err
+	}
+	runIDs := make([]string, 0, len(master.sentinels))
+	for runID := range master.sentinels {
+		runIDs = append(runIDs, runID)
+	}
+	sort.Strings(runIDs)
+	msg := NewArrayMessage()
+	for _, runID := range runIDs {
+		peer := master.sentinels[runID]
+		msg.Append(newFieldsMessage(
+			"name", runID,
+			"ip", peer.host,
+			"port", strconv.Itoa(peer.port),
+			"runid", runID,
+			"flags", "sentinel",
+			"last-hello-message", strconv.FormatInt(time.Since(peer.lastHello).Milliseconds(), 10),
+			"leader", peer.leader,
+			"leader-epoch", strconv.FormatInt(peer.leaderEpoch, 10),
+		))
+	}
+	return msg, nil
+}
+
+// SentinelIsMasterDownByAddr returns whether the master of the specified address is subjectively down.
+// When the specified run ID is not "*", the sentinel votes for the run ID as the leader of the specified epoch.
+func (server *Server) SentinelIsMasterDownByAddr(conn *Conn, host string, port int, epoch int64, runID string) (*Message, error) {
+	sentinel := server.sentinel
+	sentinel.Lock()
+	defer sentinel.Unlock()
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	var master *sentinelMaster
+	for _, m := range sentinel.masters {
+		if m.Addr() == addr {
+			master = m
+			break
+		}
+	}
+	down := 0
+	leader := "*"
+	leaderEpoch := int64(0)
+	if master != nil {
+		if master.sdown {
+			down = 1
+		}
+		if runID != "*" {
+			sentinel.updateEpochLocked(epoch)
+			if master.leaderEpoch < epoch && epoch == sentinel.currentEpoch {
+				master.leader = runID
+				master.leaderEpoch = epoch
+				sentinel.eventLocked(master, "+vote-for-leader", master.sentinelInstance, runID+" "+strconv.FormatInt(epoch, 10))
+			}
+			leader = master.leader
+			leaderEpoch = master.leaderEpoch
+		}
+	}
+	msg := NewArrayMessage()
+	msg.Append(NewIntegerMessage(down))
+	msg.Append(NewBulkMessage(leader))
+	msg.Append(NewIntegerMessage(int(leaderEpoch)))
+	return msg, nil
+}
+
+// SentinelMyID returns the ID of the sentinel.
+func (server *Server) SentinelMyID(conn *Conn) (*Message, error) {
+	return NewBulkMessage(server.sentinel.MyID()), nil
+}
+
+// SentinelMonitor starts monitoring the specified master.
+func (server *Server) SentinelMonitor(conn *Conn, name string, host string, port int, quorum int) (*Message, error) {
+	if err := server.sentinel.Monitor(name, host, port, quorum); err != nil {
+		return nil, err
+	}
+	return NewOKMessage(), nil
+}
+
+// SentinelRemove stops monitoring the specified master.
+func (server *Server) SentinelRemove(conn *Conn, name string) (*Message, error) {
+	if err := server.sentinel.Remove(name); err != nil {
+		return nil, err
+	}
+	return NewOKMessage(), nil
+}
+
+// SentinelFailover forces the failover of the specified master without the agreement of the other sentinels.
+func (server *Server) SentinelFailover(conn *Conn, name string) (*Message, error) {
+	if err := server.sentinel.Failover(name); err != nil {
+		return nil, err
+	}
+	return NewOKMessage(), nil
+}
@@ -0,0 +1,167 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cybergarage/go-logger/log"
+)
+
+// shutdownGracePeriod is the period to wait for the connection handlers after the connections are closed forcibly.
+const shutdownGracePeriod = time.Second
+
+// Shutdown gracefully shuts down the server. Shutdown stops accepting new connections,
+// waits for the in-flight commands, closes the idle connections and saves the function libraries and the dataset
+// using the FunctionPersistenceHandler and the PersistenceHandler of the user command handler if they are implemented.
+// If the specified context expires before the in-flight commands finish, the connections are closed forcibly and
+// the dataset is still saved, and the both errors are returned.
+func (server *Server) Shutdown(ctx context.Context) error {
+	return server.shutdown(ctx, true, true)
+}
+
+// Done returns a channel which is closed when the server is shut down.
+func (server *Server) Done() <-chan struct{} {
+	return server.done
+}
+
+// shutdown shuts down the server after the in-flight commands finish, and saves the dataset if the specified save flag
+// is true. If the save fails and the specified force flag is false, the shutdown is aborted and the server keeps serving.
+func (server *Server) shutdown(ctx context.Context, save bool, force bool) error {
+	server.shutdownMutex.Lock()
+	defer server.shutdownMutex.Unlock()
+
+	select {
+	case <-server.done:
+		return nil
+	default:
+	}
+
+	close(server.closing)
+
+	if err := server.closeMetrics(); err != nil {
+		log.Error(err)
+	}
+
+	addrs := server.listenAddrs()
+	if err := server.close(); err != nil {
+		log.Error(err)
+	}
+
+	err := server.drain(ctx)
+
+	if save {
+		// The dataset is saved even if the context expires not to lose the writes.
+		if saveErr := server.save(context.WithoutCancel(ctx)); saveErr != nil {
+			if !force {
+				log.Error(saveErr)
+				return errors.Join(ErrShutdown, saveErr, server.resume())
+			}
+			err = errors.Join(err, saveErr)
+		}
+	}
+
+	server.cancel()
+	close(server.done)
+
+	log.Infof("%s/%s (%s) shut down", PackageName, Version, addrs)
+
+	return err
+}
+
+// drain waits for the connection handlers to finish. If the specified context expires, drain closes the connections
+// forcibly, which cancels the contexts of their commands, and waits for the handlers for the grace period.
+func (server *Server) drain(ctx context.Context) error {
+	waitCh := make(chan struct{})
+	go func() {
+		server.waitGroup.Wait()
+		close(waitCh)
+	}()
+
+	select {
+	case <-waitCh:
+		return nil
+	case <-ctx.Done():
+	}
+
+	for _, conn := range server.clientConns.Conns() {
+		conn.Close()
+	}
+
+	timer := time.NewTimer(shutdownGracePeriod)
+	defer timer.Stop()
+	select {
+	case <-waitCh:
+	case <-timer.C:
+		log.Warnf("%s/%s shuts down without waiting for the handlers", PackageName, Version)
+	}
+
+	return ctx.Err()
+}
+
+// resume reopens the listeners to keep serving after the shutdown is aborted.
+func (server *Server) resume() error {
+	server.closing = make(chan struct{})
+	if err := server.openMetrics(); err != nil {
+		return err
+	}
+	return server.open()
+}
+
+// save saves the function libraries and the dataset using the FunctionPersistenceHandler and the PersistenceHandler
+// of the user command handler if they are implemented.
+func (server *Server) save(ctx context.Context) error {
+	if handler, ok := server.userCommandHandler.(FunctionPersistenceHandler); ok {
+		libs, err := server.libraries.list("*")
+		if err != nil {
+			return err
+		}
+		payload, err := dumpLibraries(libs)
+		if err != nil {
+			return err
+		}
+		if err := handler.SaveFunctions(ctx, payload); err != nil {
+			return err
+		}
+	}
+	handler, ok := server.userCommandHandler.(PersistenceHandler)
+	if !ok {
+		return nil
+	}
+	return handler.Save(ctx)
+}
+
+// loadFunctions loads the saved function libraries using the FunctionPersistenceHandler of the user command handler
+// if it is implemented. The saved libraries are loaded only if no library is loaded yet such as at the first start.
+func (server *Server) loadFunctions(ctx context.Context) error {
+	handler, ok := server.userCommandHandler.(FunctionPersistenceHandler)
+	if !ok {
+		return nil
+	}
+	if numLibs, _ := server.libraries.counts(); 0 < numLibs {
+		return nil
+	}
+	payload, err := handler.LoadFunctions(ctx)
+	if err != nil || len(payload) == 0 {
+		return err
+	}
+	libs, err := loadLibraries(payload)
+	if err != nil {
+		return err
+	}
+	return server.libraries.restore(libs, FunctionRestoreFlush)
+}
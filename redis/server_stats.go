@@ -0,0 +1,124 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"net"
+	"sync/atomic"
+)
+
+// ServerStats represents the server statistics.
+type ServerStats struct {
+	totalConnectionsReceived atomic.Int64
+	netInputBytes            atomic.Int64
+	netOutputBytes           atomic.Int64
+	authFailures             atomic.Int64
+	rejectedConnections      atomic.Int64
+	outputLimitDisconnects   atomic.Int64
+	syncFull                 atomic.Int64
+	syncPartialOK            atomic.Int64
+	syncPartialErr           atomic.Int64
+}
+
+// NewServerStats returns a new server statistics.
+func NewServerStats() *ServerStats {
+	return &ServerStats{} // nolint: exhaustruct
+}
+
+// TotalConnectionsReceived returns the total number of the accepted connections.
+func (stats *ServerStats) TotalConnectionsReceived() int64 {
+	return stats.totalConnectionsReceived.Load()
+}
+
+// NetInputBytes returns the total number of the bytes read from the network.
+func (stats *ServerStats) NetInputBytes() int64 {
+	return stats.netInputBytes.Load()
+}
+
+// NetOutputBytes returns the total number of the bytes written to the network.
+func (stats *ServerStats) NetOutputBytes() int64 {
+	return stats.netOutputBytes.Load()
+}
+
+// AuthFailures returns the total number of the failed authentications.
+func (stats *ServerStats) AuthFailures() int64 {
+	return stats.authFailures.Load()
+}
+
+// RejectedConnections returns the total number of the connections rejected by the client limits.
+func (stats *ServerStats) RejectedConnections() int64 {
+	return stats.rejectedConnections.Load()
+}
+
+// OutputBufferLimitDisconnections returns the total number of the connections closed by the output buffer limits.
+func (stats *ServerStats) OutputBufferLimitDisconnections() int64 {
+	return stats.outputLimitDisconnects.Load()
+}
+
+// SyncFull returns the total number of the full resynchronizations with the replicas.
+func (stats *ServerStats) SyncFull() int64 {
+	return stats.syncFull.Load()
+}
+
+// SyncPartialOK returns the total number of the accepted partial resynchronization requests.
+func (stats *ServerStats) SyncPartialOK() int64 {
+	return stats.syncPartialOK.Load()
+}
+
+// SyncPartialErr returns the total number of the denied partial resynchronization requests.
+func (stats *ServerStats) SyncPartialErr() int64 {
+	return stats.syncPartialErr.Load()
+}
+
+// Reset clears all statistics.
+func (stats *ServerStats) Reset() {
+	stats.totalConnectionsReceived.Store(0)
+	stats.netInputBytes.Store(0)
+	stats.netOutputBytes.Store(0)
+	stats.authFailures.Store(0)
+	stats.rejectedConnections.Store(0)
+	stats.outputLimitDisconnects.Store(0)
+	stats.syncFull.Store(0)
+	stats.syncPartialOK.Store(0)
+	stats.syncPartialErr.Store(0)
+}
+
+// statsConn represents a network connection counting the read and written bytes.
+type statsConn struct {
+	net.Conn
+	stats *ServerStats
+}
+
+// newStatsConn returns a new network connection counting the read and written bytes into the specified statistics.
+func newStatsConn(conn net.Conn, stats *ServerStats) *statsConn {
+	return &statsConn{
+		Conn:  conn,
+		stats: stats,
+	}
+}
+
+// Read reads bytes from the connection.
+func (conn *statsConn) Read(b []byte) (int, error) {
+	n, err := conn.Conn.Read(b)
+	conn.stats.netInputBytes.Add(int64(n))
+	return n, err
+}
+
+// Write writes bytes to the connection.
+func (conn *statsConn) Write(b []byte) (int, error) {
+	n, err := conn.Conn.Write(b)
+	conn.stats.netOutputBytes.Add(int64(n))
+	return n, err
+}
@@ -15,7 +15,18 @@
 package redis
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestServer(t *testing.T) {
@@ -33,3 +44,878 @@ func TestServer(t *testing.T) {
 		return
 	}
 }
+
+func TestServerCommandContext(t *testing.T) {
+	server := NewServer()
+	server.SetPort(0)
+
+	started := make(chan struct{})
+	ctxErrs := make(chan error, 1)
+	server.Use(func(next Executor) Executor {
+		return func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+			ctx := conn.CommandContext()
+			if _, ok := SpanContextFromContext(ctx); !ok {
+				ctxErrs <- errors.New("no span context")
+				return NewOKMessage(), nil
+			}
+			close(started)
+			select {
+			case <-ctx.Done():
+				ctxErrs <- ctx.Err()
+			case <-time.After(time.Second * 5):
+				ctxErrs <- errors.New("not cancelled")
+			}
+			return NewOKMessage(), nil
+		}
+	})
+
+	err := server.Start()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	conn, err := net.Dial("tcp", server.tcpListeners[0].Addr().String())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("*1\r\n$4\r\nPING\r\n")); err != nil {
+		t.Error(err)
+		return
+	}
+	<-started
+
+	if err := server.Stop(); err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = <-ctxErrs
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("%v != %v", err, context.Canceled)
+	}
+}
+
+func TestServerCommandContextDisconnect(t *testing.T) {
+	server := NewServer()
+	server.SetPort(0)
+
+	started := make(chan struct{})
+	ctxErrs := make(chan error, 1)
+	server.SetFallbackExecutor(func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		ctx := conn.CommandContext()
+		close(started)
+		select {
+		case <-ctx.Done():
+			ctxErrs <- ctx.Err()
+		case <-time.After(time.Second * 5):
+			ctxErrs <- errors.New("not cancelled")
+		}
+		return NewOKMessage(), nil
+	})
+
+	err := server.Start()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer server.Stop()
+
+	conn, err := net.Dial("tcp", server.tcpListeners[0].Addr().String())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := conn.Write([]byte("*2\r\n$3\r\nGET\r\n$1\r\nk\r\n")); err != nil {
+		t.Error(err)
+		return
+	}
+	<-started
+
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = <-ctxErrs
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("%v != %v", err, context.Canceled)
+	}
+}
+
+func TestServerHalfClose(t *testing.T) {
+	server := NewServer()
+	server.SetPort(0)
+
+	var mutex sync.Mutex
+	values := map[string]string{}
+	server.SetFallbackExecutor(func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		key, err := args.NextString()
+		if err != nil {
+			return nil, err
+		}
+		val, err := args.NextString()
+		if err != nil {
+			return nil, err
+		}
+		// Makes the client close the write side while the command is in progress.
+		time.Sleep(time.Millisecond * 100)
+		mutex.Lock()
+		values[key] = val
+		mutex.Unlock()
+		return NewOKMessage(), nil
+	})
+
+	err := server.Start()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer server.Stop()
+
+	conn, err := net.Dial("tcp", server.tcpListeners[0].Addr().String())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$1\r\nv\r\n")); err != nil {
+		t.Error(err)
+		return
+	}
+	tcpConn, ok := conn.(*net.TCPConn)
+	if !ok {
+		t.Fatalf("%T is not a TCP connection", conn)
+	}
+	if err := tcpConn.CloseWrite(); err != nil {
+		t.Error(err)
+		return
+	}
+
+	conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+	res, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil || res != "+OK\r\n" {
+		t.Errorf("invalid response (%q, %v)", res, err)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if values["k"] != "v" {
+		t.Errorf("%s != %s", values["k"], "v")
+	}
+}
+
+func TestServerShutdown(t *testing.T) {
+	server := NewServer()
+	server.SetPort(0)
+
+	started := make(chan struct{})
+	server.Use(func(next Executor) Executor {
+		return func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+			close(started)
+			time.Sleep(time.Millisecond * 200)
+			return NewOKMessage(), nil
+		}
+	})
+
+	err := server.Start()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	addr := server.tcpListeners[0].Addr().String()
+	idleConn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer idleConn.Close()
+
+	busyConn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer busyConn.Close()
+	if _, err := busyConn.Write([]byte("*1\r\n$4\r\nPING\r\n")); err != nil {
+		t.Error(err)
+		return
+	}
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		t.Error(err)
+		return
+	}
+
+	select {
+	case <-server.Done():
+	default:
+		t.Errorf("server is not done")
+	}
+
+	// The in-flight command should be completed before the connection is closed.
+	busyConn.SetReadDeadline(time.Now().Add(time.Second))
+	res, err := bufio.NewReader(busyConn).ReadString('\n')
+	if err != nil || res != "+OK\r\n" {
+		t.Errorf("invalid response (%s, %v)", res, err)
+	}
+
+	// The idle connection should be closed.
+	idleConn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := idleConn.Read(make([]byte, 1)); !errors.Is(err, io.EOF) {
+		t.Errorf("%v != %v", err, io.EOF)
+	}
+
+	if _, err := net.Dial("tcp", addr); err == nil {
+		t.Errorf("%s is still accepting", addr)
+	}
+}
+
+func TestServerShutdownCommand(t *testing.T) {
+	server := NewServer()
+	server.SetPort(0)
+	server.SetFallbackExecutor(func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		return NewOKMessage(), nil
+	})
+
+	err := server.Start()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	conn, err := net.Dial("tcp", server.tcpListeners[0].Addr().String())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("*2\r\n$8\r\nSHUTDOWN\r\n$6\r\nNOSAVE\r\n")); err != nil {
+		t.Error(err)
+		return
+	}
+
+	select {
+	case <-server.Done():
+	case <-time.After(time.Second * 5):
+		t.Errorf("server is not done")
+	}
+
+	// The connection should be closed without any reply.
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if n, err := conn.Read(make([]byte, 1)); n != 0 || !errors.Is(err, io.EOF) {
+		t.Errorf("%d, %v != 0, %v", n, err, io.EOF)
+	}
+}
+
+type persistentStore struct {
+	UserCommandHandler
+	sync.Mutex
+	started chan struct{}
+	delay   time.Duration
+	values  map[string]string
+	saved   map[string]string
+	saveErr error
+}
+
+func (store *persistentStore) Set(conn *Conn, key string, val string, opt SetOption) (*Message, error) {
+	close(store.started)
+	select {
+	case <-time.After(store.delay):
+	case <-conn.CommandContext().Done():
+	}
+	store.Lock()
+	defer store.Unlock()
+	store.values[key] = val
+	return NewOKMessage(), nil
+}
+
+func (store *persistentStore) Save(ctx context.Context) error {
+	store.Lock()
+	defer store.Unlock()
+	if store.saveErr != nil {
+		return store.saveErr
+	}
+	store.saved = map[string]string{}
+	for key, val := range store.values {
+		store.saved[key] = val
+	}
+	return nil
+}
+
+func TestServerShutdownSave(t *testing.T) {
+	newStore := func(delay time.Duration, saveErr error) *persistentStore {
+		return &persistentStore{
+			UserCommandHandler: nil,
+			Mutex:              sync.Mutex{},
+			started:            make(chan struct{}),
+			delay:              delay,
+			values:             map[string]string{},
+			saved:              nil,
+			saveErr:            saveErr,
+		}
+	}
+	startServer := func(store *persistentStore) (*Server, string) {
+		server := NewServer()
+		server.SetPort(0)
+		server.SetCommandHandler(store)
+		if err := server.Start(); err != nil {
+			t.Fatal(err)
+		}
+		return server, server.tcpListeners[0].Addr().String()
+	}
+	sendCommand := func(addr string, req string) net.Conn {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := conn.Write([]byte(req)); err != nil {
+			t.Fatal(err)
+		}
+		return conn
+	}
+	setReq := "*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$1\r\nv\r\n"
+	waitDone := func(server *Server) {
+		select {
+		case <-server.Done():
+		case <-time.After(time.Second * 5):
+			t.Fatalf("server is not done")
+		}
+	}
+
+	// The write which finishes while SHUTDOWN drains the connections should be saved.
+	store := newStore(time.Millisecond*200, nil)
+	server, addr := startServer(store)
+	setConn := sendCommand(addr, setReq)
+	defer setConn.Close()
+	<-store.started
+	shutdownConn := sendCommand(addr, "*1\r\n$8\r\nSHUTDOWN\r\n")
+	defer shutdownConn.Close()
+	waitDone(server)
+	store.Lock()
+	if store.saved["k"] != "v" {
+		t.Errorf("%s != %s", store.saved["k"], "v")
+	}
+	store.Unlock()
+
+	// SHUTDOWN is aborted if the save fails without FORCE.
+	store = newStore(0, errors.New("save error"))
+	server, addr = startServer(store)
+	abortConn := sendCommand(addr, "*1\r\n$8\r\nSHUTDOWN\r\n")
+	defer abortConn.Close()
+	abortConn.SetReadDeadline(time.Now().Add(time.Second * 5))
+	if _, err := abortConn.Read(make([]byte, 1)); !errors.Is(err, io.EOF) {
+		t.Errorf("%v != %v", err, io.EOF)
+	}
+	select {
+	case <-server.Done():
+		t.Errorf("server is done")
+	default:
+	}
+	// The listeners are reopened after the save fails.
+	for n := 0; len(server.ListenAddrs()) == 0; n++ {
+		if 50 <= n {
+			t.Fatalf("server is not listening")
+		}
+		time.Sleep(time.Millisecond * 100)
+	}
+	addr = server.ListenAddrs()[0]
+	forceConn := sendCommand(addr, "*2\r\n$8\r\nSHUTDOWN\r\n$5\r\nFORCE\r\n")
+	defer forceConn.Close()
+	waitDone(server)
+
+	// Shutdown saves the dataset even if the context expires.
+	store = newStore(time.Second*10, nil)
+	server, addr = startServer(store)
+	slowConn := sendCommand(addr, setReq)
+	defer slowConn.Close()
+	<-store.started
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	if err := server.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("%v != %v", err, context.DeadlineExceeded)
+	}
+	store.Lock()
+	if store.saved["k"] != "v" {
+		t.Errorf("%s != %s", store.saved["k"], "v")
+	}
+	store.Unlock()
+}
+
+func TestServerIdleTimeout(t *testing.T) {
+	server := NewServer()
+	server.SetPort(0)
+	server.SetTimeout(1)
+
+	server.Use(func(next Executor) Executor {
+		return func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+			conn.SetClientType(PubSubClient)
+			return NewOKMessage(), nil
+		}
+	})
+
+	err := server.Start()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer server.Stop()
+
+	addr := server.tcpListeners[0].Addr().String()
+	idleConn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer idleConn.Close()
+
+	subConn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer subConn.Close()
+	if _, err := subConn.Write([]byte("*2\r\n$9\r\nSUBSCRIBE\r\n$1\r\nc\r\n")); err != nil {
+		t.Error(err)
+		return
+	}
+	subReader := bufio.NewReader(subConn)
+	if res, err := subReader.ReadString('\n'); err != nil || res != "+OK\r\n" {
+		t.Errorf("invalid response (%s, %v)", res, err)
+		return
+	}
+
+	// The idle connection should be closed by the timeout.
+	idleConn.SetReadDeadline(time.Now().Add(time.Second * 5))
+	if _, err := idleConn.Read(make([]byte, 1)); !errors.Is(err, io.EOF) {
+		t.Errorf("%v != %v", err, io.EOF)
+	}
+
+	// The subscriber connection should be still alive.
+	if _, err := subConn.Write([]byte("*1\r\n$4\r\nPING\r\n")); err != nil {
+		t.Error(err)
+		return
+	}
+	subConn.SetReadDeadline(time.Now().Add(time.Second * 5))
+	if res, err := subReader.ReadString('\n'); err != nil || res != "+OK\r\n" {
+		t.Errorf("invalid response (%s, %v)", res, err)
+	}
+
+	// The subscriber connection which stops in the middle of a request should be closed by the timeout.
+	if _, err := subConn.Write([]byte("*1\r\n$4\r\nPI")); err != nil {
+		t.Error(err)
+		return
+	}
+	subConn.SetReadDeadline(time.Now().Add(time.Second * 5))
+	if _, err := subReader.ReadByte(); !errors.Is(err, io.EOF) {
+		t.Errorf("%v != %v", err, io.EOF)
+	}
+}
+
+func TestServerMonitorAuth(t *testing.T) {
+	server := NewServer()
+	server.SetPort(0)
+	server.SetRequirePass("password")
+	server.SetFallbackExecutor(func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+		return NewOKMessage(), nil
+	})
+
+	err := server.Start()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer server.Stop()
+
+	addr := server.tcpListeners[0].Addr().String()
+	dial := func() (net.Conn, *bufio.Reader) {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.SetDeadline(time.Now().Add(time.Second * 5))
+		return conn, bufio.NewReader(conn)
+	}
+	request := func(conn net.Conn, reader *bufio.Reader, req string) string {
+		if _, err := conn.Write([]byte(req)); err != nil {
+			t.Fatal(err)
+		}
+		res, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	auth := "*2\r\n$4\r\nAUTH\r\n$8\r\npassword\r\n"
+
+	monitor, monitorReader := dial()
+	defer monitor.Close()
+	request(monitor, monitorReader, auth)
+	if res := request(monitor, monitorReader, "*1\r\n$7\r\nMONITOR\r\n"); res != "+OK\r\n" {
+		t.Fatalf("invalid response (%s)", res)
+	}
+
+	conn, reader := dial()
+	defer conn.Close()
+	if res := request(conn, reader, "*2\r\n$3\r\nGET\r\n$6\r\nsecret\r\n"); !strings.HasPrefix(res, "-NOAUTH") {
+		t.Errorf("invalid response (%s)", res)
+	}
+	request(conn, reader, auth)
+
+	// The unauthorized command should not be sent to the monitor.
+	line, err := monitorReader.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(line, `"AUTH" "(redacted)"`+"\r\n") {
+		t.Errorf("%s is not ended with %s", line, `"AUTH" "(redacted)"`)
+	}
+}
+
+func TestServerMaxClients(t *testing.T) {
+	server := NewServer()
+	server.SetPort(0)
+
+	server.Use(func(next Executor) Executor {
+		return func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+			return NewOKMessage(), nil
+		}
+	})
+
+	err := server.Start()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer server.Stop()
+
+	addr := server.tcpListeners[0].Addr().String()
+
+	limits := []struct {
+		maxClients      int
+		maxClientsPerIP int
+		expected        error
+	}{
+		{1, 0, ErrMaxClients},
+		{0, 1, ErrMaxClientsPerIP},
+	}
+	for n, limit := range limits {
+		t.Run(limit.expected.Error(), func(t *testing.T) {
+			for 0 < server.ClientConns().Len() {
+				time.Sleep(time.Millisecond * 10)
+			}
+			server.SetMaxClients(limit.maxClients)
+			server.SetMaxClientsPerIP(limit.maxClientsPerIP)
+
+			conn, err := net.Dial("tcp", addr)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer conn.Close()
+			conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+			if _, err := conn.Write([]byte("*1\r\n$4\r\nPING\r\n")); err != nil {
+				t.Error(err)
+				return
+			}
+			if res, err := bufio.NewReader(conn).ReadString('\n'); err != nil || res != "+OK\r\n" {
+				t.Errorf("invalid response (%s, %v)", res, err)
+				return
+			}
+
+			rejectedConn, err := net.Dial("tcp", addr)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer rejectedConn.Close()
+			rejectedConn.SetReadDeadline(time.Now().Add(time.Second * 5))
+			reader := bufio.NewReader(rejectedConn)
+			expected := "-" + limit.expected.Error() + "\r\n"
+			if res, err := reader.ReadString('\n'); err != nil || res != expected {
+				t.Errorf("invalid response (%s, %v)", res, err)
+				return
+			}
+			if _, err := reader.ReadByte(); !errors.Is(err, io.EOF) {
+				t.Errorf("%v != %v", err, io.EOF)
+			}
+
+			if server.ServerStats().RejectedConnections() != int64(n+1) {
+				t.Errorf("%d != %d", server.ServerStats().RejectedConnections(), n+1)
+			}
+		})
+	}
+}
+
+func TestServerOutputBufferLimit(t *testing.T) {
+	server := NewServer()
+	server.SetPort(0)
+	server.SetClientOutputBufferLimit(NormalClient, ClientOutputBufferLimit{
+		HardLimit:   1024,
+		SoftLimit:   0,
+		SoftSeconds: 0,
+	})
+
+	server.Use(func(next Executor) Executor {
+		return func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+			if cmd == "PING" {
+				return NewOKMessage(), nil
+			}
+			return NewBulkMessage(strings.Repeat("x", 4096)), nil
+		}
+	})
+
+	err := server.Start()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer server.Stop()
+
+	conn, err := net.Dial("tcp", server.tcpListeners[0].Addr().String())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+
+	reader := bufio.NewReader(conn)
+	if _, err := conn.Write([]byte("*1\r\n$4\r\nPING\r\n")); err != nil {
+		t.Error(err)
+		return
+	}
+	if res, err := reader.ReadString('\n'); err != nil || res != "+OK\r\n" {
+		t.Errorf("invalid response (%s, %v)", res, err)
+		return
+	}
+
+	if _, err := conn.Write([]byte("*2\r\n$4\r\nKEYS\r\n$1\r\n*\r\n")); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := reader.ReadByte(); !errors.Is(err, io.EOF) {
+		t.Errorf("%v != %v", err, io.EOF)
+	}
+
+	if server.ServerStats().OutputBufferLimitDisconnections() != 1 {
+		t.Errorf("%d != %d", server.ServerStats().OutputBufferLimitDisconnections(), 1)
+	}
+}
+
+func TestServerBind(t *testing.T) {
+	server := NewServer()
+	server.SetPort(0)
+	server.SetBind("127.0.0.1", "-::1", "-192.0.2.1")
+
+	server.Use(func(next Executor) Executor {
+		return func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+			return NewOKMessage(), nil
+		}
+	})
+
+	err := server.Start()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer server.Stop()
+
+	if len(server.tcpListeners) == 0 {
+		t.Errorf("no listeners")
+		return
+	}
+
+	for _, l := range server.tcpListeners {
+		addr := l.Addr().String()
+		t.Run(addr, func(t *testing.T) {
+			conn, err := net.Dial("tcp", addr)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer conn.Close()
+			conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+			if _, err := conn.Write([]byte("*1\r\n$4\r\nPING\r\n")); err != nil {
+				t.Error(err)
+				return
+			}
+			if res, err := bufio.NewReader(conn).ReadString('\n'); err != nil || res != "+OK\r\n" {
+				t.Errorf("invalid response (%s, %v)", res, err)
+			}
+		})
+	}
+}
+
+func TestServerProtectedMode(t *testing.T) {
+	server := NewServer()
+	server.SetPort(0)
+	if !server.ConfigProtectedMode() {
+		t.Errorf("protected mode is disabled by default")
+	}
+
+	loopbackAddr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1234, Zone: ""}
+	remoteAddr := &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 1234, Zone: ""}
+
+	modes := []struct {
+		bind          string
+		protectedMode bool
+		password      string
+		addr          net.Addr
+		expected      bool
+	}{
+		{"*", true, "", remoteAddr, true},
+		{"*", true, "", loopbackAddr, false},
+		{"*", true, "passwd", remoteAddr, false},
+		{"*", false, "", remoteAddr, false},
+		{"127.0.0.1", true, "", remoteAddr, false},
+	}
+	for _, mode := range modes {
+		t.Run(fmt.Sprintf("%s/%v/%s/%s", mode.bind, mode.protectedMode, mode.password, mode.addr), func(t *testing.T) {
+			server.SetBind(mode.bind)
+			server.SetProtectedMode(mode.protectedMode)
+			server.RemoveRequirePass()
+			if 0 < len(mode.password) {
+				server.SetRequirePass(mode.password)
+			}
+			if err := server.Start(); err != nil {
+				t.Error(err)
+				return
+			}
+			defer server.Stop()
+			if denied := server.isProtectedModeDenied(server.ServerConfig, mode.addr); denied != mode.expected {
+				t.Errorf("%v != %v", denied, mode.expected)
+			}
+		})
+	}
+}
+
+func TestServerRebind(t *testing.T) {
+	server := NewServer()
+	server.SetPort(0)
+	server.SetBind("127.0.0.1")
+
+	server.Use(func(next Executor) Executor {
+		return func(conn *Conn, cmd string, args Arguments) (*Message, error) {
+			return NewOKMessage(), nil
+		}
+	})
+
+	err := server.Start()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer server.Stop()
+
+	ping := func(conn net.Conn) error {
+		conn.SetDeadline(time.Now().Add(time.Second * 5))
+		if _, err := conn.Write([]byte("*1\r\n$4\r\nPING\r\n")); err != nil {
+			return err
+		}
+		res, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil {
+			return err
+		}
+		if res != "+OK\r\n" {
+			return fmt.Errorf("invalid response (%s)", res)
+		}
+		return nil
+	}
+
+	oldAddr := server.tcpListeners[0].Addr().String()
+	conn, err := net.Dial("tcp", oldAddr)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer conn.Close()
+
+	freeListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	newPort := freeListener.Addr().(*net.TCPAddr).Port
+	freeListener.Close()
+
+	if _, err := server.ConfigSet(nil, map[string]string{"port": strconv.Itoa(newPort)}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	// The existing connection is kept.
+	if err := ping(conn); err != nil {
+		t.Error(err)
+	}
+
+	newAddr := net.JoinHostPort("127.0.0.1", strconv.Itoa(newPort))
+	newConn, err := net.Dial("tcp", newAddr)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	newConn.Close()
+
+	if oldConn, err := net.Dial("tcp", oldAddr); err == nil {
+		oldConn.Close()
+		t.Errorf("%s is still listening", oldAddr)
+	}
+
+	// The listen sockets are kept if the new listen socket can't be opened.
+	if _, err := server.ConfigSet(nil, map[string]string{"bind": "192.0.2.1"}); !errors.Is(err, ErrConfigSet) {
+		t.Errorf("%v != %v", err, ErrConfigSet)
+	}
+	if binds := server.ConfigBind(); len(binds) != 1 || binds[0] != "127.0.0.1" {
+		t.Errorf("%v != %v", binds, []string{"127.0.0.1"})
+	}
+	newConn, err = net.Dial("tcp", newAddr)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	newConn.Close()
+
+	if _, err := server.ConfigSet(nil, map[string]string{"tls-port": "6380"}); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("%v != %v", err, ErrNotSupported)
+	}
+
+	sockPath := filepath.Join(t.TempDir(), "redis.sock")
+	if _, err := server.ConfigSet(nil, map[string]string{"unixsocket": sockPath}); err != nil {
+		t.Error(err)
+		return
+	}
+	unixConn, err := net.Dial("unix", sockPath)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer unixConn.Close()
+	if err := ping(unixConn); err != nil {
+		t.Error(err)
+	}
+
+	// Only the conflicting listen socket is closed to listen on all interfaces on the same port.
+	unixListener := server.tcpListeners[len(server.tcpListeners)-1]
+	if _, err := server.ConfigSet(nil, map[string]string{"bind": "*"}); err != nil {
+		t.Error(err)
+		return
+	}
+	if !containsListener(server.tcpListeners, unixListener) {
+		t.Errorf("%s is reopened", sockPath)
+	}
+	if err := ping(unixConn); err != nil {
+		t.Error(err)
+	}
+	newConn, err = net.Dial("tcp", newAddr)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	newConn.Close()
+}
@@ -0,0 +1,73 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"net"
+	"time"
+
+	"github.com/cybergarage/go-logger/log"
+)
+
+// setKeepAlive applies the TCP keepalive configuration to the specified accepted connection.
+func (server *Server) setKeepAlive(conn net.Conn) {
+	tcpConn, ok := conn.(*net.TCPConn)
+	if !ok {
+		return
+	}
+	period := server.ConfigTCPKeepAlive()
+	if period <= 0 {
+		if err := tcpConn.SetKeepAlive(false); err != nil {
+			log.Error(err)
+		}
+		return
+	}
+	if err := tcpConn.SetKeepAlive(true); err != nil {
+		log.Error(err)
+		return
+	}
+	if err := tcpConn.SetKeepAlivePeriod(time.Duration(period) * time.Second); err != nil {
+		log.Error(err)
+	}
+}
+
+// isIdleTimeoutExempt returns true if the specified connection is not closed by the idle timeout.
+// The blocked clients, the subscribers, the replicas and the monitors are exempted.
+func isIdleTimeoutExempt(conn *Conn) bool {
+	return conn.IsBlocked() || conn.ClientType() != NormalClient
+}
+
+// setIdleDeadline sets the read deadline of the specified connection based on the idle timeout.
+// The deadline of the exempted connections is extended to check the timeout again later.
+func (server *Server) setIdleDeadline(conn *Conn) error {
+	timeout := server.ConfigTimeout()
+	if timeout <= 0 {
+		return conn.SetReadDeadline(time.Time{})
+	}
+	timeoutDuration := time.Duration(timeout) * time.Second
+	if isIdleTimeoutExempt(conn) {
+		return conn.SetReadDeadline(time.Now().Add(timeoutDuration))
+	}
+	return conn.SetReadDeadline(conn.LastInteraction().Add(timeoutDuration))
+}
+
+// isIdleTimeout returns true if the specified connection should be closed by the idle timeout.
+func (server *Server) isIdleTimeout(conn *Conn) bool {
+	timeout := server.ConfigTimeout()
+	if timeout <= 0 || isIdleTimeoutExempt(conn) {
+		return false
+	}
+	return !time.Now().Before(conn.LastInteraction().Add(time.Duration(timeout) * time.Second))
+}
@@ -0,0 +1,164 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// SlowLogEntryMaxArgs is the maximum number of the arguments kept in a slow log entry.
+	SlowLogEntryMaxArgs = 32
+	// SlowLogEntryMaxString is the maximum length of an argument kept in a slow log entry.
+	SlowLogEntryMaxString = 128
+	// DefaultSlowLogGetCount is the default number of the entries returned by SLOWLOG GET.
+	DefaultSlowLogGetCount = 10
+)
+
+// SlowLogEntry represents a slow log entry.
+type SlowLogEntry struct {
+	ID         int64
+	Timestamp  time.Time
+	Duration   time.Duration
+	Arguments  []string
+	ClientAddr string
+	ClientName string
+}
+
+// newSlowLogEntryArguments returns the truncated arguments for a slow log entry.
+func newSlowLogEntryArguments(args []string) []string {
+	argc := len(args)
+	if SlowLogEntryMaxArgs < argc {
+		argc = SlowLogEntryMaxArgs
+	}
+	entryArgs := make([]string, argc)
+	for n := 0; n < argc; n++ {
+		if n == (SlowLogEntryMaxArgs-1) && argc < len(args) {
+			entryArgs[n] = fmt.Sprintf("... (%d more arguments)", len(args)-argc+1)
+			break
+		}
+		arg := args[n]
+		if SlowLogEntryMaxString < len(arg) {
+			arg = fmt.Sprintf("%s... (%d more bytes)", arg[:SlowLogEntryMaxString], len(arg)-SlowLogEntryMaxString)
+		}
+		entryArgs[n] = arg
+	}
+	return entryArgs
+}
+
+// SlowLog represents a ring buffer of the slow log entries.
+type SlowLog struct {
+	sync.Mutex
+	entries []*SlowLogEntry
+	head    int
+	count   int
+	nextID  int64
+}
+
+// NewSlowLog returns a new slow log.
+func NewSlowLog() *SlowLog {
+	return &SlowLog{
+		Mutex:   sync.Mutex{},
+		entries: []*SlowLogEntry{},
+		head:    0,
+		count:   0,
+		nextID:  0,
+	}
+}
+
+// resize changes the ring buffer size keeping the newest entries.
+func (slowlog *SlowLog) resize(maxLen int) {
+	if maxLen < 0 {
+		maxLen = 0
+	}
+	if maxLen == len(slowlog.entries) {
+		return
+	}
+	newest := slowlog.entriesLocked(maxLen)
+	entries := make([]*SlowLogEntry, maxLen)
+	for n, entry := range newest {
+		entries[len(newest)-1-n] = entry
+	}
+	slowlog.entries = entries
+	slowlog.count = len(newest)
+	slowlog.head = 0
+	if 0 < maxLen {
+		slowlog.head = slowlog.count % maxLen
+	}
+}
+
+// entriesLocked returns the specified number of the newest entries, newest first.
+func (slowlog *SlowLog) entriesLocked(count int) []*SlowLogEntry {
+	if count < 0 || slowlog.count < count {
+		count = slowlog.count
+	}
+	entries := make([]*SlowLogEntry, count)
+	size := len(slowlog.entries)
+	for n := 0; n < count; n++ {
+		entries[n] = slowlog.entries[(slowlog.head-1-n+size)%size]
+	}
+	return entries
+}
+
+// Push adds a new entry into the slow log keeping at most the specified number of entries.
+func (slowlog *SlowLog) Push(maxLen int, ts time.Time, d time.Duration, args []string, clientAddr string, clientName string) {
+	slowlog.Lock()
+	defer slowlog.Unlock()
+	slowlog.resize(maxLen)
+	entry := &SlowLogEntry{
+		ID:         slowlog.nextID,
+		Timestamp:  ts,
+		Duration:   d,
+		Arguments:  newSlowLogEntryArguments(args),
+		ClientAddr: clientAddr,
+		ClientName: clientName,
+	}
+	slowlog.nextID++
+	if len(slowlog.entries) == 0 {
+		return
+	}
+	slowlog.entries[slowlog.head] = entry
+	slowlog.head = (slowlog.head + 1) % len(slowlog.entries)
+	if slowlog.count < len(slowlog.entries) {
+		slowlog.count++
+	}
+}
+
+// Entries returns the specified number of the newest entries, newest first. A negative count returns all entries.
+func (slowlog *SlowLog) Entries(count int) []*SlowLogEntry {
+	slowlog.Lock()
+	defer slowlog.Unlock()
+	return slowlog.entriesLocked(count)
+}
+
+// Len returns the number of the entries.
+func (slowlog *SlowLog) Len() int {
+	slowlog.Lock()
+	defer slowlog.Unlock()
+	return slowlog.count
+}
+
+// Reset removes all entries.
+func (slowlog *SlowLog) Reset() {
+	slowlog.Lock()
+	defer slowlog.Unlock()
+	for n := range slowlog.entries {
+		slowlog.entries[n] = nil
+	}
+	slowlog.head = 0
+	slowlog.count = 0
+}
@@ -0,0 +1,72 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlowLog(t *testing.T) {
+	slowlog := NewSlowLog()
+
+	maxLen := 3
+	for n := 0; n < 5; n++ {
+		slowlog.Push(maxLen, time.Now(), time.Millisecond, []string{"GET", strconv.Itoa(n)}, "", "")
+	}
+
+	if slowlog.Len() != maxLen {
+		t.Errorf("%d != %d", slowlog.Len(), maxLen)
+	}
+
+	entries := slowlog.Entries(-1)
+	for n, entry := range entries {
+		expected := int64(4 - n)
+		if entry.ID != expected {
+			t.Errorf("%d != %d", entry.ID, expected)
+		}
+	}
+
+	maxLen = 2
+	slowlog.Push(maxLen, time.Now(), time.Millisecond, []string{"GET", "5"}, "", "")
+	entries = slowlog.Entries(-1)
+	if len(entries) != maxLen || entries[0].ID != 5 || entries[1].ID != 4 {
+		t.Errorf("invalid entries (%v)", entries)
+	}
+
+	args := []string{"MSET"}
+	for n := 0; n < SlowLogEntryMaxArgs*2; n++ {
+		args = append(args, strings.Repeat("x", SlowLogEntryMaxString*2))
+	}
+	slowlog.Push(maxLen, time.Now(), time.Millisecond, args, "", "")
+	entries = slowlog.Entries(1)
+	entryArgs := entries[0].Arguments
+	if len(entryArgs) != SlowLogEntryMaxArgs {
+		t.Errorf("%d != %d", len(entryArgs), SlowLogEntryMaxArgs)
+	}
+	if !strings.HasSuffix(entryArgs[1], "... (128 more bytes)") {
+		t.Errorf("%s is not truncated", entryArgs[1])
+	}
+	if entryArgs[SlowLogEntryMaxArgs-1] != "... (34 more arguments)" {
+		t.Errorf("%s is not truncated", entryArgs[SlowLogEntryMaxArgs-1])
+	}
+
+	slowlog.Reset()
+	if slowlog.Len() != 0 {
+		t.Errorf("%d != %d", slowlog.Len(), 0)
+	}
+}
@@ -31,7 +31,7 @@ func (server *Server) registerSugarExecutors() {
 		if !getRet.IsNil() {
 			retVal, err := getRet.Integer()
 			if err != nil {
-				return nil, err
+				return nil, newNotIntegerArgumentError(err)
 			}
 			currVal = retVal
 		}
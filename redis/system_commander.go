@@ -14,7 +14,23 @@
 
 package redis
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cybergarage/go-logger/log"
+)
+
 func (server *Server) Ping(conn *Conn, arg string) (*Message, error) {
+	if server.isPubSubContext(conn) {
+		msg := NewArrayMessage()
+		msg.Append(NewBulkMessage("pong"))
+		msg.Append(NewBulkMessage(arg))
+		return msg, nil
+	}
 	if len(arg) == 0 {
 		return NewStringMessage("PONG"), nil
 	}
@@ -34,23 +50,106 @@ func (server *Server) Quit(conn *Conn) (*Message, error) {
 	return NewOKMessage(), ErrQuit
 }
 
+func (server *Server) ClientSetName(conn *Conn, name string) (*Message, error) {
+	if strings.ContainsAny(name, " \n") {
+		return nil, ErrInvalidClientName
+	}
+	conn.SetName(name)
+	return NewOKMessage(), nil
+}
+
+func (server *Server) ClientGetName(conn *Conn) (*Message, error) {
+	if len(conn.Name()) == 0 {
+		return NewNilMessage(), nil
+	}
+	return NewBulkMessage(conn.Name()), nil
+}
+
 func (server *Server) ConfigSet(conn *Conn, params map[string]string) (*Message, error) {
-	for key, param := range params {
-		server.SetConfig(key, param)
+	if err := server.SetConfigs(params); err != nil {
+		return nil, err
 	}
 	return NewOKMessage(), nil
 }
 
 func (server *Server) ConfigGet(conn *Conn, keys []string) (*Message, error) {
+	values, err := server.ConfigValues(keys...)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 	msg := NewArrayMessage()
-	for _, key := range keys {
-		msg.Append(NewBulkMessage(key))
-		param, ok := server.ConfigParameter(key)
-		if ok {
-			msg.Append(NewBulkMessage(param))
-		} else {
-			msg.Append(NewBulkMessage(""))
+	for _, name := range names {
+		msg.Append(NewBulkMessage(name))
+		msg.Append(NewBulkMessage(values[name]))
+	}
+	return msg, nil
+}
+
+func (server *Server) ConfigResetStat(conn *Conn) (*Message, error) {
+	server.commandStats.Reset()
+	server.serverStats.Reset()
+	return NewOKMessage(), nil
+}
+
+func (server *Server) ConfigRewrite(conn *Conn) (*Message, error) {
+	if err := server.RewriteConfigFile(); err != nil {
+		if errors.Is(err, ErrNoConfigFile) {
+			return nil, err
 		}
+		return nil, fmt.Errorf("Rewriting config file: %w", err)
+	}
+	return NewOKMessage(), nil
+}
+
+func (server *Server) Info(conn *Conn, sections []string) (*Message, error) {
+	return NewBulkMessage(server.InfoString(sections)), nil
+}
+
+func (server *Server) SlowLogGet(conn *Conn, count int) (*Message, error) {
+	msg := NewArrayMessage()
+	for _, entry := range server.slowLog.Entries(count) {
+		entryMsg := NewArrayMessage()
+		entryMsg.Append(NewIntegerMessage(int(entry.ID)))
+		entryMsg.Append(NewIntegerMessage(int(entry.Timestamp.Unix())))
+		entryMsg.Append(NewIntegerMessage(int(entry.Duration.Microseconds())))
+		entryMsg.Append(NewStringArrayMessage(entry.Arguments))
+		entryMsg.Append(NewBulkMessage(entry.ClientAddr))
+		entryMsg.Append(NewBulkMessage(entry.ClientName))
+		msg.Append(entryMsg)
 	}
 	return msg, nil
 }
+
+func (server *Server) SlowLogLen(conn *Conn) (*Message, error) {
+	return NewIntegerMessage(server.slowLog.Len()), nil
+}
+
+func (server *Server) SlowLogReset(conn *Conn) (*Message, error) {
+	server.slowLog.Reset()
+	return NewOKMessage(), nil
+}
+
+func (server *Server) Monitor(conn *Conn) (*Message, error) {
+	conn.SetClientType(MonitorClient)
+	server.monitorConns.Add(conn)
+	return NewOKMessage(), nil
+}
+
+func (server *Server) ShutdownServer(conn *Conn, opt ShutdownOption) (*Message, error) {
+	// The dataset is saved after the in-flight commands of the other clients finish. If the save fails without FORCE,
+	// the shutdown is aborted and the server keeps serving.
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultShutdownTimeout)
+		defer cancel()
+		if err := server.shutdown(ctx, !opt.NOSAVE, opt.FORCE); err != nil {
+			log.Error(err)
+		}
+	}()
+	// The connection is closed by the shutdown without any reply.
+	return nil, ErrNoReply
+}
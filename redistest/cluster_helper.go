@@ -0,0 +1,213 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redistest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cybergarage/go-redis/redis"
+	goredis "github.com/go-redis/redis"
+)
+
+// ClusterPorts is the ports of the cluster nodes which ClusterTest starts.
+var ClusterPorts = []int{6390, 6391, 6392}
+
+// NewClusterServers returns the servers of a cluster which the hash slots are assigned evenly to the specified ports.
+func NewClusterServers(ports []int) ([]*Server, error) {
+	nodes := []*redis.ClusterNode{}
+	for n, port := range ports {
+		start := redis.ClusterSlots * n / len(ports)
+		end := redis.ClusterSlots*(n+1)/len(ports) - 1
+		nodes = append(nodes, redis.NewClusterNode(LocalHost, port).AddSlots(start, end))
+	}
+	servers := []*Server{}
+	for n, port := range ports {
+		server := NewServer()
+		server.SetPort(port)
+		server.SetClusterEnabled(true)
+		cluster := server.Cluster()
+		cluster.SetMyID(nodes[n].ID)
+		for _, node := range nodes {
+			if err := cluster.AddNode(node); err != nil {
+				return nil, err
+			}
+		}
+		servers = append(servers, server)
+	}
+	return servers, nil
+}
+
+// ClusterTest tests the cluster mode with the cluster nodes on the specified ports.
+// nolint: gocyclo
+func ClusterTest(t *testing.T, ports []int) {
+	t.Helper()
+
+	servers, err := NewClusterServers(ports)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, server := range servers {
+		if err := server.Start(); err != nil {
+			t.Error(err)
+			return
+		}
+		defer server.Stop()
+	}
+
+	addrs := []string{}
+	for _, port := range ports {
+		addrs = append(addrs, fmt.Sprintf("%s:%d", LocalHost, port))
+	}
+
+	// Cluster-aware client
+
+	cluster := goredis.NewClusterClient(&goredis.ClusterOptions{ // nolint: exhaustruct
+		Addrs: addrs,
+	})
+	defer cluster.Close()
+
+	keys := []string{}
+	for n := 0; n < 30; n++ {
+		keys = append(keys, fmt.Sprintf("cluster_key%d", n))
+	}
+	for _, key := range keys {
+		if err := cluster.Set(key, key, 0).Err(); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	for _, key := range keys {
+		val, err := cluster.Get(key).Result()
+		if err != nil || val != key {
+			t.Errorf("%s != %s (%v)", val, key, err)
+		}
+	}
+
+	// The keys are distributed to all nodes
+
+	nodeKeys := 0
+	for _, server := range servers {
+		stats, err := server.KeyspaceStats()
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		stat, ok := stats[0]
+		if !ok || stat.Keys == 0 {
+			t.Errorf("node %d has no keys", server.ConfigPort())
+			continue
+		}
+		nodeKeys += stat.Keys
+	}
+	if nodeKeys != len(keys) {
+		t.Errorf("%d != %d", nodeKeys, len(keys))
+	}
+
+	// MOVED
+
+	opts := NewClientOptions()
+	opts.DB = 0
+	opts.Addr = addrs[0]
+	client := &Client{Client: goredis.NewClient(&opts)}
+	defer client.Close()
+
+	key := "cluster_moved"
+	slot := redis.KeySlot(key)
+	owner := slot * len(ports) / redis.ClusterSlots
+	expected := fmt.Sprintf("MOVED %d %s", slot, addrs[owner])
+	if owner == 0 {
+		t.Errorf("%s is served by the first node", key)
+	}
+	if err := client.Get(key).Err(); err == nil || err.Error() != expected {
+		t.Errorf("%v != %s", err, expected)
+	}
+
+	// CROSSSLOT
+
+	err = client.MSet("{a}key", "1", "{b}key", "2").Err()
+	if err == nil || !strings.HasPrefix(err.Error(), "CROSSSLOT") {
+		t.Errorf("CROSSSLOT is not returned (%v)", err)
+	}
+
+	// CLUSTER commands
+
+	if n, err := client.ClusterKeySlot(key).Result(); err != nil || int(n) != slot {
+		t.Errorf("%d != %d (%v)", n, slot, err)
+	}
+	info, err := client.ClusterInfo().Result()
+	if err != nil || !strings.Contains(info, "cluster_state:ok") {
+		t.Errorf("invalid CLUSTER INFO (%s)", info)
+	}
+	slots, err := client.ClusterSlots().Result()
+	if err != nil || len(slots) != len(ports) {
+		t.Errorf("invalid CLUSTER SLOTS (%v)", slots)
+	}
+	nodes, err := client.ClusterNodes().Result()
+	if err != nil || strings.Count(nodes, "\n") != len(ports) || !strings.Contains(nodes, "myself,master") {
+		t.Errorf("invalid CLUSTER NODES (%s)", nodes)
+	}
+	myID, err := client.Do("CLUSTER", "MYID").String()
+	if err != nil || myID != servers[0].Cluster().MyID() {
+		t.Errorf("%s != %s (%v)", myID, servers[0].Cluster().MyID(), err)
+	}
+
+	ownedKeys := []string{}
+	for _, key := range keys {
+		if redis.KeySlot(key) < redis.ClusterSlots/len(ports) {
+			ownedKeys = append(ownedKeys, key)
+		}
+	}
+	if 0 < len(ownedKeys) {
+		slot := int64(redis.KeySlot(ownedKeys[0]))
+		n, err := client.ClusterCountKeysInSlot(int(slot)).Result()
+		if err != nil || n < 1 {
+			t.Errorf("no keys in slot %d (%v)", slot, err)
+		}
+		inSlot, err := client.ClusterGetKeysInSlot(int(slot), 10).Result()
+		if err != nil || int64(len(inSlot)) != n {
+			t.Errorf("%v is not %d keys (%v)", inSlot, n, err)
+		}
+	}
+
+	// ASK
+
+	migratingSlot := redis.KeySlot("cluster_ask")
+	source := migratingSlot * len(ports) / redis.ClusterSlots
+	target := (source + 1) % len(ports)
+	targetID := servers[target].Cluster().MyID()
+	sourceID := servers[source].Cluster().MyID()
+	if err := servers[source].Cluster().SetSlotMigrating(migratingSlot, targetID); err != nil {
+		t.Error(err)
+	}
+	if err := servers[target].Cluster().SetSlotImporting(migratingSlot, sourceID); err != nil {
+		t.Error(err)
+	}
+	opts.Addr = addrs[source]
+	sourceClient := &Client{Client: goredis.NewClient(&opts)}
+	defer sourceClient.Close()
+	expected = fmt.Sprintf("ASK %d %s", migratingSlot, addrs[target])
+	if err := sourceClient.Get("cluster_ask").Err(); err == nil || err.Error() != expected {
+		t.Errorf("%v != %s", err, expected)
+	}
+	if err := cluster.Set("cluster_ask", "ask", 0).Err(); err != nil {
+		t.Error(err)
+	}
+	if db, err := servers[target].GetDatabase(0); err != nil || !db.HasRecord("cluster_ask") {
+		t.Errorf("cluster_ask is not imported")
+	}
+}
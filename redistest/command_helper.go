@@ -15,7 +15,9 @@
 package redistest
 
 import (
+	"bufio"
 	"fmt"
+	"net"
 	"reflect"
 	"strconv"
 	"strings"
@@ -122,6 +124,37 @@ func ConnectionCommandTest(t *testing.T, client *Client) {
 			})
 		}
 	})
+
+	t.Run("ERROR", func(t *testing.T) {
+		if err := client.Set("key_error", "value", 0).Err(); err != nil {
+			t.Error(err)
+			return
+		}
+		records := []struct {
+			args     []interface{}
+			expected string
+		}{
+			{[]interface{}{"GET"}, "ERR wrong number of arguments for 'get' command"},
+			{[]interface{}{"SET", "key"}, "ERR wrong number of arguments for 'set' command"},
+			{[]interface{}{"NOCOMMAND", "a", "b"}, "ERR unknown command 'NOCOMMAND', with args beginning with: 'a' 'b' "},
+			{[]interface{}{"CONFIG", "NOSUBCOMMAND"}, "ERR unknown subcommand 'NOSUBCOMMAND'. Try CONFIG HELP."},
+			{[]interface{}{"LPUSH", "key_error", "a"}, "WRONGTYPE Operation against a key holding the wrong kind of value"},
+			{[]interface{}{"INCR", "key_error"}, "ERR value is not an integer or out of range"},
+			{[]interface{}{"SET", "key_error", "value", "NOOPTION"}, "ERR syntax error"},
+		}
+		for _, r := range records {
+			t.Run(r.expected, func(t *testing.T) {
+				err := client.Do(r.args...).Err()
+				if err == nil {
+					t.Errorf("%v should be failed", r.args)
+					return
+				}
+				if err.Error() != r.expected {
+					t.Errorf("'%s' != '%s'", err.Error(), r.expected)
+				}
+			})
+		}
+	})
 }
 
 // nolint: maintidx, gocyclo
@@ -142,6 +175,197 @@ func ServerCommandTest(t *testing.T, client *Client) {
 				}
 			})
 		}
+		t.Run("GET", func(t *testing.T) {
+			vals, err := client.ConfigGet("maxclients*").Result()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			expected := []interface{}{"maxclients", "10000", "maxclients-per-ip", "0"}
+			if !reflect.DeepEqual(vals, expected) {
+				t.Errorf("%v != %v", vals, expected)
+			}
+		})
+		t.Run("SET", func(t *testing.T) {
+			if err := client.ConfigSet("slowlog-max-len", "64").Err(); err != nil {
+				t.Error(err)
+				return
+			}
+			defer client.ConfigSet("slowlog-max-len", "128")
+			errs := []struct {
+				key      string
+				val      string
+				expected string
+			}{
+				{"slowlog-max-len", "abc", "ERR CONFIG SET failed (possibly related to argument 'slowlog-max-len') - argument couldn't be parsed into an integer"},
+				{"unknown-param", "1", "ERR Unknown option or number of arguments for CONFIG SET - 'unknown-param'"},
+				{"metrics-port", "9181", "ERR CONFIG SET failed (possibly related to argument 'metrics-port') - can't set immutable config"},
+			}
+			for _, e := range errs {
+				err := client.ConfigSet(e.key, e.val).Err()
+				if err == nil || err.Error() != e.expected {
+					t.Errorf("%v != %s", err, e.expected)
+				}
+			}
+			vals, err := client.ConfigGet("slowlog-max-len").Result()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			expected := []interface{}{"slowlog-max-len", "64"}
+			if !reflect.DeepEqual(vals, expected) {
+				t.Errorf("%v != %v", vals, expected)
+			}
+		})
+		t.Run("REWRITE", func(t *testing.T) {
+			expected := "ERR The server is running without a config file"
+			err := client.ConfigRewrite().Err()
+			if err == nil || err.Error() != expected {
+				t.Errorf("%v != %s", err, expected)
+			}
+		})
+	})
+
+	t.Run("INFO", func(t *testing.T) {
+		if err := client.ConfigResetStat().Err(); err != nil {
+			t.Error(err)
+			return
+		}
+		if err := client.Echo("info").Err(); err != nil {
+			t.Error(err)
+			return
+		}
+		sections := []struct {
+			section  string
+			expected string
+		}{
+			{"commandstats", "cmdstat_echo:calls=1,"},
+			{"latencystats", "latency_percentiles_usec_echo:p50="},
+		}
+		for _, r := range sections {
+			t.Run(r.section, func(t *testing.T) {
+				info, err := client.Info(r.section).Result()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if !strings.Contains(info, r.expected) {
+					t.Errorf("%s is not found in %s", r.expected, info)
+				}
+			})
+		}
+	})
+
+	t.Run("SLOWLOG", func(t *testing.T) {
+		if err := client.ConfigSet("slowlog-log-slower-than", "0").Err(); err != nil {
+			t.Error(err)
+			return
+		}
+		defer client.ConfigSet("slowlog-log-slower-than", "10000")
+		if err := client.Do("SLOWLOG", "RESET").Err(); err != nil {
+			t.Error(err)
+			return
+		}
+		if err := client.Echo("slowlog").Err(); err != nil {
+			t.Error(err)
+			return
+		}
+		entries, err := client.Do("SLOWLOG", "GET", 1).Result()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		entryArray, ok := entries.([]interface{})
+		if !ok || len(entryArray) != 1 {
+			t.Errorf("invalid entries (%v)", entries)
+			return
+		}
+		entry, ok := entryArray[0].([]interface{})
+		if !ok || len(entry) != 6 {
+			t.Errorf("invalid entry (%v)", entryArray[0])
+			return
+		}
+		args, ok := entry[3].([]interface{})
+		if !ok || len(args) != 2 || args[1] != "slowlog" {
+			t.Errorf("invalid arguments (%v)", entry[3])
+		}
+	})
+
+	t.Run("COMMAND", func(t *testing.T) {
+		count, err := client.Do("COMMAND", "COUNT").Int64()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if count <= 0 {
+			t.Errorf("invalid command count (%d)", count)
+		}
+		infos, err := client.Do("COMMAND", "INFO", "get").Result()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		infoArray, ok := infos.([]interface{})
+		if !ok || len(infoArray) != 1 {
+			t.Errorf("invalid command infos (%v)", infos)
+			return
+		}
+		info, ok := infoArray[0].([]interface{})
+		if !ok || len(info) != 10 || info[0] != "get" || info[1] != int64(2) {
+			t.Errorf("invalid command info (%v)", infoArray[0])
+		}
+		keys, err := client.Do("COMMAND", "GETKEYS", "mset", "a", "1", "b", "2").Result()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		keyArray, ok := keys.([]interface{})
+		if !ok || len(keyArray) != 2 || keyArray[0] != "a" || keyArray[1] != "b" {
+			t.Errorf("invalid keys (%v)", keys)
+		}
+	})
+
+	t.Run("MONITOR", func(t *testing.T) {
+		monitor, err := net.Dial("tcp", net.JoinHostPort(LocalHost, strconv.Itoa(DefaultPort)))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer monitor.Close()
+		if _, err := monitor.Write([]byte("*1\r\n$7\r\nMONITOR\r\n")); err != nil {
+			t.Error(err)
+			return
+		}
+		monitor.SetReadDeadline(time.Now().Add(time.Second * 5))
+		reader := bufio.NewReader(monitor)
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if line != "+OK\r\n" {
+			t.Errorf("%s != %s", line, "+OK")
+			return
+		}
+
+		cmds := []struct {
+			args     []interface{}
+			expected string
+		}{
+			{[]interface{}{"ECHO", "hello \"world\""}, `"ECHO" "hello \"world\""`},
+			{[]interface{}{"AUTH", "password"}, `"AUTH" "(redacted)"`},
+		}
+		for _, cmd := range cmds {
+			client.Do(cmd.args...)
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if !strings.HasPrefix(line, "+") || !strings.HasSuffix(line, cmd.expected+"\r\n") {
+				t.Errorf("%s is not ended with %s", line, cmd.expected)
+			}
+		}
 	})
 }
 
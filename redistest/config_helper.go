@@ -0,0 +1,82 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redistest
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// ConfigConcurrencyTest runs CONFIG SET, CONFIG GET and AUTH commands from many clients concurrently.
+// The test should be run with the race detector.
+func ConfigConcurrencyTest(t *testing.T, server *Server) {
+	t.Helper()
+
+	const (
+		clientCount = 16
+		loopCount   = 50
+	)
+
+	requirePass := "password"
+	server.SetRequirePass(requirePass)
+	defer server.RemoveRequirePass()
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, clientCount)
+	for n := 0; n < clientCount; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			client := NewClient()
+			opts := NewClientOptions()
+			opts.Password = requirePass
+			if err := client.OpenWith(LocalHost, &opts); err != nil {
+				errCh <- err
+				return
+			}
+			defer client.Close()
+			for i := 0; i < loopCount; i++ {
+				maxLen := strconv.Itoa(128 + (n*loopCount+i)%128)
+				if err := client.ConfigSet("slowlog-max-len", maxLen).Err(); err != nil {
+					errCh <- err
+					return
+				}
+				if err := client.ConfigSet("requirepass", requirePass).Err(); err != nil {
+					errCh <- err
+					return
+				}
+				if err := client.Do("AUTH", requirePass).Err(); err != nil {
+					errCh <- err
+					return
+				}
+				if err := client.ConfigGet("slowlog-*").Err(); err != nil {
+					errCh <- err
+					return
+				}
+			}
+		}(n)
+	}
+	wg.Wait()
+	close(errCh)
+
+	for err := range errCh {
+		t.Error(err)
+	}
+
+	if err := server.SetConfigs(map[string]string{"slowlog-max-len": "128"}); err != nil {
+		t.Error(err)
+	}
+}
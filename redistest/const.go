@@ -17,4 +17,5 @@ package redistest
 const (
 	LocalHost   = "localhost"
 	DefaultPort = 6379
+	MetricsPort = 9121
 )
@@ -0,0 +1,133 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redistest
+
+import (
+	"strings"
+	"testing"
+)
+
+// FunctionTest tests the function commands with the specified client.
+// nolint: gocyclo, maintidx
+func FunctionTest(t *testing.T, client *Client) {
+	t.Helper()
+
+	code := "#!lua name=func_lib\n" +
+		"local function set(keys, args) return redis.call('SET', keys[1], args[1]) end\n" +
+		"local function get(keys, args) return redis.call('GET', keys[1]) end\n" +
+		"redis.register_function('func_set', set)\n" +
+		"redis.register_function{function_name='func_get', callback=get, flags={'no-writes'}, description='gets a key'}"
+
+	// FUNCTION LOAD
+
+	name, err := client.Do("FUNCTION", "LOAD", code).Result()
+	if err != nil || name != "func_lib" {
+		t.Errorf("FUNCTION LOAD = %v (%v)", name, err)
+	}
+	_, err = client.Do("FUNCTION", "LOAD", code).Result()
+	if err == nil {
+		t.Errorf("FUNCTION LOAD of existing library is succeeded")
+	}
+	name, err = client.Do("FUNCTION", "LOAD", "REPLACE", code).Result()
+	if err != nil || name != "func_lib" {
+		t.Errorf("FUNCTION LOAD REPLACE = %v (%v)", name, err)
+	}
+	_, err = client.Do("FUNCTION", "LOAD", "#!lua name=func_lib2\nredis.register_function('func_set', function() end)").Result()
+	if err == nil {
+		t.Errorf("FUNCTION LOAD of existing function is succeeded")
+	}
+	_, err = client.Do("FUNCTION", "LOAD", "return 1").Result()
+	if err == nil {
+		t.Errorf("FUNCTION LOAD without metadata is succeeded")
+	}
+
+	// FCALL and FCALL_RO
+
+	ret, err := client.Do("FCALL", "func_set", 1, "func_key1", "value1").Result()
+	if err != nil || ret != "OK" {
+		t.Errorf("FCALL = %v (%v)", ret, err)
+	}
+	ret, err = client.Do("FCALL_RO", "func_get", 1, "func_key1").Result()
+	if err != nil || ret != "value1" {
+		t.Errorf("FCALL_RO = %v (%v)", ret, err)
+	}
+	_, err = client.Do("FCALL_RO", "func_set", 1, "func_key1", "value2").Result()
+	if err == nil {
+		t.Errorf("FCALL_RO of write function is succeeded")
+	}
+	_, err = client.Do("FCALL", "func_none", 0).Result()
+	if err == nil {
+		t.Errorf("FCALL of unknown function is succeeded")
+	}
+
+	// FUNCTION LIST
+
+	ret, err = client.Do("FUNCTION", "LIST", "LIBRARYNAME", "func_*", "WITHCODE").Result()
+	libs, ok := ret.([]any)
+	if err != nil || !ok || len(libs) != 1 {
+		t.Errorf("FUNCTION LIST = %v (%v)", ret, err)
+	} else if fields, ok := libs[0].([]any); !ok || len(fields) != 8 || fields[1] != "func_lib" || fields[7] != code {
+		t.Errorf("FUNCTION LIST = %v", libs[0])
+	}
+
+	// FUNCTION STATS
+
+	ret, err = client.Do("FUNCTION", "STATS").Result()
+	if stats, ok := ret.([]any); err != nil || !ok || len(stats) != 4 || stats[1] != nil {
+		t.Errorf("FUNCTION STATS = %v (%v)", ret, err)
+	}
+
+	// FUNCTION DUMP and FUNCTION RESTORE
+
+	payload, err := client.Do("FUNCTION", "DUMP").String()
+	if err != nil {
+		t.Error(err)
+	}
+	if err := client.Do("FUNCTION", "FLUSH").Err(); err != nil {
+		t.Error(err)
+	}
+	_, err = client.Do("FCALL", "func_get", 1, "func_key1").Result()
+	if err == nil {
+		t.Errorf("FCALL of flushed function is succeeded")
+	}
+	if err := client.Do("FUNCTION", "RESTORE", payload).Err(); err != nil {
+		t.Error(err)
+	}
+	err = client.Do("FUNCTION", "RESTORE", payload, "APPEND").Err()
+	if err == nil {
+		t.Errorf("FUNCTION RESTORE APPEND of existing library is succeeded")
+	}
+	if err := client.Do("FUNCTION", "RESTORE", payload, "REPLACE").Err(); err != nil {
+		t.Error(err)
+	}
+	ret, err = client.Do("FCALL", "func_get", 1, "func_key1").Result()
+	if err != nil || ret != "value1" {
+		t.Errorf("FCALL = %v (%v)", ret, err)
+	}
+
+	// FUNCTION DELETE
+
+	if err := client.Do("FUNCTION", "DELETE", "func_lib").Err(); err != nil {
+		t.Error(err)
+	}
+	err = client.Do("FUNCTION", "DELETE", "func_lib").Err()
+	if err == nil || !strings.Contains(err.Error(), "Library not found") {
+		t.Errorf("FUNCTION DELETE = %v", err)
+	}
+
+	if err := client.Del("func_key1").Err(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redistest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cybergarage/go-redis/redis"
+)
+
+func MetricsTest(t *testing.T, server *Server) {
+	t.Helper()
+
+	url := fmt.Sprintf("http://%s:%d%s", LocalHost, server.ConfigMetricsPort(), redis.MetricsPath)
+	res, err := http.Get(url) // nolint: noctx
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("%d != %d", res.StatusCode, http.StatusOK)
+		return
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	metrics := []string{
+		"redis_connected_clients ",
+		"redis_net_input_bytes_total ",
+		"redis_net_output_bytes_total ",
+		"redis_auth_failures_total ",
+		"redis_commands_total{cmd=\"set\",result=\"ok\"} ",
+		"redis_command_duration_seconds_bucket{cmd=\"set\",le=\"+Inf\"} ",
+		"redis_command_duration_seconds_count{cmd=\"set\"} ",
+		"redis_db_keys{db=\"db1\"} ",
+	}
+	for _, metric := range metrics {
+		if !strings.Contains(string(body), metric) {
+			t.Errorf("%s is not found", metric)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redistest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cybergarage/go-redis/redis"
+	"github.com/cybergarage/go-redis/redis/proxy"
+	goredis "github.com/go-redis/redis"
+)
+
+// ProxyUpstreamPorts is the ports of the upstream servers which ProxyTest starts.
+// The first two servers are the primaries of the shards and the last server is the replica of the first shard.
+var ProxyUpstreamPorts = []int{6396, 6397, 6398}
+
+// ProxyPort is the port of the proxy server which ProxyTest starts.
+const ProxyPort = 6399
+
+// newProxyUpstreamClient returns a client of the specified upstream server on the specified database.
+func newProxyUpstreamClient(addr string, db int) *Client {
+	opts := NewClientOptions()
+	opts.Addr = addr
+	opts.DB = db
+	return &Client{Client: goredis.NewClient(&opts)}
+}
+
+// ProxyTest tests the proxy server in front of the upstream servers on the specified ports.
+// nolint: gocyclo, maintidx
+func ProxyTest(t *testing.T, upstreamPorts []int, proxyPort int) {
+	t.Helper()
+
+	addrs := []string{}
+	for n, port := range upstreamPorts {
+		server := NewServer()
+		server.SetPort(port)
+		if n == 2 {
+			server.SetReplicaOf(LocalHost, upstreamPorts[0])
+		}
+		if err := server.Start(); err != nil {
+			t.Error(err)
+			return
+		}
+		defer server.Stop()
+		addrs = append(addrs, fmt.Sprintf("%s:%d", LocalHost, port))
+	}
+
+	shards := []*proxy.Shard{
+		proxy.NewShard(proxy.NewUpstream(addrs[0]), proxy.NewUpstream(addrs[2])),
+		proxy.NewShard(proxy.NewUpstream(addrs[1])),
+	}
+	px := proxy.NewProxy(shards...)
+	defer px.Close()
+
+	requirePass := "proxy_password"
+	proxyServer := redis.NewServer()
+	proxyServer.SetPort(proxyPort)
+	proxyServer.SetRequirePass(requirePass)
+	px.Register(proxyServer)
+	if err := proxyServer.Start(); err != nil {
+		t.Error(err)
+		return
+	}
+	defer proxyServer.Stop()
+
+	// Authentication in front of the upstream servers
+
+	opts := NewClientOptions()
+	opts.Addr = fmt.Sprintf("%s:%d", LocalHost, proxyPort)
+	noAuthClient := &Client{Client: goredis.NewClient(&opts)}
+	err := noAuthClient.Set("proxy_key", "val", 0).Err()
+	if err == nil || !strings.HasPrefix(err.Error(), "NOAUTH") {
+		t.Errorf("proxy accepted a command without authentication (%v)", err)
+	}
+	noAuthClient.Close()
+
+	opts.Password = requirePass
+	client := &Client{Client: goredis.NewClient(&opts)}
+	defer client.Close()
+
+	// Key-based sharding
+
+	keys := []string{}
+	for n := 0; n < 8; n++ {
+		key := fmt.Sprintf("proxy_key%d", n)
+		if err := client.Set(key, key, 0).Err(); err != nil {
+			t.Error(err)
+			return
+		}
+		keys = append(keys, key)
+	}
+	keysByShard := map[*proxy.Shard]string{}
+	for _, key := range keys {
+		shard := px.ShardOf(key)
+		keysByShard[shard] = key
+		for _, s := range shards {
+			upstream := newProxyUpstreamClient(s.Primary().Addr(), opts.DB)
+			n, err := upstream.Exists(key).Result()
+			if err != nil || (s == shard) != (n == 1) {
+				t.Errorf("%s is not stored in the shard (%d, %v)", key, n, err)
+			}
+			upstream.Close()
+		}
+		if ret, err := client.Get(key).Result(); err != nil || ret != key {
+			t.Errorf("%s = %s (%v)", key, ret, err)
+		}
+	}
+	if len(keysByShard) != len(shards) {
+		t.Errorf("keys are not distributed to the shards")
+		return
+	}
+
+	// Selected database
+
+	upstream := newProxyUpstreamClient(shards[1].Primary().Addr(), 0)
+	if n, err := upstream.Exists(keysByShard[shards[1]]).Result(); err != nil || n != 0 {
+		t.Errorf("%s is stored in the database 0 (%v)", keysByShard[shards[1]], err)
+	}
+	upstream.Close()
+
+	// Multi-key commands
+
+	err = client.MGet(keysByShard[shards[0]], keysByShard[shards[1]]).Err()
+	if err == nil || !strings.HasPrefix(err.Error(), "CROSSSLOT") {
+		t.Errorf("MGET across the shards is accepted (%v)", err)
+	}
+	if err := client.MSet("{proxy_tag}1", "v1", "{proxy_tag}2", "v2").Err(); err != nil {
+		t.Error(err)
+	}
+	if vals, err := client.MGet("{proxy_tag}1", "{proxy_tag}2").Result(); err != nil || len(vals) != 2 || vals[1] != "v2" {
+		t.Errorf("MGET = %v (%v)", vals, err)
+	}
+
+	// Error replies of the upstream servers
+
+	err = client.Incr(keys[0]).Err()
+	if err == nil || !strings.HasPrefix(err.Error(), "ERR") {
+		t.Errorf("INCR of a non-integer value is accepted (%v)", err)
+	}
+
+	// Read/write splitting
+
+	primary := newProxyUpstreamClient(addrs[0], opts.DB)
+	defer primary.Close()
+	if n, err := primary.Do("WAIT", 1, 5000).Int64(); err != nil || n != 1 {
+		t.Errorf("WAIT = %d (%v)", n, err)
+	}
+	replica := newProxyUpstreamClient(addrs[2], opts.DB)
+	defer replica.Close()
+	px.SetReadFromReplicas(true)
+	if ret, err := client.Get(keysByShard[shards[0]]).Result(); err != nil || ret != keysByShard[shards[0]] {
+		t.Errorf("%s = %s (%v)", keysByShard[shards[0]], ret, err)
+	}
+	info, err := replica.Info("commandstats").Result()
+	if err != nil || !strings.Contains(info, "cmdstat_get:") {
+		t.Errorf("GET is not forwarded to the replica (%v)", err)
+	}
+	if err := client.Set(keysByShard[shards[0]], "newval", 0).Err(); err != nil {
+		t.Errorf("SET is not forwarded to the primary (%v)", err)
+	}
+	px.SetReadFromReplicas(false)
+
+	// Per-command routing
+
+	px.SetRoute("KEYS", shards[1])
+	routedKeys, err := client.Keys("proxy_key*").Result()
+	if err != nil || len(routedKeys) == 0 {
+		t.Errorf("KEYS = %v (%v)", routedKeys, err)
+	}
+	for _, key := range routedKeys {
+		if px.ShardOf(key) != shards[1] {
+			t.Errorf("KEYS is not forwarded to the routed shard (%s)", key)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redistest
+
+import (
+	"testing"
+	"time"
+
+	goredis "github.com/go-redis/redis"
+)
+
+// PubSubTest tests the pub/sub commands with the specified client.
+// nolint: gocyclo
+func PubSubTest(t *testing.T, client *Client) {
+	t.Helper()
+
+	sub := client.Subscribe("pubsub_ch1")
+	defer sub.Close()
+	if _, err := sub.ReceiveTimeout(time.Second); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := sub.PSubscribe("pubsub_p*"); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := sub.ReceiveTimeout(time.Second); err != nil {
+		t.Error(err)
+		return
+	}
+
+	// PUBSUB
+
+	channels, err := client.PubSubChannels("pubsub_*").Result()
+	if err != nil || len(channels) != 1 || channels[0] != "pubsub_ch1" {
+		t.Errorf("PUBSUB CHANNELS = %v (%v)", channels, err)
+	}
+	numSub, err := client.PubSubNumSub("pubsub_ch1", "pubsub_ch2").Result()
+	if err != nil || numSub["pubsub_ch1"] != 1 || numSub["pubsub_ch2"] != 0 {
+		t.Errorf("PUBSUB NUMSUB = %v (%v)", numSub, err)
+	}
+	numPat, err := client.PubSubNumPat().Result()
+	if err != nil || numPat != 1 {
+		t.Errorf("PUBSUB NUMPAT = %d (%v)", numPat, err)
+	}
+
+	// PUBLISH
+
+	n, err := client.Publish("pubsub_ch1", "msg1").Result()
+	if err != nil || n != 1 {
+		t.Errorf("PUBLISH = %d (%v)", n, err)
+	}
+	msg, err := sub.ReceiveTimeout(time.Second)
+	if m, ok := msg.(*goredis.Message); err != nil || !ok || m.Channel != "pubsub_ch1" || m.Payload != "msg1" {
+		t.Errorf("message = %v (%v)", msg, err)
+	}
+
+	n, err = client.Publish("pubsub_pch", "msg2").Result()
+	if err != nil || n != 1 {
+		t.Errorf("PUBLISH = %d (%v)", n, err)
+	}
+	msg, err = sub.ReceiveTimeout(time.Second)
+	if m, ok := msg.(*goredis.Message); err != nil || !ok || m.Pattern != "pubsub_p*" || m.Payload != "msg2" {
+		t.Errorf("pmessage = %v (%v)", msg, err)
+	}
+
+	// UNSUBSCRIBE
+
+	if err := sub.Unsubscribe("pubsub_ch1"); err != nil {
+		t.Error(err)
+	}
+	if _, err := sub.ReceiveTimeout(time.Second); err != nil {
+		t.Error(err)
+	}
+	n, err = client.Publish("pubsub_ch1", "msg3").Result()
+	if err != nil || n != 0 {
+		t.Errorf("PUBLISH = %d (%v)", n, err)
+	}
+}
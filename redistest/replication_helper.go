@@ -0,0 +1,196 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redistest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	goredis "github.com/go-redis/redis"
+)
+
+// waitReplicationInfo waits until the INFO replication of the specified client has the specified line.
+func waitReplicationInfo(client *Client, line string) bool {
+	for n := 0; n < 100; n++ {
+		info, err := client.Info("replication").Result()
+		if err == nil && strings.Contains(info, line) {
+			return true
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return false
+}
+
+// waitReplicatedValue waits until the specified key of the specified client has the specified value.
+func waitReplicatedValue(client *Client, key string, val string) bool {
+	for n := 0; n < 100; n++ {
+		if ret, err := client.Get(key).Result(); err == nil && ret == val {
+			return true
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return false
+}
+
+// waitReplicatedFunction waits until the specified function of the specified client returns the specified value.
+func waitReplicatedFunction(client *Client, name string, val string) bool {
+	for n := 0; n < 100; n++ {
+		if ret, err := client.Do("FCALL_RO", name, 0).Result(); err == nil && ret == val {
+			return true
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return false
+}
+
+// newReplicationLibrary returns a library code which has a read-only function returning the specified value.
+func newReplicationLibrary(name string, fn string, val string) string {
+	return "#!lua name=" + name + "\n" +
+		"redis.register_function{function_name='" + fn + "', callback=function() return '" + val + "' end, flags={'no-writes'}}"
+}
+
+// ReplicationTest tests the replication from the specified master server to a new replica server.
+// nolint: gocyclo
+func ReplicationTest(t *testing.T, server *Server) {
+	t.Helper()
+
+	master := NewClient()
+	if err := master.Open(LocalHost); err != nil {
+		t.Error(err)
+		return
+	}
+	defer master.Close()
+
+	if err := master.Set("repl_key1", "val1", 0).Err(); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := master.Do("FUNCTION", "LOAD", newReplicationLibrary("repl_lib1", "repl_f1", "val1")).Err(); err != nil {
+		t.Error(err)
+		return
+	}
+
+	replica := NewServer()
+	replica.SetPort(0)
+	replica.SetReplicaOf(LocalHost, DefaultPort)
+	if err := replica.Start(); err != nil {
+		t.Error(err)
+		return
+	}
+	defer replica.Stop()
+
+	opts := NewClientOptions()
+	opts.Addr = replica.ListenAddrs()[0]
+	client := &Client{Client: goredis.NewClient(&opts)}
+	defer client.Close()
+
+	if !waitReplicationInfo(client, "master_link_status:up") {
+		t.Errorf("replica is not connected to the master")
+		return
+	}
+
+	// Full resynchronization
+
+	if !waitReplicatedValue(client, "repl_key1", "val1") {
+		t.Errorf("repl_key1 is not replicated")
+	}
+	if !waitReplicatedFunction(client, "repl_f1", "val1") {
+		t.Errorf("repl_lib1 is not replicated")
+	}
+
+	// Replication stream
+
+	if err := master.Set("repl_key2", "val2", 0).Err(); err != nil {
+		t.Error(err)
+		return
+	}
+	n, err := master.Do("WAIT", 1, 5000).Int64()
+	if err != nil || n != 1 {
+		t.Errorf("WAIT = %d (%v)", n, err)
+	}
+	if !waitReplicatedValue(client, "repl_key2", "val2") {
+		t.Errorf("repl_key2 is not replicated")
+	}
+	if err := master.Do("FUNCTION", "LOAD", newReplicationLibrary("repl_lib2", "repl_f2", "val2")).Err(); err != nil {
+		t.Error(err)
+		return
+	}
+	if !waitReplicatedFunction(client, "repl_f2", "val2") {
+		t.Errorf("repl_lib2 is not replicated")
+	}
+
+	// Read only replica
+
+	err = client.Set("repl_key3", "val3", 0).Err()
+	if err == nil || !strings.HasPrefix(err.Error(), "READONLY") {
+		t.Errorf("replica accepted a write command (%v)", err)
+	}
+
+	// ROLE
+
+	role, err := master.Do("ROLE").Result()
+	if roles, ok := role.([]any); err != nil || !ok || len(roles) != 3 || roles[0] != "master" {
+		t.Errorf("master ROLE = %v (%v)", role, err)
+	}
+	role, err = client.Do("ROLE").Result()
+	if roles, ok := role.([]any); err != nil || !ok || len(roles) != 5 || roles[0] != "slave" {
+		t.Errorf("replica ROLE = %v (%v)", role, err)
+	}
+
+	// INFO replication
+
+	if !waitReplicationInfo(master, "connected_slaves:1") {
+		t.Errorf("master has no connected replica")
+	}
+
+	// Partial resynchronization
+
+	if err := replica.Stop(); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := master.Set("repl_key4", "val4", 0).Err(); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := replica.Start(); err != nil {
+		t.Error(err)
+		return
+	}
+	opts.Addr = replica.ListenAddrs()[0]
+	client.Close()
+	client = &Client{Client: goredis.NewClient(&opts)}
+	if !waitReplicatedValue(client, "repl_key4", "val4") {
+		t.Errorf("repl_key4 is not replicated")
+	}
+	info, err := master.Info("stats").Result()
+	if err != nil || !strings.Contains(info, "sync_partial_ok:1") {
+		t.Errorf("partial resynchronization is not accepted")
+	}
+
+	// REPLICAOF NO ONE
+
+	if err := client.Do("REPLICAOF", "NO", "ONE").Err(); err != nil {
+		t.Error(err)
+	}
+	if err := client.Set("repl_key3", "val3", 0).Err(); err != nil {
+		t.Error(err)
+	}
+
+	master.Del("repl_key1", "repl_key2", "repl_key4")
+	master.Do("FUNCTION", "DELETE", "repl_lib1")
+	master.Do("FUNCTION", "DELETE", "repl_lib2")
+}
@@ -0,0 +1,156 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redistest
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// ScriptTest tests the scripting commands with the specified client.
+// nolint: gocyclo, maintidx
+func ScriptTest(t *testing.T, client *Client) {
+	t.Helper()
+
+	// EVAL
+
+	body := "redis.call('SET', KEYS[1], ARGV[1]); return redis.call('GET', KEYS[1])"
+	ret, err := client.Eval(body, []string{"script_key1"}, "value1").Result()
+	if err != nil || ret != "value1" {
+		t.Errorf("EVAL = %v (%v)", ret, err)
+	}
+	val, err := client.Get("script_key1").Result()
+	if err != nil || val != "value1" {
+		t.Errorf("GET = %v (%v)", val, err)
+	}
+
+	ret, err = client.Eval("return {1, 'two', {3}, false, true}", []string{}).Result()
+	if vals, ok := ret.([]interface{}); err != nil || !ok || len(vals) != 5 || vals[0] != int64(1) || vals[1] != "two" || vals[3] != nil || vals[4] != int64(1) {
+		t.Errorf("EVAL = %v (%v)", ret, err)
+	}
+	ret, err = client.Eval("return redis.status_reply('DONE')", []string{}).Result()
+	if err != nil || ret != "DONE" {
+		t.Errorf("EVAL = %v (%v)", ret, err)
+	}
+	_, err = client.Eval("return redis.error_reply('MYERR failed')", []string{}).Result()
+	if err == nil || err.Error() != "MYERR failed" {
+		t.Errorf("EVAL = %v", err)
+	}
+
+	// redis.call raises the errors, and redis.pcall returns them.
+
+	_, err = client.Eval("return redis.call('INCR', KEYS[1])", []string{"script_key1"}).Result()
+	if err == nil {
+		t.Errorf("EVAL INCR is succeeded")
+	}
+	ret, err = client.Eval("local r = redis.pcall('INCR', KEYS[1]); return type(r['err'])", []string{"script_key1"}).Result()
+	if err != nil || ret != "string" {
+		t.Errorf("EVAL = %v (%v)", ret, err)
+	}
+	_, err = client.Eval("return redis.call('EVAL', 'return 1', 0)", []string{}).Result()
+	if err == nil {
+		t.Errorf("EVAL in script is succeeded")
+	}
+	_, err = client.Eval("return 1 +", []string{}).Result()
+	if err == nil {
+		t.Errorf("EVAL of invalid script is succeeded")
+	}
+
+	// EVAL_RO and no-writes flag
+
+	ret, err = client.Do("EVAL_RO", "return redis.call('GET', KEYS[1])", 1, "script_key1").Result()
+	if err != nil || ret != "value1" {
+		t.Errorf("EVAL_RO = %v (%v)", ret, err)
+	}
+	_, err = client.Do("EVAL_RO", "return redis.call('SET', KEYS[1], 'x')", 1, "script_key1").Result()
+	if err == nil {
+		t.Errorf("EVAL_RO SET is succeeded")
+	}
+	_, err = client.Eval("#!lua flags=no-writes\nreturn redis.call('DEL', KEYS[1])", []string{"script_key1"}).Result()
+	if err == nil {
+		t.Errorf("EVAL no-writes DEL is succeeded")
+	}
+	_, err = client.Do("EVAL", "return 1", -1).Result()
+	if err == nil {
+		t.Errorf("EVAL with negative numkeys is succeeded")
+	}
+
+	// SCRIPT LOAD, SCRIPT EXISTS and EVALSHA
+
+	sha, err := client.ScriptLoad("return ARGV[1]").Result()
+	if err != nil || len(sha) != 40 {
+		t.Errorf("SCRIPT LOAD = %v (%v)", sha, err)
+	}
+	ret, err = client.EvalSha(strings.ToUpper(sha), []string{}, "arg1").Result()
+	if err != nil || ret != "arg1" {
+		t.Errorf("EVALSHA = %v (%v)", ret, err)
+	}
+	exists, err := client.ScriptExists(sha, "0000000000000000000000000000000000000000").Result()
+	if err != nil || len(exists) != 2 || !exists[0] || exists[1] {
+		t.Errorf("SCRIPT EXISTS = %v (%v)", exists, err)
+	}
+
+	// SCRIPT FLUSH
+
+	if err := client.ScriptFlush().Err(); err != nil {
+		t.Error(err)
+	}
+	_, err = client.EvalSha(sha, []string{}).Result()
+	if err == nil || !strings.HasPrefix(err.Error(), "NOSCRIPT") {
+		t.Errorf("EVALSHA = %v", err)
+	}
+
+	// SCRIPT KILL
+
+	err = client.ScriptKill().Err()
+	if err == nil || !strings.HasPrefix(err.Error(), "NOTBUSY") {
+		t.Errorf("SCRIPT KILL = %v", err)
+	}
+
+	busyClient := NewClient()
+	if err := busyClient.Open(LocalHost); err != nil {
+		t.Error(err)
+		return
+	}
+	defer busyClient.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- busyClient.Eval("while true do end", []string{}).Err()
+	}()
+
+	killed := false
+	for n := 0; n < 50 && !killed; n++ {
+		time.Sleep(100 * time.Millisecond)
+		killed = client.ScriptKill().Err() == nil
+	}
+	if !killed {
+		t.Errorf("SCRIPT KILL is failed")
+		return
+	}
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "killed") {
+			t.Errorf("EVAL = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("killed script is running")
+	}
+
+	if err := client.Del("script_key1").Err(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright (C) 2022 Satoshi Konno All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redistest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cybergarage/go-redis/redis"
+	goredis "github.com/go-redis/redis"
+)
+
+const (
+	// SentinelHost is the host of the instances which SentinelTest monitors.
+	SentinelHost = "127.0.0.1"
+	// SentinelPort is the port of the sentinel which SentinelTest starts.
+	SentinelPort = 26379
+	// SentinelMasterName is the name of the master which SentinelTest monitors.
+	SentinelMasterName = "mymaster"
+)
+
+// SentinelInstancePorts is the ports of the master and the replicas which SentinelTest starts.
+var SentinelInstancePorts = []int{6393, 6394, 6395}
+
+// newSentinelInstanceClient returns a client of the instance on the specified port.
+func newSentinelInstanceClient(port int) *Client {
+	opts := NewClientOptions()
+	opts.Addr = fmt.Sprintf("%s:%d", SentinelHost, port)
+	return &Client{Client: goredis.NewClient(&opts)}
+}
+
+// waitSentinelReplicas waits until the sentinel discovers the specified number of the replicas.
+func waitSentinelReplicas(sentinel *goredis.SentinelClient, n int) bool {
+	for i := 0; i < 100; i++ {
+		replicas, err := sentinel.Do("SENTINEL", "REPLICAS", SentinelMasterName).Result()
+		if list, ok := replicas.([]any); err == nil && ok && len(list) == n {
+			discovered := 0
+			for _, replica := range list {
+				if fields, ok := replica.([]any); ok && strings.Contains(fmt.Sprint(fields), "role-reported slave") {
+					discovered++
+				}
+			}
+			if discovered == n {
+				return true
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return false
+}
+
+// SentinelTest tests the sentinel mode which fails over the master on the first specified port
+// to one of the replicas on the other specified ports.
+// nolint: gocyclo, maintidx
+func SentinelTest(t *testing.T, ports []int) {
+	t.Helper()
+
+	servers := []*Server{}
+	for n, port := range ports {
+		server := NewServer()
+		server.SetPort(port)
+		if 0 < n {
+			server.SetReplicaOf(SentinelHost, ports[0])
+		}
+		if err := server.Start(); err != nil {
+			t.Error(err)
+			return
+		}
+		defer server.Stop()
+		servers = append(servers, server)
+	}
+
+	sentinelServer := redis.NewServer()
+	sentinelServer.SetPort(SentinelPort)
+	sentinelServer.SetSentinelEnabled(true)
+	if err := sentinelServer.Sentinel().Monitor(SentinelMasterName, SentinelHost, ports[0], 1); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := sentinelServer.Sentinel().SetDownAfter(SentinelMasterName, 500*time.Millisecond); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := sentinelServer.Sentinel().SetFailoverTimeout(SentinelMasterName, 5*time.Second); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := sentinelServer.Start(); err != nil {
+		t.Error(err)
+		return
+	}
+	defer sentinelServer.Stop()
+
+	opts := NewClientOptions()
+	opts.Addr = fmt.Sprintf("%s:%d", SentinelHost, SentinelPort)
+	opts.DB = 0
+	sentinel := goredis.NewSentinelClient(&opts)
+	defer sentinel.Close()
+
+	master := newSentinelInstanceClient(ports[0])
+	defer master.Close()
+
+	// SENTINEL GET-MASTER-ADDR-BY-NAME
+
+	addr, err := sentinel.GetMasterAddrByName(SentinelMasterName).Result()
+	if err != nil || len(addr) != 2 || addr[0] != SentinelHost || addr[1] != strconv.Itoa(ports[0]) {
+		t.Errorf("SENTINEL GET-MASTER-ADDR-BY-NAME = %v (%v)", addr, err)
+		return
+	}
+
+	// SENTINEL REPLICAS
+
+	if !waitSentinelReplicas(sentinel, len(ports)-1) {
+		t.Errorf("sentinel does not discover the replicas")
+		return
+	}
+
+	// Commands not served by the sentinel
+
+	if err := sentinel.Do("GET", "sentinel_key").Err(); err == nil || !strings.HasPrefix(err.Error(), "ERR unknown command") {
+		t.Errorf("sentinel accepted a data command (%v)", err)
+	}
+
+	// ROLE
+
+	role, err := sentinel.Do("ROLE").Result()
+	if roles, ok := role.([]any); err != nil || !ok || len(roles) != 2 || roles[0] != redis.SentinelRole {
+		t.Errorf("sentinel ROLE = %v (%v)", role, err)
+	}
+
+	// Failover
+
+	if err := master.Set("sentinel_key", "val", 0).Err(); err != nil {
+		t.Error(err)
+		return
+	}
+	if n, err := master.Do("WAIT", len(ports)-1, 5000).Int64(); err != nil || n != int64(len(ports)-1) {
+		t.Errorf("WAIT = %d (%v)", n, err)
+	}
+
+	sub := sentinel.Subscribe("+switch-master")
+	defer sub.Close()
+	if _, err := sub.ReceiveTimeout(time.Second); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if err := servers[0].Stop(); err != nil {
+		t.Error(err)
+		return
+	}
+
+	msg, err := sub.ReceiveTimeout(10 * time.Second)
+	if err != nil {
+		t.Errorf("+switch-master is not published (%v)", err)
+		return
+	}
+	switchMsg, ok := msg.(*goredis.Message)
+	if !ok {
+		t.Errorf("+switch-master = %v", msg)
+		return
+	}
+	fields := strings.Fields(switchMsg.Payload)
+	if len(fields) != 5 || fields[0] != SentinelMasterName || fields[2] != strconv.Itoa(ports[0]) {
+		t.Errorf("+switch-master = %s", switchMsg.Payload)
+		return
+	}
+
+	addr, err = sentinel.GetMasterAddrByName(SentinelMasterName).Result()
+	if err != nil || len(addr) != 2 || addr[0] != fields[3] || addr[1] != fields[4] {
+		t.Errorf("SENTINEL GET-MASTER-ADDR-BY-NAME = %v (%v)", addr, err)
+		return
+	}
+
+	newPort, _ := strconv.Atoi(fields[4])
+	newMaster := newSentinelInstanceClient(newPort)
+	defer newMaster.Close()
+	if !waitReplicationInfo(newMaster, "role:master") {
+		t.Errorf("the replica is not promoted")
+	}
+	if ret, err := newMaster.Get("sentinel_key").Result(); err != nil || ret != "val" {
+		t.Errorf("sentinel_key = %s (%v)", ret, err)
+	}
+	if err := newMaster.Set("sentinel_key", "newval", 0).Err(); err != nil {
+		t.Error(err)
+	}
+
+	// Reconfiguration of the other replicas and the old master
+
+	for _, port := range ports[1:] {
+		if port == newPort {
+			continue
+		}
+		replica := newSentinelInstanceClient(port)
+		if !waitReplicationInfo(replica, fmt.Sprintf("master_port:%d", newPort)) {
+			t.Errorf("the replica on %d is not reconfigured", port)
+		}
+		replica.Close()
+	}
+
+	if err := servers[0].Start(); err != nil {
+		t.Error(err)
+		return
+	}
+	if !waitReplicationInfo(master, fmt.Sprintf("master_port:%d", newPort)) {
+		t.Errorf("the old master is not converted to a replica")
+	}
+	if !waitReplicatedValue(master, "sentinel_key", "newval") {
+		t.Errorf("sentinel_key is not replicated to the old master")
+	}
+
+	newMaster.Del("sentinel_key")
+}
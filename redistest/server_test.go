@@ -21,6 +21,7 @@ import (
 // nolint: maintidx, gocyclo
 func TestServer(t *testing.T) {
 	server := NewServer()
+	server.SetMetricsPort(MetricsPort)
 
 	err := server.Start()
 	if err != nil {
@@ -32,6 +33,12 @@ func TestServer(t *testing.T) {
 
 	AuthCommandTest(t, server)
 
+	// ConfigConcurrencyTest
+
+	t.Run("ConfigConcurrency", func(t *testing.T) {
+		ConfigConcurrencyTest(t, server)
+	})
+
 	// CommandTest
 
 	client := NewClient()
@@ -45,6 +52,36 @@ func TestServer(t *testing.T) {
 		CommandTest(t, client)
 	})
 
+	// PubSubTest
+
+	t.Run("PubSub", func(t *testing.T) {
+		PubSubTest(t, client)
+	})
+
+	// ScriptTest
+
+	t.Run("Script", func(t *testing.T) {
+		ScriptTest(t, client)
+	})
+
+	// FunctionTest
+
+	t.Run("Function", func(t *testing.T) {
+		FunctionTest(t, client)
+	})
+
+	// MetricsTest
+
+	t.Run("Metrics", func(t *testing.T) {
+		MetricsTest(t, server)
+	})
+
+	// ReplicationTest
+
+	t.Run("Replication", func(t *testing.T) {
+		ReplicationTest(t, server)
+	})
+
 	// // panic: not implemented
 	// err = client.Quit().Err()
 	// if err != nil {